@@ -0,0 +1,341 @@
+/*
+Package alertmanager manages incidents with alerts of the Prometheus Alertmanager.
+
+A Handler receives the webhooks of the Alertmanager. Alerts are mapped by a label
+to components and by their severity to component statuses:
+
+	h := alertmanager.NewHandler(client, &alertmanager.Options{
+		Components: map[string]int{"api": 3, "website": 4},
+	})
+	http.Handle("/alertmanager", h)
+
+with the receiver
+
+	receivers:
+	  - name: cachet
+	    webhook_configs:
+	      - url: http://localhost:8080/alertmanager
+	        send_resolved: true
+
+A firing alert creates an incident for its component or updates the open one, which keeps its status.
+The component gets the status of the most severe firing alert.
+Once all alerts of a component are resolved, the incident is fixed and the component is operational again.
+Alerts without component are ignored.
+*/
+package alertmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/dedup"
+)
+
+// Statuses of alerts and messages.
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// DefaultSeverities maps the severity label to component statuses.
+var DefaultSeverities = map[string]int{
+	"critical": cachet.ComponentStatusMajorOutage,
+	"error":    cachet.ComponentStatusPartialOutage,
+	"warning":  cachet.ComponentStatusPerformanceIssues,
+}
+
+// Message is the payload of an Alertmanager webhook.
+type Message struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// Alert is a single alert of a webhook.
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Options configures the mapping of alerts.
+type Options struct {
+	// ComponentLabel is the label naming the component. It defaults to "component".
+	ComponentLabel string
+	// Components maps values of the component label to component IDs.
+	// Values missing here are used as component ID if they are numeric.
+	Components map[string]int
+	// SeverityLabel is the label with the severity. It defaults to "severity".
+	SeverityLabel string
+	// Severities overrides DefaultSeverities.
+	Severities map[string]int
+	// DefaultStatus is the component status of unknown severities.
+	// It defaults to cachet.ComponentStatusPartialOutage.
+	DefaultStatus int
+	// Visible is the visibility of new incidents. It defaults to cachet.IncidentVisibilityPublic.
+	Visible int
+	// Notify notifies subscribers about new incidents.
+	Notify bool
+	// Ensurer finds and creates the incidents of components. Share one Ensurer between all
+	// integrations of an instance, so their changes to the same component are serialized.
+	// It defaults to an Ensurer of the handler only.
+	Ensurer *dedup.Ensurer
+}
+
+// Handler is an http.Handler receiving Alertmanager webhooks.
+type Handler struct {
+	client  *cachet.Client
+	options Options
+	ensurer *dedup.Ensurer
+
+	// mu serializes the processing of webhooks and guards firing.
+	mu sync.Mutex
+	// firing maps component IDs to the fingerprints of their firing alerts and the component status of each.
+	firing map[int]map[string]int
+}
+
+// NewHandler returns a new handler. o can be nil.
+func NewHandler(client *cachet.Client, o *Options) *Handler {
+	h := &Handler{client: client, firing: map[int]map[string]int{}}
+	if o != nil {
+		h.options = *o
+	}
+	h.ensurer = h.options.Ensurer
+	if h.ensurer == nil {
+		h.ensurer = dedup.NewEnsurer(client, nil)
+	}
+	if len(h.options.ComponentLabel) == 0 {
+		h.options.ComponentLabel = "component"
+	}
+	if len(h.options.SeverityLabel) == 0 {
+		h.options.SeverityLabel = "severity"
+	}
+	if h.options.Severities == nil {
+		h.options.Severities = DefaultSeverities
+	}
+	if h.options.DefaultStatus == 0 {
+		h.options.DefaultStatus = cachet.ComponentStatusPartialOutage
+	}
+	if h.options.Visible == 0 {
+		h.options.Visible = cachet.IncidentVisibilityPublic
+	}
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	m := &Message{}
+	if err := json.NewDecoder(r.Body).Decode(m); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid webhook payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// The Alertmanager retries failed webhooks, already applied alerts are skipped then.
+	if err := h.Handle(m); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Handle applies all alerts of the message.
+// All alerts are tried, the first error is returned.
+func (h *Handler) Handle(m *Message) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var first error
+	for _, a := range m.Alerts {
+		id, ok := h.component(a)
+		if !ok {
+			continue
+		}
+
+		var err error
+		if a.Status == StatusResolved {
+			err = h.resolve(id, a)
+		} else {
+			err = h.fire(id, a)
+		}
+		if err != nil && first == nil {
+			first = fmt.Errorf("Failed to apply alert %q: %v", name(a), err)
+		}
+	}
+	return first
+}
+
+// fire creates or updates the incident of the component of a firing alert. Updates keep the status of the incident.
+// Repeated notifications of an alert without changes are skipped.
+func (h *Handler) fire(id int, a Alert) error {
+	status := h.severity(a)
+	key := fingerprint(a)
+	if previous, ok := h.firing[id][key]; ok && previous == status {
+		return nil
+	}
+
+	// The alert is only recorded once everything is applied, so a retried notification is not skipped.
+	alerts := map[string]int{key: status}
+	for k, v := range h.firing[id] {
+		if k != key {
+			alerts[k] = v
+		}
+	}
+	worst := worstStatus(alerts)
+
+	if _, _, err := h.ensurer.EnsureIncident(&cachet.Incident{
+		Name:            name(a),
+		Message:         message(a),
+		ComponentID:     id,
+		ComponentStatus: worst,
+		Visible:         h.options.Visible,
+		Notify:          h.options.Notify,
+	}); err != nil {
+		return err
+	}
+
+	if _, _, err := h.client.Components.Update(id, &cachet.Component{Status: worst}); err != nil {
+		return fmt.Errorf("Failed to update status of component %d: %v", id, err)
+	}
+	h.firing[id] = alerts
+	return nil
+}
+
+// resolve fixes the incident of the component once its last alert is resolved.
+// Otherwise the component gets the status of the remaining alerts.
+// Without known firing alerts (e.g. after a restart) the resolved alert is assumed to be the last one.
+func (h *Handler) resolve(id int, a Alert) error {
+	alerts := h.alerts(id)
+	delete(alerts, fingerprint(a))
+
+	return h.ensurer.WithOpenIncident(id, func(incident *cachet.Incident) error {
+		if incident == nil {
+			if len(alerts) == 0 {
+				delete(h.firing, id)
+			}
+			return nil
+		}
+
+		update := &cachet.IncidentUpdate{
+			Status:          cachet.IncidentStatusFixed,
+			Message:         fmt.Sprintf("Resolved: %s", name(a)),
+			ComponentStatus: cachet.ComponentStatusOperational,
+		}
+		if len(alerts) > 0 {
+			update.Status = dedup.CurrentStatus(incident)
+			update.ComponentStatus = worstStatus(alerts)
+		}
+
+		if _, _, err := h.client.IncidentUpdates.Create(incident.ID, update); err != nil {
+			return fmt.Errorf("Failed to update incident %d: %v", incident.ID, err)
+		}
+		if update.Status == cachet.IncidentStatusFixed {
+			if _, _, err := h.client.Incidents.Update(incident.ID, &cachet.Incident{Status: cachet.IncidentStatusFixed}); err != nil {
+				return fmt.Errorf("Failed to fix incident %d: %v", incident.ID, err)
+			}
+			delete(h.firing, id)
+		}
+		if _, _, err := h.client.Components.Update(id, &cachet.Component{Status: update.ComponentStatus}); err != nil {
+			return fmt.Errorf("Failed to update status of component %d: %v", id, err)
+		}
+		return nil
+	})
+}
+
+// alerts returns the firing alerts of the component with the ID id.
+func (h *Handler) alerts(id int) map[string]int {
+	if h.firing[id] == nil {
+		h.firing[id] = map[string]int{}
+	}
+	return h.firing[id]
+}
+
+// component returns the component ID of the alert.
+func (h *Handler) component(a Alert) (int, bool) {
+	value, ok := a.Labels[h.options.ComponentLabel]
+	if !ok {
+		return 0, false
+	}
+	if id, ok := h.options.Components[value]; ok {
+		return id, true
+	}
+	id, err := strconv.Atoi(value)
+	return id, err == nil && id > 0
+}
+
+// severity returns the component status of the alert.
+func (h *Handler) severity(a Alert) int {
+	if status, ok := h.options.Severities[a.Labels[h.options.SeverityLabel]]; ok {
+		return status
+	}
+	return h.options.DefaultStatus
+}
+
+// worstStatus returns the most severe component status of alerts.
+func worstStatus(alerts map[string]int) int {
+	worst := cachet.ComponentStatusOperational
+	for _, status := range alerts {
+		if status > worst {
+			worst = status
+		}
+	}
+	return worst
+}
+
+// fingerprint identifies an alert. The Alertmanager sends it since 0.19, older versions are identified by their labels.
+func fingerprint(a Alert) string {
+	if len(a.Fingerprint) > 0 {
+		return a.Fingerprint
+	}
+	keys := make([]string, 0, len(a.Labels))
+	for k := range a.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+a.Labels[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// name returns the summary of an alert or its name.
+func name(a Alert) string {
+	if s := a.Annotations["summary"]; len(s) > 0 {
+		return s
+	}
+	if s := a.Labels["alertname"]; len(s) > 0 {
+		return s
+	}
+	return "Alert"
+}
+
+// message returns the description of an alert or its name.
+func message(a Alert) string {
+	if s := a.Annotations["description"]; len(s) > 0 {
+		return s
+	}
+	return name(a)
+}
@@ -0,0 +1,208 @@
+package alertmanager
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/dedup"
+	"github.com/andygrunwald/cachet/internal/cachettest"
+)
+
+// post sends the alerts as webhook to h and returns the status code.
+func post(h http.Handler, status string, alerts ...string) int {
+	body := fmt.Sprintf(`{"version":"4","status":%q,"alerts":[%s]}`, status, strings.Join(alerts, ","))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/alertmanager", strings.NewReader(body)))
+	return w.Code
+}
+
+func alert(status, fingerprint, severity string) string {
+	return fmt.Sprintf(`{"status":%q,"fingerprint":%q,"labels":{"alertname":"HighErrorRate","component":"api","severity":%q},
+		"annotations":{"summary":"High error rate","description":"More than 5%% errors"}}`, status, fingerprint, severity)
+}
+
+// newTestServer returns an instance with the component 3.
+func newTestServer() *cachettest.Server {
+	s := cachettest.NewServer()
+	s.Add("components", cachet.Component{ID: 3, Name: "API"})
+	return s
+}
+
+func TestHandler(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	h := NewHandler(s.Client, &Options{Components: map[string]int{"api": 3}})
+
+	steps := []struct {
+		name     string
+		status   string
+		alert    string
+		expected []cachettest.Write
+	}{
+		{
+			"first alert",
+			StatusFiring,
+			alert(StatusFiring, "a", "critical"),
+			[]cachettest.Write{
+				{Method: "POST", Path: "/api/v1/incidents", Body: map[string]interface{}{
+					"name": "High error rate", "message": "More than 5% errors", "status": float64(1), "visible": float64(1),
+					"component_id": float64(3), "component_status": float64(4),
+				}},
+				{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(4)}},
+			},
+		},
+		{
+			"second alert",
+			StatusFiring,
+			alert(StatusFiring, "b", "warning"),
+			[]cachettest.Write{
+				{Method: "POST", Path: "/api/v1/incidents/1/updates", Body: map[string]interface{}{
+					"message": "More than 5% errors", "status": float64(1), "component_id": float64(3), "component_status": float64(4),
+				}},
+				{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(4)}},
+			},
+		},
+		{"repeated alert", StatusFiring, alert(StatusFiring, "a", "critical"), nil},
+		{
+			"first resolved",
+			StatusResolved,
+			alert(StatusResolved, "a", "critical"),
+			[]cachettest.Write{
+				{Method: "POST", Path: "/api/v1/incidents/1/updates", Body: map[string]interface{}{
+					"message": "Resolved: High error rate", "status": float64(1), "component_status": float64(2),
+				}},
+				{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(2)}},
+			},
+		},
+		{
+			"last resolved",
+			StatusResolved,
+			alert(StatusResolved, "b", "warning"),
+			[]cachettest.Write{
+				{Method: "POST", Path: "/api/v1/incidents/1/updates", Body: map[string]interface{}{
+					"message": "Resolved: High error rate", "status": float64(4), "component_status": float64(1),
+				}},
+				{Method: "PUT", Path: "/api/v1/incidents/1", Body: map[string]interface{}{"status": float64(4)}},
+				{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(1)}},
+			},
+		},
+		{"resolved again", StatusResolved, alert(StatusResolved, "b", "warning"), nil},
+		{"unknown component", StatusFiring, `{"status":"firing","labels":{"component":"db"}}`, nil},
+	}
+
+	for _, step := range steps {
+		if code := post(h, step.status, step.alert); code != http.StatusOK {
+			t.Errorf("Handler returned %d for %s, want %d", code, step.name, http.StatusOK)
+		}
+		if got := s.Writes(); !reflect.DeepEqual(got, step.expected) {
+			t.Errorf("Handler sent for %s\n%v\nwant\n%v", step.name, got, step.expected)
+		}
+	}
+}
+
+func TestHandler_Retry(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	h := NewHandler(s.Client, &Options{Components: map[string]int{"api": 3}})
+
+	s.Fail("PUT", "/api/v1/components/3")
+	if code := post(h, StatusFiring, alert(StatusFiring, "a", "critical")); code != http.StatusInternalServerError {
+		t.Errorf("Handler returned %d for a failed component update, want %d", code, http.StatusInternalServerError)
+	}
+	s.Writes()
+
+	if code := post(h, StatusFiring, alert(StatusFiring, "a", "critical")); code != http.StatusOK {
+		t.Errorf("Handler returned %d for the retry, want %d", code, http.StatusOK)
+	}
+	expected := []cachettest.Write{
+		{Method: "POST", Path: "/api/v1/incidents/1/updates", Body: map[string]interface{}{
+			"message": "More than 5% errors", "status": float64(1), "component_id": float64(3), "component_status": float64(4),
+		}},
+		{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(4)}},
+	}
+	if got := s.Writes(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Handler sent for the retry\n%v\nwant\n%v", got, expected)
+	}
+}
+
+func TestHandler_KeepStatus(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	s.Add("incidents", cachet.Incident{ID: 5, Name: "API down", ComponentID: 3, Status: cachet.IncidentStatusIdentified})
+
+	h := NewHandler(s.Client, &Options{Components: map[string]int{"api": 3}})
+	if code := post(h, StatusFiring, alert(StatusFiring, "a", "critical")); code != http.StatusOK {
+		t.Fatalf("Handler returned %d, want %d", code, http.StatusOK)
+	}
+	expected := []cachettest.Write{
+		{Method: "POST", Path: "/api/v1/incidents/5/updates", Body: map[string]interface{}{
+			"message": "More than 5% errors", "status": float64(2), "component_id": float64(3), "component_status": float64(4),
+		}},
+		{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(4)}},
+	}
+	if got := s.Writes(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Handler sent\n%v\nwant\n%v", got, expected)
+	}
+}
+
+func TestNewHandler_Ensurer(t *testing.T) {
+	e := dedup.NewEnsurer(nil, nil)
+	if h := NewHandler(nil, &Options{Ensurer: e}); h.ensurer != e {
+		t.Error("NewHandler did not use the shared Ensurer")
+	}
+	if h := NewHandler(nil, nil); h.ensurer == nil {
+		t.Error("NewHandler returned a handler without Ensurer")
+	}
+}
+
+func TestHandler_InvalidRequests(t *testing.T) {
+	h := NewHandler(nil, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/alertmanager", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Handler returned %d for GET, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/alertmanager", strings.NewReader("{")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Handler returned %d for an invalid payload, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandler_Mapping(t *testing.T) {
+	h := NewHandler(nil, &Options{ComponentLabel: "service", Severities: map[string]int{"page": cachet.ComponentStatusMajorOutage}})
+
+	mockData := []struct {
+		labels    map[string]string
+		component int
+		ok        bool
+		status    int
+	}{
+		{map[string]string{"service": "7", "severity": "page"}, 7, true, cachet.ComponentStatusMajorOutage},
+		{map[string]string{"service": "api", "severity": "critical"}, 0, false, cachet.ComponentStatusPartialOutage},
+		{map[string]string{"component": "7"}, 0, false, cachet.ComponentStatusPartialOutage},
+	}
+
+	for _, data := range mockData {
+		a := Alert{Labels: data.labels}
+		if id, ok := h.component(a); id != data.component || ok != data.ok {
+			t.Errorf("component of %v returned %d, %v, want %d, %v", data.labels, id, ok, data.component, data.ok)
+		}
+		if status := h.severity(a); status != data.status {
+			t.Errorf("severity of %v returned %d, want %d", data.labels, status, data.status)
+		}
+	}
+
+	if a, b := fingerprint(Alert{Labels: map[string]string{"a": "1", "b": "2"}}), fingerprint(Alert{Labels: map[string]string{"b": "2", "a": "1"}}); a != b {
+		t.Errorf("fingerprint returned %q and %q for the same labels", a, b)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Package backup exports a whole Cachet instance into a portable archive and restores it.
+
+The archive is a versioned JSON document with all component groups, components,
+incidents (including their updates), metrics (including their points), schedules and subscribers:
+
+	archive, err := backup.Export(client)
+	err = archive.Write(file)
+
+A restore recreates everything on an empty instance.
+Because the new instance assigns new IDs, all references (group_id, component_id,
+incident_id, metric_id) are remapped. The mapping of old to new IDs is returned:
+
+	archive, err := backup.Read(file)
+	ids, err := backup.Restore(client, archive, nil)
+
+Timestamps are preserved where the API allows it: the time an incident occurred at,
+the start and end of schedules and the time of metric points.
+All other entities get the time of the restore as creation time.
+*/
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// Version is the version of the archive format written by this package.
+const Version = 1
+
+// Archive contains the exported entities of a Cachet instance.
+type Archive struct {
+	// Version of the archive format.
+	Version int `json:"version"`
+	// ExportedAt is the time of the export in RFC 3339 format.
+	ExportedAt string `json:"exported_at"`
+	// CachetVersion is the version of the exported Cachet instance.
+	CachetVersion string `json:"cachet_version,omitempty"`
+
+	ComponentGroups []cachet.ComponentGroup `json:"component_groups"`
+	Components      []cachet.Component      `json:"components"`
+	// Incidents contain their updates in the Updates field.
+	Incidents   []cachet.Incident   `json:"incidents"`
+	Metrics     []Metric            `json:"metrics"`
+	Schedules   []cachet.Schedule   `json:"schedules"`
+	Subscribers []cachet.Subscriber `json:"subscribers"`
+}
+
+// Metric is an exported metric including its points.
+type Metric struct {
+	cachet.Metric
+	Points []cachet.Point `json:"points"`
+}
+
+// Read reads an archive from r.
+// Archives of newer, unknown versions are rejected.
+func Read(r io.Reader) (*Archive, error) {
+	a := &Archive{}
+	if err := json.NewDecoder(r).Decode(a); err != nil {
+		return nil, err
+	}
+
+	if a.Version < 1 || a.Version > Version {
+		return nil, fmt.Errorf("Unsupported archive version %d. Supported versions: 1 to %d", a.Version, Version)
+	}
+	return a, nil
+}
+
+// Write writes the archive as indented JSON to w.
+func (a *Archive) Write(w io.Writer) error {
+	b, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
@@ -0,0 +1,74 @@
+package backup
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func testMethod(t *testing.T, r *http.Request, want string) {
+	if got := r.Method; got != want {
+		t.Errorf("Request method: %v, want %v", got, want)
+	}
+}
+
+func TestArchive_WriteRead(t *testing.T) {
+	a := &Archive{
+		Version:    Version,
+		ExportedAt: "2017-09-26T10:00:00Z",
+		Components: []cachet.Component{{ID: 1, Name: "API", GroupID: 2}},
+		Metrics: []Metric{
+			{Metric: cachet.Metric{ID: 1, Name: "Response time"}, Points: []cachet.Point{{ID: 1, Value: 5}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := a.Write(&buf); err != nil {
+		t.Fatalf("Archive.Write returned error: %v", err)
+	}
+
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, a) {
+		t.Errorf("Read returned %+v, want %+v", got, a)
+	}
+}
+
+func TestRead_UnsupportedVersion(t *testing.T) {
+	mockData := []string{`{}`, `{"version":99}`, `not json`}
+	for _, data := range mockData {
+		if _, err := Read(strings.NewReader(data)); err == nil {
+			t.Errorf("Read(%q) returned no error. Expected one.", data)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package backup
+
+import (
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// Export walks through all list endpoints of the instance and returns their entities as archive.
+// The client needs to be authenticated to export subscribers and hidden entities.
+func Export(client *cachet.Client) (*Archive, error) {
+	a := &Archive{
+		Version:    Version,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	version, _, err := client.General.Version()
+	if err != nil {
+		return nil, err
+	}
+	a.CachetVersion = version.Data
+
+	if a.ComponentGroups, err = fetch.ComponentGroups(client, nil); err != nil {
+		return nil, err
+	}
+	if a.Components, err = fetch.Components(client, nil); err != nil {
+		return nil, err
+	}
+
+	if a.Incidents, err = fetch.Incidents(client, nil); err != nil {
+		return nil, err
+	}
+	for i := range a.Incidents {
+		if a.Incidents[i].Updates, err = fetch.IncidentUpdates(client, a.Incidents[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	metrics, err := fetch.Metrics(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range metrics {
+		points, err := fetch.Points(client, m.ID)
+		if err != nil {
+			return nil, err
+		}
+		a.Metrics = append(a.Metrics, Metric{Metric: m, Points: points})
+	}
+
+	if a.Schedules, err = fetch.Schedules(client, nil); err != nil {
+		return nil, err
+	}
+	if a.Subscribers, err = fetch.Subscribers(client, nil); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
@@ -0,0 +1,83 @@
+package backup
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestExport(t *testing.T) {
+	setup()
+	defer teardown()
+
+	responses := map[string]string{
+		"/api/v1/version":             `{"meta":{"on_latest":true},"data":"2.3.12"}`,
+		"/api/v1/components/groups":   `{"data":[{"id":2,"name":"Websites"}]}`,
+		"/api/v1/components":          `{"data":[{"id":1,"name":"API","group_id":2}]}`,
+		"/api/v1/incidents":           `{"data":[{"id":3,"name":"Outage","component_id":1}]}`,
+		"/api/v1/incidents/3/updates": `{"meta":{"pagination":{"current_page":1,"total_pages":1}},"data":[{"id":4,"incident_id":3,"status":4,"message":"Fixed"}]}`,
+		"/api/v1/metrics":             `{"data":[{"id":5,"name":"Response time"}]}`,
+		"/api/v1/schedules":           `{"data":[{"id":7,"name":"Maintenance"}]}`,
+		"/api/v1/subscribers":         `{"data":[{"id":8,"email":"oncall@example.com"}]}`,
+	}
+	for path, response := range responses {
+		response := response
+		testMux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "GET")
+			fmt.Fprint(w, response)
+		})
+	}
+	testMux.HandleFunc("/api/v1/metrics/5/points", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":1,"total_pages":2}},"data":[{"id":6,"metric_id":5,"value":100}]}`)
+		case "2":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":2,"total_pages":2}},"data":[{"id":7,"metric_id":5,"value":120}]}`)
+		}
+	})
+
+	got, err := Export(testClient)
+	if err != nil {
+		t.Fatalf("Export returned error: %v", err)
+	}
+
+	if got.Version != Version || len(got.ExportedAt) == 0 {
+		t.Errorf("Export returned version %d exported at %q, want version %d and a time", got.Version, got.ExportedAt, Version)
+	}
+	got.ExportedAt = ""
+
+	expected := &Archive{
+		Version:         Version,
+		CachetVersion:   "2.3.12",
+		ComponentGroups: []cachet.ComponentGroup{{ID: 2, Name: "Websites"}},
+		Components:      []cachet.Component{{ID: 1, Name: "API", GroupID: 2}},
+		Incidents: []cachet.Incident{
+			{ID: 3, Name: "Outage", ComponentID: 1, Updates: []cachet.IncidentUpdate{{ID: 4, IncidentID: 3, Status: 4, Message: "Fixed"}}},
+		},
+		Metrics: []Metric{
+			{Metric: cachet.Metric{ID: 5, Name: "Response time"}, Points: []cachet.Point{{ID: 6, MetricID: 5, Value: 100}, {ID: 7, MetricID: 5, Value: 120}}},
+		},
+		Schedules:   []cachet.Schedule{{ID: 7, Name: "Maintenance"}},
+		Subscribers: []cachet.Subscriber{{ID: 8, Email: "oncall@example.com"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Export returned %+v, want %+v", got, expected)
+	}
+}
+
+func TestExport_Error(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+
+	if _, err := Export(testClient); err == nil {
+		t.Error("Export returned no error. Expected one.")
+	}
+}
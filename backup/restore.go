@@ -0,0 +1,331 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// errEmptyResponse is returned if the API did not return the created entity.
+var errEmptyResponse = errors.New("Empty response from the Cachet API")
+
+// Options configures a restore.
+type Options struct {
+	// Force restores into an instance that already contains entities.
+	// Existing entities are kept, which may result in duplicates.
+	Force bool
+}
+
+// IDMap maps the IDs of the archive to the IDs of the restored entities.
+type IDMap struct {
+	ComponentGroups map[int]int
+	Components      map[int]int
+	Incidents       map[int]int
+	IncidentUpdates map[int]int
+	Metrics         map[int]int
+	Schedules       map[int]int
+	Subscribers     map[int]int
+}
+
+// incidentBody is sent to create incidents.
+// In contrast to cachet.Incident it does not omit empty values.
+// Otherwise e.g. hidden incidents would be restored as visible.
+type incidentBody struct {
+	Name            string `json:"name"`
+	Message         string `json:"message"`
+	Status          int    `json:"status"`
+	Visible         int    `json:"visible"`
+	ComponentID     int    `json:"component_id,omitempty"`
+	ComponentStatus int    `json:"component_status,omitempty"`
+	Stickied        bool   `json:"stickied"`
+	Notify          bool   `json:"notify"`
+	OccurredAt      string `json:"occurred_at,omitempty"`
+}
+
+// Restore recreates all entities of the archive.
+// By default the instance has to be empty. Subscribers are not notified about restored incidents.
+// The returned IDMap contains the entities restored so far, even if an error occurred.
+func Restore(client *cachet.Client, a *Archive, o *Options) (*IDMap, error) {
+	if o == nil {
+		o = &Options{}
+	}
+
+	ids := &IDMap{
+		ComponentGroups: map[int]int{},
+		Components:      map[int]int{},
+		Incidents:       map[int]int{},
+		IncidentUpdates: map[int]int{},
+		Metrics:         map[int]int{},
+		Schedules:       map[int]int{},
+		Subscribers:     map[int]int{},
+	}
+
+	if !o.Force {
+		if err := checkEmpty(client); err != nil {
+			return ids, err
+		}
+	}
+
+	// Entities are restored in the order of their original IDs to keep their relative order.
+	groups := append([]cachet.ComponentGroup(nil), a.ComponentGroups...)
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	for _, g := range groups {
+		body := &api.ComponentGroup{Name: g.Name, Order: g.Order, Collapsed: g.Collapsed, Visible: g.Visible}
+		v := &struct {
+			Data *cachet.ComponentGroup `json:"data"`
+		}{}
+		_, err := client.Call("POST", "api/v1/components/groups", body, v)
+		if err == nil && v.Data == nil {
+			err = errEmptyResponse
+		}
+		if err != nil {
+			return ids, fmt.Errorf("Failed to restore component group %q: %v", g.Name, err)
+		}
+		ids.ComponentGroups[g.ID] = v.Data.ID
+	}
+
+	componentStatus := map[int]int{}
+	components := append([]cachet.Component(nil), a.Components...)
+	sort.Slice(components, func(i, j int) bool { return components[i].ID < components[j].ID })
+	for _, c := range components {
+		status := c.Status
+		body := &api.Component{
+			Name:        c.Name,
+			Description: c.Description,
+			Link:        c.Link,
+			Status:      &status,
+			Order:       c.Order,
+			GroupID:     ids.ComponentGroups[c.GroupID],
+			Enabled:     c.Enabled,
+		}
+		v := &struct {
+			Data *cachet.Component `json:"data"`
+		}{}
+		_, err := client.Call("POST", "api/v1/components", body, v)
+		if err == nil && v.Data == nil {
+			err = errEmptyResponse
+		}
+		if err != nil {
+			return ids, fmt.Errorf("Failed to restore component %q: %v", c.Name, err)
+		}
+		ids.Components[c.ID] = v.Data.ID
+		componentStatus[v.Data.ID] = c.Status
+	}
+
+	incidents := append([]cachet.Incident(nil), a.Incidents...)
+	sort.Slice(incidents, func(i, j int) bool { return incidents[i].ID < incidents[j].ID })
+	for _, i := range incidents {
+		if err := restoreIncident(client, i, ids, componentStatus); err != nil {
+			return ids, err
+		}
+	}
+
+	// Updates with a component status changed the status of their component.
+	// These components are set back to their archived status.
+	changed := map[int]bool{}
+	for _, i := range incidents {
+		for _, u := range i.Updates {
+			if id, ok := ids.Components[u.ComponentID]; ok && u.ComponentStatus > 0 {
+				changed[id] = true
+			}
+		}
+	}
+	for _, c := range components {
+		id := ids.Components[c.ID]
+		if !changed[id] {
+			continue
+		}
+		body := &struct {
+			Status int `json:"status"`
+		}{c.Status}
+		if _, err := client.Call("PUT", fmt.Sprintf("api/v1/components/%d", id), body, nil); err != nil {
+			return ids, fmt.Errorf("Failed to restore the status of component %q: %v", c.Name, err)
+		}
+	}
+
+	metrics := append([]Metric(nil), a.Metrics...)
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].ID < metrics[j].ID })
+	for _, m := range metrics {
+		if err := restoreMetric(client, m, ids); err != nil {
+			return ids, err
+		}
+	}
+
+	schedules := append([]cachet.Schedule(nil), a.Schedules...)
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+	for _, s := range schedules {
+		schedule := &api.Schedule{
+			Name:        s.Name,
+			Message:     s.Message,
+			Status:      s.Status,
+			ScheduledAt: s.ScheduledAt,
+			CompletedAt: s.CompletedAt,
+		}
+		for _, c := range s.Components {
+			if id, ok := ids.Components[c.ID]; ok {
+				schedule.Components = append(schedule.Components, cachet.Component{ID: id})
+			}
+		}
+		created, err := api.CreateSchedule(client, schedule)
+		if err != nil {
+			return ids, fmt.Errorf("Failed to restore schedule %q: %v", s.Name, err)
+		}
+		ids.Schedules[s.ID] = created.ID
+	}
+
+	for _, s := range a.Subscribers {
+		verify := 0
+		if len(s.VerifiedAt) > 0 {
+			verify = 1
+		}
+		created, _, err := client.Subscribers.Create(s.Email, verify)
+		if err == nil && created == nil {
+			err = errEmptyResponse
+		}
+		if err != nil {
+			return ids, fmt.Errorf("Failed to restore subscriber %q: %v", s.Email, err)
+		}
+		ids.Subscribers[s.ID] = created.ID
+	}
+
+	return ids, nil
+}
+
+// restoreIncident restores an incident and its updates.
+// componentStatus contains the current status of the restored components.
+// It is sent along with the incident to not change the status of the component.
+func restoreIncident(client *cachet.Client, i cachet.Incident, ids *IDMap, componentStatus map[int]int) error {
+	body := &incidentBody{
+		Name:       i.Name,
+		Message:    i.Message,
+		Status:     i.Status,
+		Visible:    i.Visible,
+		Stickied:   i.Stickied,
+		OccurredAt: i.OccurredAt,
+	}
+	if id, ok := ids.Components[i.ComponentID]; ok {
+		body.ComponentID = id
+		body.ComponentStatus = componentStatus[id]
+	}
+
+	v := &struct {
+		Data *cachet.Incident `json:"data"`
+	}{}
+	_, err := client.Call("POST", "api/v1/incidents", body, v)
+	if err == nil && v.Data == nil {
+		err = errEmptyResponse
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to restore incident %q: %v", i.Name, err)
+	}
+	ids.Incidents[i.ID] = v.Data.ID
+
+	updates := append([]cachet.IncidentUpdate(nil), i.Updates...)
+	sort.Slice(updates, func(i, j int) bool { return updates[i].ID < updates[j].ID })
+	for _, u := range updates {
+		update := &cachet.IncidentUpdate{Status: u.Status, Message: u.Message}
+		if id, ok := ids.Components[u.ComponentID]; ok {
+			update.ComponentID = id
+			update.ComponentStatus = u.ComponentStatus
+		}
+		created, _, err := client.IncidentUpdates.Create(v.Data.ID, update)
+		if err == nil && created == nil {
+			err = errEmptyResponse
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to restore update %d of incident %q: %v", u.ID, i.Name, err)
+		}
+		ids.IncidentUpdates[u.ID] = created.ID
+	}
+	return nil
+}
+
+// restoreMetric restores a metric and its points.
+// Points that were aggregated by Cachet (counter > 1) are added multiple times.
+func restoreMetric(client *cachet.Client, m Metric, ids *IDMap) error {
+	body := &api.Metric{
+		Name:         m.Name,
+		Suffix:       m.Suffix,
+		Description:  m.Description,
+		DefaultValue: m.DefaultValue,
+		CalcType:     m.CalcType,
+		DisplayChart: m.DisplayChart,
+		Places:       m.Places,
+		DefaultView:  m.DefaultView,
+		Threshold:    m.Threshold,
+		Order:        m.Order,
+		Visible:      m.Visible,
+	}
+	v := &struct {
+		Data *cachet.Metric `json:"data"`
+	}{}
+	_, err := client.Call("POST", "api/v1/metrics", body, v)
+	if err == nil && v.Data == nil {
+		err = errEmptyResponse
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to restore metric %q: %v", m.Name, err)
+	}
+	ids.Metrics[m.ID] = v.Data.ID
+
+	points := append([]cachet.Point(nil), m.Points...)
+	sort.Slice(points, func(i, j int) bool { return points[i].ID < points[j].ID })
+	for _, p := range points {
+		for n := 0; n < p.Counter || n == 0; n++ {
+			if _, _, err := client.Metrics.AddPoint(v.Data.ID, p.Value, unixTimestamp(p.CreatedAt)); err != nil {
+				return fmt.Errorf("Failed to restore point %d of metric %q: %v", p.ID, m.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// unixTimestamp converts a timestamp of the Cachet API into a unix timestamp.
+// It returns an empty string (= now) if the timestamp can not be parsed.
+func unixTimestamp(s string) string {
+	for _, layout := range []string{api.TimestampLayout, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return strconv.FormatInt(t.Unix(), 10)
+		}
+	}
+	return ""
+}
+
+// checkEmpty returns an error if the instance contains any entity that would be restored.
+func checkEmpty(client *cachet.Client) error {
+	groups, err := fetch.ComponentGroups(client, nil)
+	if err != nil {
+		return err
+	}
+	components, err := fetch.Components(client, nil)
+	if err != nil {
+		return err
+	}
+	incidents, err := fetch.Incidents(client, nil)
+	if err != nil {
+		return err
+	}
+	metrics, err := fetch.Metrics(client, nil)
+	if err != nil {
+		return err
+	}
+	schedules, err := fetch.Schedules(client, nil)
+	if err != nil {
+		return err
+	}
+	subscribers, err := fetch.Subscribers(client, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(groups) > 0 || len(components) > 0 || len(incidents) > 0 || len(metrics) > 0 || len(schedules) > 0 || len(subscribers) > 0 {
+		return fmt.Errorf("The Cachet instance is not empty. Use Options.Force to restore anyway")
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// testArchive contains one entity of every kind with references between them.
+var testArchive = &Archive{
+	Version:         Version,
+	ComponentGroups: []cachet.ComponentGroup{{ID: 20, Name: "Websites", Visible: cachet.ComponentGroupVisibilityLoggedIn}},
+	Components:      []cachet.Component{{ID: 10, Name: "API", GroupID: 20, Status: cachet.ComponentStatusPartialOutage}},
+	Incidents: []cachet.Incident{
+		{
+			ID:          30,
+			Name:        "Outage",
+			Status:      cachet.IncidentStatusInvestigating,
+			Visible:     cachet.IncidentVisibilityPublic,
+			ComponentID: 10,
+			OccurredAt:  "2017-09-26 10:00:00",
+			Updates: []cachet.IncidentUpdate{
+				{ID: 32, Status: cachet.IncidentStatusFixed, Message: "Fixed"},
+				{ID: 31, Status: cachet.IncidentStatusIdentified, Message: "Found it", ComponentID: 10, ComponentStatus: cachet.ComponentStatusMajorOutage},
+			},
+		},
+	},
+	Metrics: []Metric{
+		{Metric: cachet.Metric{ID: 40, Name: "Response time"}, Points: []cachet.Point{{ID: 41, Value: 100, Counter: 2, CreatedAt: "2017-09-26 10:00:00"}}},
+	},
+	Schedules:   []cachet.Schedule{{ID: 50, Name: "Maintenance", ScheduledAt: "2017-09-27 10:00:00", Components: []cachet.Component{{ID: 10}}}},
+	Subscribers: []cachet.Subscriber{{ID: 60, Email: "oncall@example.com", VerifiedAt: "2017-09-26 10:00:00"}},
+}
+
+func TestRestore(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var requests []string
+	handle := func(path, response string, expected map[string]interface{}) {
+		testMux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				fmt.Fprint(w, `{"data":[]}`)
+				return
+			}
+			testMethod(t, r, "POST")
+			body := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if !reflect.DeepEqual(body, expected) {
+				t.Errorf("Request body of %s is %+v, want %+v", path, body, expected)
+			}
+			requests = append(requests, path)
+			fmt.Fprint(w, response)
+		})
+	}
+
+	handle("/api/v1/components/groups", `{"data":{"id":2}}`, map[string]interface{}{
+		"name": "Websites", "order": float64(0), "collapsed": float64(0), "visible": float64(0),
+	})
+	handle("/api/v1/components", `{"data":{"id":1}}`, map[string]interface{}{
+		"name": "API", "description": "", "link": "", "status": float64(3), "order": float64(0), "group_id": float64(2), "enabled": false,
+	})
+	handle("/api/v1/incidents", `{"data":{"id":3}}`, map[string]interface{}{
+		"name": "Outage", "message": "", "status": float64(1), "visible": float64(1), "component_id": float64(1),
+		"component_status": float64(3), "stickied": false, "notify": false, "occurred_at": "2017-09-26 10:00:00",
+	})
+	updateID := 0
+	testMux.HandleFunc("/api/v1/incidents/3/updates", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := &cachet.IncidentUpdate{}
+		json.NewDecoder(r.Body).Decode(body)
+		requests = append(requests, fmt.Sprintf("update %s component %d status %d", body.Message, body.ComponentID, body.ComponentStatus))
+		updateID++
+		fmt.Fprintf(w, `{"data":{"id":%d}}`, updateID)
+	})
+	testMux.HandleFunc("/api/v1/components/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, fmt.Sprintf("component status %v", body["status"]))
+		fmt.Fprint(w, `{"data":{"id":1}}`)
+	})
+	handle("/api/v1/metrics", `{"data":{"id":4}}`, map[string]interface{}{
+		"name": "Response time", "suffix": "", "description": "", "default_value": float64(0), "calc_type": float64(0),
+		"display_chart": false, "places": float64(0), "default_view": float64(0), "threshold": float64(0), "order": float64(0), "visible": float64(0),
+	})
+	handle("/api/v1/metrics/4/points", `{"data":{"id":1}}`, map[string]interface{}{"value": float64(100), "timestamp": "1506420000"})
+	handle("/api/v1/schedules", `{"data":{"id":5}}`, map[string]interface{}{
+		"name": "Maintenance", "message": "", "status": float64(0), "scheduled_at": "2017-09-27 10:00:00", "components": []interface{}{map[string]interface{}{"id": float64(1)}},
+	})
+	handle("/api/v1/subscribers", `{"data":{"id":6}}`, map[string]interface{}{"email": "oncall@example.com", "verify": float64(1)})
+
+	got, err := Restore(testClient, testArchive, nil)
+	if err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+
+	expected := &IDMap{
+		ComponentGroups: map[int]int{20: 2},
+		Components:      map[int]int{10: 1},
+		Incidents:       map[int]int{30: 3},
+		IncidentUpdates: map[int]int{31: 1, 32: 2},
+		Metrics:         map[int]int{40: 4},
+		Schedules:       map[int]int{50: 5},
+		Subscribers:     map[int]int{60: 6},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Restore returned %+v, want %+v", got, expected)
+	}
+
+	expectedRequests := []string{
+		"/api/v1/components/groups",
+		"/api/v1/components",
+		"/api/v1/incidents",
+		"update Found it component 1 status 4",
+		"update Fixed component 0 status 0",
+		"component status 3",
+		"/api/v1/metrics",
+		"/api/v1/metrics/4/points",
+		"/api/v1/metrics/4/points",
+		"/api/v1/schedules",
+		"/api/v1/subscribers",
+	}
+	if !reflect.DeepEqual(requests, expectedRequests) {
+		t.Errorf("Restore made the requests %v, want %v", requests, expectedRequests)
+	}
+}
+
+func TestRestore_NotEmpty(t *testing.T) {
+	for _, path := range []string{"/api/v1/components", "/api/v1/subscribers"} {
+		setup()
+
+		testMux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "GET")
+			fmt.Fprint(w, `{"data":[{"id":1}]}`)
+		})
+		testMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "GET")
+			fmt.Fprint(w, `{"data":[]}`)
+		})
+
+		if _, err := Restore(testClient, testArchive, nil); err == nil {
+			t.Errorf("Restore returned no error for an instance with %s. Expected one.", path)
+		}
+
+		teardown()
+	}
+}
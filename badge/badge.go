@@ -0,0 +1,139 @@
+/*
+Package badge renders SVG status badges of components and component groups.
+
+	badge.Render(w, "API", cachet.ComponentStatusOperational, nil)
+
+A Handler serves the badges of an instance under /badge/component/{id}.svg and /badge/group/{id}.svg:
+
+	http.Handle("/badge/", badge.NewHandler(client, nil, time.Minute))
+
+The label defaults to the name of the component or group and can be changed with the query parameter label.
+*/
+package badge
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// DefaultMessages contains the status texts of badges.
+var DefaultMessages = map[int]string{
+	cachet.ComponentStatusUnknown:           "unknown",
+	cachet.ComponentStatusOperational:       "operational",
+	cachet.ComponentStatusPerformanceIssues: "performance issues",
+	cachet.ComponentStatusPartialOutage:     "partial outage",
+	cachet.ComponentStatusMajorOutage:       "major outage",
+}
+
+// DefaultColors contains the status colors of badges.
+var DefaultColors = map[int]string{
+	cachet.ComponentStatusUnknown:           "#9f9f9f",
+	cachet.ComponentStatusOperational:       "#4c1",
+	cachet.ComponentStatusPerformanceIssues: "#007ec6",
+	cachet.ComponentStatusPartialOutage:     "#dfb317",
+	cachet.ComponentStatusMajorOutage:       "#e05d44",
+}
+
+// DefaultLabelColor is the color of the label.
+const DefaultLabelColor = "#555"
+
+// charWidth is the approximated width of a character in pixels.
+const charWidth = 7
+
+// padding is the horizontal padding of each part of a badge in pixels.
+const padding = 6
+
+// Options configures badges.
+// Statuses missing in Messages or Colors use the defaults.
+type Options struct {
+	Messages   map[int]string
+	Colors     map[int]string
+	LabelColor string
+}
+
+func (o *Options) message(status int) string {
+	if o != nil {
+		if m, ok := o.Messages[status]; ok {
+			return m
+		}
+	}
+	if m, ok := DefaultMessages[status]; ok {
+		return m
+	}
+	return DefaultMessages[cachet.ComponentStatusUnknown]
+}
+
+func (o *Options) color(status int) string {
+	if o != nil {
+		if c, ok := o.Colors[status]; ok {
+			return c
+		}
+	}
+	if c, ok := DefaultColors[status]; ok {
+		return c
+	}
+	return DefaultColors[cachet.ComponentStatusUnknown]
+}
+
+func (o *Options) labelColor() string {
+	if o != nil && len(o.LabelColor) > 0 {
+		return o.LabelColor
+	}
+	return DefaultLabelColor
+}
+
+// badgeTemplate is a flat badge. Texts and colors are escaped before.
+var badgeTemplate = template.Must(template.New("badge").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Message}}">
+<title>{{.Label}}: {{.Message}}</title>
+<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)">
+<rect width="{{.LabelWidth}}" height="20" fill="{{.LabelColor}}"/>
+<rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/>
+<rect width="{{.Width}}" height="20" fill="url(#s)"/>
+</g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="{{.LabelX}}" y="14">{{.Label}}</text>
+<text x="{{.MessageX}}" y="14">{{.Message}}</text>
+</g>
+</svg>
+`))
+
+// Render writes the badge of a status with the label to w.
+func Render(w io.Writer, label string, status int, o *Options) error {
+	message := o.message(status)
+	labelWidth := textWidth(label)
+	messageWidth := textWidth(message)
+
+	return badgeTemplate.Execute(w, map[string]interface{}{
+		"Label":        escape(label),
+		"Message":      escape(message),
+		"LabelColor":   escape(o.labelColor()),
+		"Color":        escape(o.color(status)),
+		"Width":        labelWidth + messageWidth,
+		"LabelWidth":   labelWidth,
+		"MessageWidth": messageWidth,
+		"LabelX":       labelWidth / 2,
+		"MessageX":     labelWidth + messageWidth/2,
+	})
+}
+
+// textWidth approximates the width of a part of the badge.
+func textWidth(s string) int {
+	return utf8.RuneCountInString(s)*charWidth + 2*padding
+}
+
+// escape escapes s for the use in XML text and attributes.
+func escape(s string) string {
+	var buf bytes.Buffer
+	if err := xml.EscapeText(&buf, []byte(s)); err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return buf.String()
+}
@@ -0,0 +1,65 @@
+package badge
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, "API", cachet.ComponentStatusPartialOutage, nil); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	got := buf.String()
+
+	expected := []string{
+		`width="143"`,
+		`aria-label="API: partial outage"`,
+		`<rect width="33" height="20" fill="#555"/>`,
+		`<rect x="33" width="110" height="20" fill="#dfb317"/>`,
+		`<text x="16" y="14">API</text>`,
+		`<text x="88" y="14">partial outage</text>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(got, e) {
+			t.Errorf("Render returned\n%s\nwithout %q", got, e)
+		}
+	}
+}
+
+func TestRender_Options(t *testing.T) {
+	o := &Options{
+		Messages:   map[int]string{cachet.ComponentStatusOperational: "up"},
+		Colors:     map[int]string{cachet.ComponentStatusOperational: "green"},
+		LabelColor: "black",
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, `<script>"`, cachet.ComponentStatusOperational, o); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	got := buf.String()
+
+	for _, e := range []string{`fill="black"`, `fill="green"`, ">up</text>", "&lt;script&gt;&#34;"} {
+		if !strings.Contains(got, e) {
+			t.Errorf("Render returned\n%s\nwithout %q", got, e)
+		}
+	}
+	if err := xml.Unmarshal(buf.Bytes(), new(struct{})); err != nil {
+		t.Errorf("Render returned invalid XML: %v", err)
+	}
+}
+
+func TestOptions_Defaults(t *testing.T) {
+	var o *Options
+	if got := o.message(42); got != "unknown" {
+		t.Errorf("message of an unknown status returned %q, want %q", got, "unknown")
+	}
+	if got := o.color(42); got != DefaultColors[cachet.ComponentStatusUnknown] {
+		t.Errorf("color of an unknown status returned %q, want %q", got, DefaultColors[cachet.ComponentStatusUnknown])
+	}
+}
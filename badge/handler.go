@@ -0,0 +1,167 @@
+package badge
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/fetch"
+	"github.com/andygrunwald/cachet/status"
+)
+
+// entity is the name and status of a component or group.
+type entity struct {
+	name   string
+	status int
+}
+
+// Handler serves badges of the components and component groups of an instance.
+// Only enabled components and visible groups have badges.
+type Handler struct {
+	client  *cachet.Client
+	options *Options
+	ttl     time.Duration
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+
+	mu         sync.Mutex
+	components map[int]entity
+	groups     map[int]entity
+	expires    time.Time
+}
+
+// NewHandler returns a handler that serves badges.
+// Components and groups are fetched at most once per ttl.
+func NewHandler(client *cachet.Client, o *Options, ttl time.Duration) *Handler {
+	return &Handler{client: client, options: o, ttl: ttl, now: time.Now}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The path ends with {component,group}/{id}.svg, independent of where the handler is mounted.
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || !strings.HasSuffix(parts[len(parts)-1], ".svg") {
+		http.NotFound(w, r)
+		return
+	}
+	kind := parts[len(parts)-2]
+	id, err := strconv.Atoi(strings.TrimSuffix(parts[len(parts)-1], ".svg"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	components, groups, err := h.entities()
+	if err != nil {
+		http.Error(w, "Failed to fetch the status", http.StatusBadGateway)
+		return
+	}
+
+	var e entity
+	var ok bool
+	switch kind {
+	case "component":
+		e, ok = components[id]
+	case "group":
+		e, ok = groups[id]
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	label := e.name
+	if l := r.URL.Query().Get("label"); len(l) > 0 {
+		label = l
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, label, e.status, h.options); err != nil {
+		http.Error(w, "Failed to render the badge", http.StatusInternalServerError)
+		return
+	}
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(buf.Bytes()))
+
+	w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.ttl.Seconds())))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if r.Method == "HEAD" {
+		return
+	}
+	w.Write(buf.Bytes())
+}
+
+// entities returns the cached components and groups and refreshes them if they expired.
+// If refreshing fails, the expired entities are used until ttl passed again.
+func (h *Handler) entities() (map[int]entity, map[int]entity, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	if h.components != nil && now.Before(h.expires) {
+		return h.components, h.groups, nil
+	}
+
+	components, groups, err := h.fetch()
+	if err != nil {
+		if h.components != nil {
+			h.expires = now.Add(h.ttl)
+			return h.components, h.groups, nil
+		}
+		return nil, nil, err
+	}
+
+	h.components, h.groups = components, groups
+	h.expires = now.Add(h.ttl)
+	return components, groups, nil
+}
+
+// fetch fetches the enabled components and visible groups along with their status.
+func (h *Handler) fetch() (map[int]entity, map[int]entity, error) {
+	allGroups, err := fetch.ComponentGroups(h.client, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	allComponents, err := fetch.Components(h.client, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hidden := map[int]bool{}
+	for _, g := range allGroups {
+		if g.Visible != cachet.ComponentGroupVisibilityPublic {
+			hidden[g.ID] = true
+		}
+	}
+
+	components := map[int]entity{}
+	for _, c := range allComponents {
+		if c.Enabled && !hidden[c.GroupID] {
+			components[c.ID] = entity{name: c.Name, status: c.Status}
+		}
+	}
+
+	groups := map[int]entity{}
+	for _, g := range status.Compute(allGroups, allComponents, nil).Groups {
+		if !hidden[g.ID] {
+			groups[g.ID] = entity{name: g.Name, status: g.Status}
+		}
+	}
+	return components, groups, nil
+}
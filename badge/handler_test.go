@@ -0,0 +1,76 @@
+package badge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client, _ := cachet.NewClient(server.URL, nil)
+
+	requests := 0
+	mux.HandleFunc("/api/v1/components/groups", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"data":[{"id":1,"name":"Websites","visible":1},{"id":2,"name":"Internal","visible":0}]}`)
+	})
+	mux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"id":1,"name":"Blog","status":1,"enabled":true,"group_id":1},
+			{"id":2,"name":"Shop","status":4,"enabled":true,"group_id":1},
+			{"id":3,"name":"Wiki","status":1,"enabled":true,"group_id":2},
+			{"id":4,"name":"Old","status":1,"enabled":false}
+		]}`)
+	})
+
+	now := time.Date(2017, 10, 10, 12, 0, 0, 0, time.UTC)
+	h := NewHandler(client, nil, time.Minute)
+	h.now = func() time.Time { return now }
+
+	mockData := []struct {
+		path     string
+		code     int
+		contains string
+	}{
+		{"/badge/component/1.svg", http.StatusOK, ">operational</text>"},
+		{"/badge/component/2.svg?label=shop", http.StatusOK, "shop: major outage"},
+		{"/badge/group/1.svg", http.StatusOK, "Websites: major outage"},
+		{"/badge/component/3.svg", http.StatusNotFound, ""},
+		{"/badge/component/4.svg", http.StatusNotFound, ""},
+		{"/badge/group/2.svg", http.StatusNotFound, ""},
+		{"/badge/group/x.svg", http.StatusNotFound, ""},
+		{"/badge/incident/1.svg", http.StatusNotFound, ""},
+		{"/badge/component/1.png", http.StatusNotFound, ""},
+	}
+
+	for _, data := range mockData {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", data.path, nil))
+		if w.Code != data.code {
+			t.Errorf("Handler returned %d for %s, want %d", w.Code, data.path, data.code)
+		}
+		if !strings.Contains(w.Body.String(), data.contains) {
+			t.Errorf("Handler returned %q for %s, want it to contain %q", w.Body.String(), data.path, data.contains)
+		}
+		if data.code == http.StatusOK && w.Header().Get("Content-Type") != "image/svg+xml; charset=utf-8" {
+			t.Errorf("Handler returned content type %q for %s", w.Header().Get("Content-Type"), data.path)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Handler fetched the groups %d times within the ttl, want once", requests)
+	}
+
+	now = now.Add(2 * time.Minute)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/badge/component/1.svg", nil))
+	if requests != 2 {
+		t.Errorf("Handler fetched the groups %d times after the ttl, want twice", requests)
+	}
+}
@@ -0,0 +1,291 @@
+package chatops
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/fetch"
+	"github.com/andygrunwald/cachet/maintenance"
+	"github.com/andygrunwald/cachet/status"
+)
+
+// replyLayout is the layout of times in replies.
+const replyLayout = "2006-01-02 15:04 MST"
+
+// Options configures the executor.
+type Options struct {
+	// Location is the time zone of the Cachet instance.
+	// It defaults to the time zone of Maintenance or UTC.
+	Location *time.Location
+
+	// Maintenance runs the maintenance windows of the maint command.
+	// The command is disabled without it.
+	Maintenance *maintenance.Runner
+}
+
+// Executor executes commands against a Cachet instance.
+type Executor struct {
+	client      *cachet.Client
+	location    *time.Location
+	maintenance *maintenance.Runner
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+// NewExecutor returns a new executor. o can be nil.
+func NewExecutor(client *cachet.Client, o *Options) *Executor {
+	e := &Executor{client: client, location: time.UTC, now: time.Now}
+	if o == nil {
+		return e
+	}
+	e.maintenance = o.Maintenance
+	switch {
+	case o.Location != nil:
+		e.location = o.Location
+	case o.Maintenance != nil:
+		e.location = o.Maintenance.Location()
+	}
+	return e
+}
+
+// Run parses and executes a command line and returns the reply.
+// Errors are returned as reply, too.
+func (e *Executor) Run(line string) string {
+	c, err := Parse(line)
+	if err != nil {
+		return err.Error()
+	}
+	reply, err := e.Execute(c)
+	if err != nil {
+		return err.Error()
+	}
+	return reply
+}
+
+// Execute executes a parsed command and returns the reply.
+func (e *Executor) Execute(c Command) (string, error) {
+	switch c := c.(type) {
+	case *Help:
+		return Usage, nil
+	case *ShowStatus:
+		return e.showStatus(c)
+	case *SetStatus:
+		return e.setStatus(c)
+	case *OpenIncident:
+		return e.openIncident(c)
+	case *UpdateIncident:
+		return e.updateIncident(c)
+	case *CloseIncident:
+		return e.closeIncident(c)
+	case *ScheduleMaintenance:
+		return e.scheduleMaintenance(c)
+	}
+	return "", fmt.Errorf("Unknown command %T", c)
+}
+
+func (e *Executor) showStatus(c *ShowStatus) (string, error) {
+	if len(c.Component) > 0 {
+		component, err := e.component(c.Component)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s is %s.", component.Name, status.HumanStatus(component.Status)), nil
+	}
+
+	summary, err := status.Fetch(e.client, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(summary.Message + ".")
+	components := summary.Components
+	for _, g := range summary.Groups {
+		components = append(components, g.Components...)
+	}
+	for _, component := range components {
+		if component.Status > cachet.ComponentStatusOperational {
+			fmt.Fprintf(&buf, "\n%s: %s", component.Name, component.HumanStatus)
+		}
+	}
+	return buf.String(), nil
+}
+
+func (e *Executor) setStatus(c *SetStatus) (string, error) {
+	component, err := e.component(c.Component)
+	if err != nil {
+		return "", err
+	}
+	if _, _, err := e.client.Components.Update(component.ID, &cachet.Component{Status: c.Status}); err != nil {
+		return "", fmt.Errorf("Failed to update %s: %v", component.Name, err)
+	}
+	return fmt.Sprintf("%s is now %s.", component.Name, status.HumanStatus(c.Status)), nil
+}
+
+func (e *Executor) openIncident(c *OpenIncident) (string, error) {
+	i := &cachet.Incident{
+		Name:            c.Name,
+		Message:         c.Message,
+		Status:          cachet.IncidentStatusInvestigating,
+		Visible:         cachet.IncidentVisibilityPublic,
+		ComponentStatus: c.ComponentStatus,
+	}
+	if len(i.Message) == 0 {
+		i.Message = c.Name
+	}
+
+	var component *cachet.Component
+	if len(c.Component) > 0 {
+		var err error
+		if component, err = e.component(c.Component); err != nil {
+			return "", err
+		}
+		i.ComponentID = component.ID
+	}
+
+	created, _, err := e.client.Incidents.Create(i)
+	if err != nil {
+		return "", fmt.Errorf("Failed to open the incident: %v", err)
+	}
+	if created == nil {
+		return "", fmt.Errorf("Empty response from the Cachet API")
+	}
+
+	reply := fmt.Sprintf("Opened incident #%d %q", created.ID, c.Name)
+	if component != nil {
+		reply += " for " + component.Name
+		if c.ComponentStatus > 0 {
+			if _, _, err := e.client.Components.Update(component.ID, &cachet.Component{Status: c.ComponentStatus}); err != nil {
+				return "", fmt.Errorf("Opened incident #%d, but failed to update %s: %v", created.ID, component.Name, err)
+			}
+			reply += " (" + status.HumanStatus(c.ComponentStatus) + ")"
+		}
+	}
+	return reply + ".", nil
+}
+
+func (e *Executor) updateIncident(c *UpdateIncident) (string, error) {
+	i, err := e.incident(c.ID)
+	if err != nil {
+		return "", err
+	}
+	if err := e.update(i, c.Status, c.Message, c.ComponentStatus); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Updated incident #%d %q to %s.", i.ID, i.Name, status.HumanIncidentStatus(c.Status)), nil
+}
+
+func (e *Executor) closeIncident(c *CloseIncident) (string, error) {
+	i, err := e.incident(c.ID)
+	if err != nil {
+		return "", err
+	}
+
+	message := c.Message
+	if len(message) == 0 {
+		message = "The incident has been resolved."
+	}
+	if err := e.update(i, cachet.IncidentStatusFixed, message, cachet.ComponentStatusOperational); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Closed incident #%d %q.", i.ID, i.Name), nil
+}
+
+// update adds an update to the incident, moves it to status and sets the status of its component.
+func (e *Executor) update(i *cachet.Incident, incidentStatus int, message string, componentStatus int) error {
+	if i.ComponentID == 0 {
+		componentStatus = 0
+	}
+
+	u := &cachet.IncidentUpdate{Status: incidentStatus, Message: message, ComponentStatus: componentStatus}
+	if _, _, err := e.client.IncidentUpdates.Create(i.ID, u); err != nil {
+		return fmt.Errorf("Failed to update incident #%d: %v", i.ID, err)
+	}
+	if _, _, err := e.client.Incidents.Update(i.ID, &cachet.Incident{Status: incidentStatus}); err != nil {
+		return fmt.Errorf("Failed to update the status of incident #%d: %v", i.ID, err)
+	}
+	if componentStatus > 0 {
+		if _, _, err := e.client.Components.Update(i.ComponentID, &cachet.Component{Status: componentStatus}); err != nil {
+			return fmt.Errorf("Failed to update the status of component %d: %v", i.ComponentID, err)
+		}
+	}
+	return nil
+}
+
+func (e *Executor) scheduleMaintenance(c *ScheduleMaintenance) (string, error) {
+	if e.maintenance == nil {
+		return "", fmt.Errorf("Maintenance windows are not enabled")
+	}
+
+	w := &maintenance.Window{Name: c.Name, Start: e.now().In(e.location).Add(c.In)}
+	w.End = w.Start.Add(c.Duration)
+	var names []string
+	for _, name := range c.Components {
+		component, err := e.component(name)
+		if err != nil {
+			return "", err
+		}
+		w.Components = append(w.Components, component.ID)
+		names = append(names, component.Name)
+	}
+	if len(w.Name) == 0 {
+		w.Name = "Maintenance of " + strings.Join(names, ", ")
+	}
+	w.Message = fmt.Sprintf("%s from %s to %s.", w.Name, w.Start.Format(replyLayout), w.End.Format(replyLayout))
+
+	schedule, err := e.maintenance.Schedule(w)
+	if err != nil {
+		return "", fmt.Errorf("Failed to schedule the maintenance: %v", err)
+	}
+	return fmt.Sprintf("Scheduled maintenance #%d %q from %s to %s.", schedule.ID, w.Name, w.Start.Format(replyLayout), w.End.Format(replyLayout)), nil
+}
+
+// incident returns the incident with the ID id.
+func (e *Executor) incident(id int) (*cachet.Incident, error) {
+	i, _, err := e.client.Incidents.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get incident #%d: %v", id, err)
+	}
+	if i == nil || i.ID == 0 {
+		return nil, fmt.Errorf("Incident #%d not found", id)
+	}
+	return i, nil
+}
+
+// component looks up a component by ID or name.
+// Names match case insensitive, a unique prefix is enough.
+func (e *Executor) component(ref string) (*cachet.Component, error) {
+	components, err := fetch.Components(e.client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := strconv.Atoi(ref)
+	var prefixed []*cachet.Component
+	for k := range components {
+		c := &components[k]
+		if (id > 0 && c.ID == id) || strings.EqualFold(c.Name, ref) {
+			return c, nil
+		}
+		if strings.HasPrefix(strings.ToLower(c.Name), strings.ToLower(ref)) {
+			prefixed = append(prefixed, c)
+		}
+	}
+
+	switch len(prefixed) {
+	case 0:
+		return nil, fmt.Errorf("Component %q not found", ref)
+	case 1:
+		return prefixed[0], nil
+	}
+	names := make([]string, 0, len(prefixed))
+	for _, c := range prefixed {
+		names = append(names, c.Name)
+	}
+	return nil, fmt.Errorf("Component %q is ambiguous: %s", ref, strings.Join(names, ", "))
+}
@@ -0,0 +1,148 @@
+package chatops
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/cachettest"
+	"github.com/andygrunwald/cachet/maintenance"
+)
+
+// newTestServer returns an instance with the components API, Admin and Database and the incident 42 on the Database.
+func newTestServer() *cachettest.Server {
+	s := cachettest.NewServer()
+	s.Add("components/groups", cachet.ComponentGroup{ID: 1, Name: "Backend", Visible: cachet.ComponentGroupVisibilityPublic})
+	s.Add("components",
+		cachet.Component{ID: 1, Name: "API", Status: cachet.ComponentStatusMajorOutage, Enabled: true},
+		cachet.Component{ID: 2, Name: "Admin", Status: cachet.ComponentStatusOperational, Enabled: true},
+		cachet.Component{ID: 3, Name: "Database", Status: cachet.ComponentStatusPerformanceIssues, Enabled: true, GroupID: 1},
+	)
+	s.Add("incidents", cachet.Incident{ID: 42, Name: "DB latency", ComponentID: 3, Status: cachet.IncidentStatusInvestigating})
+	return s
+}
+
+func TestExecutor_Run(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	dir, _ := ioutil.TempDir("", "chatops")
+	defer os.RemoveAll(dir)
+	runner, err := maintenance.NewRunner(s.Client, filepath.Join(dir, "maintenance.json"), nil)
+	if err != nil {
+		t.Fatalf("NewRunner returned error: %v", err)
+	}
+
+	e := NewExecutor(s.Client, &Options{Maintenance: runner})
+	e.now = func() time.Time { return time.Date(2017, 10, 10, 10, 0, 0, 0, time.UTC) }
+
+	mockData := []struct {
+		line     string
+		reply    string
+		expected []cachettest.Write
+	}{
+		{"status", "Some systems are experiencing issues.\nAPI: Major Outage\nDatabase: Performance Issues", nil},
+		{"status data", "Database is Performance Issues.", nil},
+		{
+			"status 2 operational",
+			"Admin is now Operational.",
+			[]cachettest.Write{{Method: "PUT", Path: "/api/v1/components/2", Body: map[string]interface{}{"status": float64(1)}}},
+		},
+		{"status a major", `Component "a" is ambiguous: API, Admin`, nil},
+		{"status cache", `Component "cache" not found`, nil},
+		{
+			"incident open api 'DB latency' major",
+			`Opened incident #43 "DB latency" for API (Major Outage).`,
+			[]cachettest.Write{
+				{Method: "POST", Path: "/api/v1/incidents", Body: map[string]interface{}{
+					"name": "DB latency", "message": "DB latency", "status": float64(1), "visible": float64(1),
+					"component_id": float64(1), "component_status": float64(4),
+				}},
+				{Method: "PUT", Path: "/api/v1/components/1", Body: map[string]interface{}{"status": float64(4)}},
+			},
+		},
+		{
+			"incident update 42 watching 'fix deployed' degraded",
+			`Updated incident #42 "DB latency" to Watching.`,
+			[]cachettest.Write{
+				{Method: "POST", Path: "/api/v1/incidents/42/updates", Body: map[string]interface{}{
+					"message": "fix deployed", "status": float64(3), "component_status": float64(2),
+				}},
+				{Method: "PUT", Path: "/api/v1/incidents/42", Body: map[string]interface{}{"status": float64(3)}},
+				{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(2)}},
+			},
+		},
+		{
+			"incident close 42",
+			`Closed incident #42 "DB latency".`,
+			[]cachettest.Write{
+				{Method: "POST", Path: "/api/v1/incidents/42/updates", Body: map[string]interface{}{
+					"message": "The incident has been resolved.", "status": float64(4), "component_status": float64(1),
+				}},
+				{Method: "PUT", Path: "/api/v1/incidents/42", Body: map[string]interface{}{"status": float64(4)}},
+				{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(1)}},
+			},
+		},
+		{
+			"maint schedule data 2h",
+			`Scheduled maintenance #1 "Maintenance of Database" from 2017-10-10 10:00 UTC to 2017-10-10 12:00 UTC.`,
+			[]cachettest.Write{{Method: "POST", Path: "/api/v1/schedules", Body: map[string]interface{}{
+				"name": "Maintenance of Database", "message": "Maintenance of Database from 2017-10-10 10:00 UTC to 2017-10-10 12:00 UTC.",
+				"status": float64(0), "scheduled_at": "2017-10-10 10:00:00",
+				"components": []interface{}{map[string]interface{}{"id": float64(3)}},
+			}}},
+		},
+		{
+			"maint schedule data,api 1h in 1d Upgrade",
+			`Scheduled maintenance #2 "Upgrade" from 2017-10-11 10:00 UTC to 2017-10-11 11:00 UTC.`,
+			[]cachettest.Write{{Method: "POST", Path: "/api/v1/schedules", Body: map[string]interface{}{
+				"name": "Upgrade", "message": "Upgrade from 2017-10-11 10:00 UTC to 2017-10-11 11:00 UTC.",
+				"status": float64(0), "scheduled_at": "2017-10-11 10:00:00",
+				"components": []interface{}{map[string]interface{}{"id": float64(3)}, map[string]interface{}{"id": float64(1)}},
+			}}},
+		},
+		{"help", Usage, nil},
+		{"reboot", `Unknown command "reboot". Try help`, nil},
+	}
+
+	for _, data := range mockData {
+		if got := e.Run(data.line); got != data.reply {
+			t.Errorf("Run(%q) returned %q, want %q", data.line, got, data.reply)
+		}
+		if got := s.Writes(); !reflect.DeepEqual(got, data.expected) {
+			t.Errorf("Run(%q) sent\n%v\nwant\n%v", data.line, got, data.expected)
+		}
+	}
+}
+
+func TestExecutor_Run_NoMaintenance(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	e := NewExecutor(s.Client, nil)
+	expected := "Maintenance windows are not enabled"
+	if got := e.Run("maint schedule api 1h"); got != expected {
+		t.Errorf("Run returned %q, want %q", got, expected)
+	}
+}
+
+func TestNewExecutor_Location(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chatops")
+	defer os.RemoveAll(dir)
+	loc := time.FixedZone("CEST", 2*60*60)
+	runner, err := maintenance.NewRunner(nil, filepath.Join(dir, "maintenance.json"), &maintenance.Options{Location: loc})
+	if err != nil {
+		t.Fatalf("NewRunner returned error: %v", err)
+	}
+
+	if e := NewExecutor(nil, &Options{Maintenance: runner}); e.location != loc {
+		t.Errorf("NewExecutor used location %v, want the location of the runner %v", e.location, loc)
+	}
+	if e := NewExecutor(nil, &Options{Location: time.UTC, Maintenance: runner}); e.location != time.UTC {
+		t.Errorf("NewExecutor used location %v, want %v", e.location, time.UTC)
+	}
+}
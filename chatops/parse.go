@@ -0,0 +1,366 @@
+/*
+Package chatops parses and executes status page commands of chat bots.
+
+Commands are parsed into typed operations and executed against a Cachet instance.
+The result is a human-readable reply for the chat:
+
+	e := chatops.NewExecutor(client, nil)
+	reply := e.Run(`incident open api "DB latency" major`)
+	// Opened incident #42 "DB latency" for API (Major Outage).
+
+Supported commands:
+
+	status                                       overall status and components with issues
+	status <component>                           status of a component
+	status <component> <component status>        set the status of a component
+	incident open <component|-> <name> [<component status>] [<message>]
+	incident update <id> <incident status> <message> [<component status>]
+	incident close <id> [<message>]
+	maint schedule <component>[,<component>...] <duration> [in <duration>] [<name>]
+	help
+
+Maintenance windows are run by a maintenance.Runner, see Options.Maintenance.
+Components are referenced by ID or name (case insensitive, unique prefixes are enough).
+Arguments with spaces are quoted with single or double quotes.
+Component statuses are operational, performance, partial and major,
+incident statuses are investigating, identified, watching and fixed.
+*/
+package chatops
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// Usage is the reply of the help command.
+const Usage = "Commands:\n" +
+	"  status [<component> [<status>]]\n" +
+	"  incident open <component|-> <name> [<component status>] [<message>]\n" +
+	"  incident update <id> <incident status> <message> [<component status>]\n" +
+	"  incident close <id> [<message>]\n" +
+	"  maint schedule <component>[,<component>...] <duration> [in <duration>] [<name>]"
+
+// componentStatuses maps the names of component statuses and their aliases to statuses.
+var componentStatuses = map[string]int{
+	"operational": cachet.ComponentStatusOperational,
+	"ok":          cachet.ComponentStatusOperational,
+	"up":          cachet.ComponentStatusOperational,
+	"performance": cachet.ComponentStatusPerformanceIssues,
+	"degraded":    cachet.ComponentStatusPerformanceIssues,
+	"slow":        cachet.ComponentStatusPerformanceIssues,
+	"partial":     cachet.ComponentStatusPartialOutage,
+	"major":       cachet.ComponentStatusMajorOutage,
+	"down":        cachet.ComponentStatusMajorOutage,
+	"outage":      cachet.ComponentStatusMajorOutage,
+}
+
+// incidentStatuses maps the names of incident statuses and their aliases to statuses.
+var incidentStatuses = map[string]int{
+	"investigating": cachet.IncidentStatusInvestigating,
+	"identified":    cachet.IncidentStatusIdentified,
+	"watching":      cachet.IncidentStatusWatching,
+	"monitoring":    cachet.IncidentStatusWatching,
+	"fixed":         cachet.IncidentStatusFixed,
+	"resolved":      cachet.IncidentStatusFixed,
+}
+
+// Command is a parsed command.
+// It is one of *ShowStatus, *SetStatus, *OpenIncident, *UpdateIncident, *CloseIncident, *ScheduleMaintenance or *Help.
+type Command interface {
+	command()
+}
+
+// ShowStatus shows the overall status or, if Component is set, the status of a component.
+type ShowStatus struct {
+	Component string
+}
+
+// SetStatus sets the status of a component.
+type SetStatus struct {
+	Component string
+	Status    int
+}
+
+// OpenIncident opens an incident.
+type OpenIncident struct {
+	// Component is empty for incidents without component.
+	Component string
+	Name      string
+	Message   string
+	// ComponentStatus is 0 if the status of the component is not changed.
+	ComponentStatus int
+}
+
+// UpdateIncident adds an update to an incident.
+type UpdateIncident struct {
+	ID      int
+	Status  int
+	Message string
+	// ComponentStatus is 0 if the status of the component is not changed.
+	ComponentStatus int
+}
+
+// CloseIncident fixes an incident and sets its component to operational.
+type CloseIncident struct {
+	ID      int
+	Message string
+}
+
+// ScheduleMaintenance schedules a maintenance of components.
+type ScheduleMaintenance struct {
+	Components []string
+	Duration   time.Duration
+	// In is the time until the maintenance starts. 0 starts it now.
+	In   time.Duration
+	Name string
+}
+
+// Help shows the usage.
+type Help struct{}
+
+func (*ShowStatus) command()          {}
+func (*SetStatus) command()           {}
+func (*OpenIncident) command()        {}
+func (*UpdateIncident) command()      {}
+func (*CloseIncident) command()       {}
+func (*ScheduleMaintenance) command() {}
+func (*Help) command()                {}
+
+// Parse parses a command line.
+func Parse(line string) (Command, error) {
+	args, err := split(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("No command given. Try help")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "help":
+		return &Help{}, nil
+	case "status":
+		return parseStatus(args[1:])
+	case "incident":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("Usage: incident open|update|close ...")
+		}
+		switch strings.ToLower(args[1]) {
+		case "open":
+			return parseOpen(args[2:])
+		case "update":
+			return parseUpdate(args[2:])
+		case "close", "resolve":
+			return parseClose(args[2:])
+		}
+		return nil, fmt.Errorf("Unknown incident command %q. Try help", args[1])
+	case "maint", "maintenance":
+		if len(args) < 2 || strings.ToLower(args[1]) != "schedule" {
+			return nil, fmt.Errorf("Usage: maint schedule <component>[,<component>...] <duration> [in <duration>] [<name>]")
+		}
+		return parseMaintenance(args[2:])
+	}
+	return nil, fmt.Errorf("Unknown command %q. Try help", args[0])
+}
+
+func parseStatus(args []string) (Command, error) {
+	switch len(args) {
+	case 0:
+		return &ShowStatus{}, nil
+	case 1:
+		return &ShowStatus{Component: args[0]}, nil
+	case 2:
+		status, ok := componentStatuses[strings.ToLower(args[1])]
+		if !ok {
+			return nil, fmt.Errorf("Unknown component status %q. Use operational, performance, partial or major", args[1])
+		}
+		return &SetStatus{Component: args[0], Status: status}, nil
+	}
+	return nil, fmt.Errorf("Usage: status [<component> [<status>]]")
+}
+
+func parseOpen(args []string) (Command, error) {
+	if len(args) < 2 || len(args) > 4 {
+		return nil, fmt.Errorf("Usage: incident open <component|-> <name> [<component status>] [<message>]")
+	}
+
+	c := &OpenIncident{Name: args[1]}
+	if args[0] != "-" {
+		c.Component = args[0]
+	}
+	rest := args[2:]
+	if len(rest) > 0 {
+		if status, ok := componentStatuses[strings.ToLower(rest[0])]; ok {
+			c.ComponentStatus = status
+			rest = rest[1:]
+		}
+	}
+	if len(rest) > 1 {
+		return nil, fmt.Errorf("Unknown component status %q. Use operational, performance, partial or major", args[2])
+	}
+	if len(rest) == 1 {
+		c.Message = rest[0]
+	}
+	if len(c.Component) == 0 && c.ComponentStatus > 0 {
+		return nil, fmt.Errorf("An incident without component can not set a component status")
+	}
+	return c, nil
+}
+
+func parseUpdate(args []string) (Command, error) {
+	if len(args) < 3 || len(args) > 4 {
+		return nil, fmt.Errorf("Usage: incident update <id> <incident status> <message> [<component status>]")
+	}
+
+	id, err := parseID(args[0])
+	if err != nil {
+		return nil, err
+	}
+	status, ok := incidentStatuses[strings.ToLower(args[1])]
+	if !ok {
+		return nil, fmt.Errorf("Unknown incident status %q. Use investigating, identified, watching or fixed", args[1])
+	}
+
+	c := &UpdateIncident{ID: id, Status: status, Message: args[2]}
+	if len(args) == 4 {
+		if c.ComponentStatus, ok = componentStatuses[strings.ToLower(args[3])]; !ok {
+			return nil, fmt.Errorf("Unknown component status %q. Use operational, performance, partial or major", args[3])
+		}
+	}
+	return c, nil
+}
+
+func parseClose(args []string) (Command, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("Usage: incident close <id> [<message>]")
+	}
+
+	id, err := parseID(args[0])
+	if err != nil {
+		return nil, err
+	}
+	c := &CloseIncident{ID: id}
+	if len(args) == 2 {
+		c.Message = args[1]
+	}
+	return c, nil
+}
+
+func parseMaintenance(args []string) (Command, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("Usage: maint schedule <component>[,<component>...] <duration> [in <duration>] [<name>]")
+	}
+
+	c := &ScheduleMaintenance{}
+	for _, name := range strings.Split(args[0], ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			c.Components = append(c.Components, name)
+		}
+	}
+	if len(c.Components) == 0 {
+		return nil, fmt.Errorf("No component given")
+	}
+
+	var err error
+	if c.Duration, err = parseDuration(args[1]); err != nil {
+		return nil, err
+	}
+
+	rest := args[2:]
+	if len(rest) >= 2 && strings.ToLower(rest[0]) == "in" {
+		if c.In, err = parseDuration(rest[1]); err != nil {
+			return nil, err
+		}
+		rest = rest[2:]
+	}
+	if len(rest) > 1 {
+		return nil, fmt.Errorf("Too many arguments. Quote names with spaces")
+	}
+	if len(rest) == 1 {
+		c.Name = rest[0]
+	}
+	return c, nil
+}
+
+// parseID parses an incident ID with an optional # prefix.
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(s, "#"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("Invalid incident ID %q", s)
+	}
+	return id, nil
+}
+
+// parseDuration parses a positive duration like 90m or 2h. Days are supported as 1d.
+func parseDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if strings.HasSuffix(s, "d") {
+		var days int
+		if days, err = strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil {
+			d = time.Duration(days) * 24 * time.Hour
+		}
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("Invalid duration %q. Use e.g. 30m or 2h", s)
+	}
+	return d, nil
+}
+
+// split splits a command line into arguments. Single and double quotes group arguments,
+// a backslash escapes the next character within double quotes and outside of quotes.
+// Typographic quotes of chat clients are treated as plain quotes.
+func split(line string) ([]string, error) {
+	line = strings.NewReplacer("“", `"`, "”", `"`, "‘", "'", "’", "'").Replace(line)
+
+	var args []string
+	var current []rune
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current = append(current, r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current = append(current, r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, string(current))
+				current, inArg = nil, false
+			}
+		default:
+			current = append(current, r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("Unclosed quote")
+	}
+	if escaped {
+		current = append(current, '\\')
+	}
+	if inArg {
+		args = append(args, string(current))
+	}
+	return args, nil
+}
@@ -0,0 +1,93 @@
+package chatops
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestParse(t *testing.T) {
+	mockData := []struct {
+		line     string
+		expected Command
+	}{
+		{"help", &Help{}},
+		{"status", &ShowStatus{}},
+		{"status api", &ShowStatus{Component: "api"}},
+		{"STATUS api Major", &SetStatus{Component: "api", Status: cachet.ComponentStatusMajorOutage}},
+		{`status "Public API" ok`, &SetStatus{Component: "Public API", Status: cachet.ComponentStatusOperational}},
+		{"incident open api 'DB latency'", &OpenIncident{Component: "api", Name: "DB latency"}},
+		{`incident open api "DB latency" partial "Queries are slow"`, &OpenIncident{Component: "api", Name: "DB latency", Message: "Queries are slow", ComponentStatus: cachet.ComponentStatusPartialOutage}},
+		{`incident open - “Mails delayed” 'We are on it'`, &OpenIncident{Name: "Mails delayed", Message: "We are on it"}},
+		{"incident update 42 watching 'fix deployed'", &UpdateIncident{ID: 42, Status: cachet.IncidentStatusWatching, Message: "fix deployed"}},
+		{`incident update #42 identified "It's the \"cache\"" degraded`, &UpdateIncident{ID: 42, Status: cachet.IncidentStatusIdentified, Message: `It's the "cache"`, ComponentStatus: cachet.ComponentStatusPerformanceIssues}},
+		{"incident close 42", &CloseIncident{ID: 42}},
+		{"incident resolve 42 'All good'", &CloseIncident{ID: 42, Message: "All good"}},
+		{"maint schedule db 2h", &ScheduleMaintenance{Components: []string{"db"}, Duration: 2 * time.Hour}},
+		{"maint schedule db,api 90m in 1d 'Database upgrade'", &ScheduleMaintenance{Components: []string{"db", "api"}, Duration: 90 * time.Minute, In: 24 * time.Hour, Name: "Database upgrade"}},
+	}
+
+	for _, data := range mockData {
+		got, err := Parse(data.line)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", data.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, data.expected) {
+			t.Errorf("Parse(%q) returned %+v, want %+v", data.line, got, data.expected)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	mockData := map[string]string{
+		"":                                      "No command given. Try help",
+		"deploy api":                            `Unknown command "deploy". Try help`,
+		"status api broken":                     `Unknown component status "broken". Use operational, performance, partial or major`,
+		"status api major now":                  "Usage: status [<component> [<status>]]",
+		"incident":                              "Usage: incident open|update|close ...",
+		"incident delete 1":                     `Unknown incident command "delete". Try help`,
+		"incident open api":                     "Usage: incident open <component|-> <name> [<component status>] [<message>]",
+		"incident open api 'DB' slow msg x":     "Usage: incident open <component|-> <name> [<component status>] [<message>]",
+		"incident open api DB latency high":     `Unknown component status "latency". Use operational, performance, partial or major`,
+		"incident open - DB major":              "An incident without component can not set a component status",
+		"incident update x watching fixed":      `Invalid incident ID "x"`,
+		"incident update 1 sleeping zzz":        `Unknown incident status "sleeping". Use investigating, identified, watching or fixed`,
+		"incident update 1 watching 'a' bad":    `Unknown component status "bad". Use operational, performance, partial or major`,
+		"incident close":                        "Usage: incident close <id> [<message>]",
+		"maint db 2h":                           "Usage: maint schedule <component>[,<component>...] <duration> [in <duration>] [<name>]",
+		"maint schedule db forever":             `Invalid duration "forever". Use e.g. 30m or 2h`,
+		"maint schedule db -2h":                 `Invalid duration "-2h". Use e.g. 30m or 2h`,
+		"maint schedule , 2h":                   "No component given",
+		"maint schedule db 2h Database upgrade": "Too many arguments. Quote names with spaces",
+		"status 'api":                           "Unclosed quote",
+	}
+
+	for line, expected := range mockData {
+		_, err := Parse(line)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Parse(%q) returned %v, want %q", line, err, expected)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	mockData := map[string][]string{
+		`a  b	c`:            {"a", "b", "c"},
+		`a "b c" 'd e'`:     {"a", "b c", "d e"},
+		`a "" b`:            {"a", "", "b"},
+		`a\ b 'c\d'`:        {"a b", `c\d`},
+		`x"y z"`:            {"xy z"},
+		`trailing\`:         {`trailing\`},
+		`‘single’ “double”`: {"single", "double"},
+	}
+
+	for line, expected := range mockData {
+		got, err := split(line)
+		if err != nil || !reflect.DeepEqual(got, expected) {
+			t.Errorf("split(%q) returned %q, %v, want %q", line, got, err, expected)
+		}
+	}
+}
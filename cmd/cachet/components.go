@@ -0,0 +1,171 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var componentsResource = &resource{
+	name: "components",
+	help: "Manage components",
+	commands: []*command{
+		{name: "list", args: "[flags]", help: "List all components", run: componentsList},
+		{name: "get", args: "<component>", help: "Show a single component", run: componentsGet},
+		{name: "create", args: "[flags]", help: "Create a new component", run: componentsCreate},
+		{name: "update", args: "[flags] <component>", help: "Update a component", run: componentsUpdate},
+		{name: "delete", args: "<component>", help: "Delete a component", run: componentsDelete},
+	},
+}
+
+var componentGroupsResource = &resource{
+	name: "groups",
+	help: "Manage component groups",
+	commands: []*command{
+		{name: "list", args: "[flags]", help: "List all component groups", run: componentGroupsList},
+		{name: "get", args: "<group>", help: "Show a single component group", run: componentGroupsGet},
+		{name: "create", args: "[flags]", help: "Create a new component group", run: componentGroupsCreate},
+		{name: "update", args: "[flags] <group>", help: "Update a component group", run: componentGroupsUpdate},
+		{name: "delete", args: "<group>", help: "Delete a component group", run: componentGroupsDelete},
+	},
+}
+
+func componentsList(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	filter := &cachet.ComponentsQueryParams{}
+	fs.StringVar(&filter.Name, "name", "", "Filter by name")
+	enumVar(fs, &filter.Status, "status", componentStatuses, "Filter by status")
+	fs.IntVar(&filter.GroupID, "group", 0, "Filter by component group ID")
+	fs.BoolVar(&filter.Enabled, "enabled", false, "Only show enabled components")
+	queryOptionsVar(fs, &filter.QueryOptions)
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Components.GetAll(filter)
+	return v, err
+}
+
+func componentsGet(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "component")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Components.Get(ids[0])
+	return v, err
+}
+
+// componentVar defines the flags to fill a component.
+func componentVar(fs *flag.FlagSet, co *cachet.Component) {
+	fs.StringVar(&co.Name, "name", "", "Name of the component")
+	fs.StringVar(&co.Description, "description", "", "Description of the component")
+	fs.StringVar(&co.Link, "link", "", "Hyperlink to the component")
+	enumVar(fs, &co.Status, "status", componentStatuses, "Status of the component")
+	fs.IntVar(&co.Order, "order", 0, "Order of the component")
+	fs.IntVar(&co.GroupID, "group", 0, "ID of the component group")
+	fs.BoolVar(&co.Enabled, "enabled", false, "Enable the component")
+}
+
+func componentsCreate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	co := &cachet.Component{}
+	componentVar(fs, co)
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	if len(co.Name) == 0 {
+		return nil, usageError("-name is required")
+	}
+
+	v, _, err := c.Components.Create(co)
+	return v, err
+}
+
+func componentsUpdate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	co := &cachet.Component{}
+	componentVar(fs, co)
+	ids, err := parseArgs(fs, args, "component")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Components.Update(ids[0], co)
+	return v, err
+}
+
+func componentsDelete(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "component")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Components.Delete(ids[0])
+	return nil, err
+}
+
+func componentGroupsList(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	filter := &cachet.ComponentGroupsQueryParams{}
+	fs.StringVar(&filter.Name, "name", "", "Filter by name")
+	fs.BoolVar(&filter.Collapsed, "collapsed", false, "Only show collapsed groups")
+	fs.IntVar(&filter.Visible, "visible", 0, "Filter by visibility (1 = public, 0 = logged in users)")
+	queryOptionsVar(fs, &filter.QueryOptions)
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.ComponentGroups.GetAll(filter)
+	return v, err
+}
+
+func componentGroupsGet(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "group")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.ComponentGroups.Get(ids[0])
+	return v, err
+}
+
+// componentGroupVar defines the flags to fill a component group.
+func componentGroupVar(fs *flag.FlagSet, g *cachet.ComponentGroup) {
+	fs.StringVar(&g.Name, "name", "", "Name of the component group")
+	fs.IntVar(&g.Order, "order", 0, "Order of the component group")
+	fs.IntVar(&g.Collapsed, "collapsed", 0, "Collapse the group (0 = no, 1 = yes, 2 = if not operational)")
+	fs.IntVar(&g.Visible, "visible", 0, "Visibility of the group (1 = public, 0 = logged in users)")
+}
+
+func componentGroupsCreate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	g := &cachet.ComponentGroup{}
+	componentGroupVar(fs, g)
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	if len(g.Name) == 0 {
+		return nil, usageError("-name is required")
+	}
+
+	v, _, err := c.ComponentGroups.Create(g)
+	return v, err
+}
+
+func componentGroupsUpdate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	g := &cachet.ComponentGroup{}
+	componentGroupVar(fs, g)
+	ids, err := parseArgs(fs, args, "group")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.ComponentGroups.Update(ids[0], g)
+	return v, err
+}
+
+func componentGroupsDelete(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "group")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.ComponentGroups.Delete(ids[0])
+	return nil, err
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComponentsCommands(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			if got, want := r.URL.Query().Get("status"), "4"; got != want {
+				t.Errorf("status query param is %q, want %q", got, want)
+			}
+			fmt.Fprint(w, `{"data":[{"id":1,"name":"API","status":4}]}`)
+		case "POST":
+			body := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			expected := map[string]interface{}{"name": "API", "status": float64(1), "group_id": float64(2), "enabled": true}
+			if !reflect.DeepEqual(body, expected) {
+				t.Errorf("Request body is %+v, want %+v", body, expected)
+			}
+			fmt.Fprint(w, `{"data":{"id":1,"name":"API","status":1}}`)
+		default:
+			t.Errorf("Unexpected request method %s", r.Method)
+		}
+	})
+
+	testMux.HandleFunc("/api/v1/components/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mockData := []struct {
+		Args   []string
+		Stdout string
+	}{
+		{[]string{"components", "list", "-status", "major"}, `"name": "API"`},
+		{[]string{"components", "create", "-name", "API", "-status", "operational", "-group", "2", "-enabled"}, `"id": 1`},
+		{[]string{"components", "delete", "1"}, ""},
+	}
+
+	for _, mock := range mockData {
+		code, stdout, stderr := testRun(mock.Args...)
+		if code != 0 {
+			t.Errorf("run(%v) returned exit code %d: %s", mock.Args, code, stderr)
+		}
+		if len(mock.Stdout) == 0 && len(stdout) > 0 {
+			t.Errorf("run(%v) printed %q, want nothing", mock.Args, stdout)
+		}
+		if len(mock.Stdout) > 0 && !strings.Contains(stdout, mock.Stdout) {
+			t.Errorf("run(%v) printed %q, want it to contain %q", mock.Args, stdout, mock.Stdout)
+		}
+	}
+}
+
+func TestComponentsCreate_NameRequired(t *testing.T) {
+	setup()
+	defer teardown()
+
+	code, _, _ := testRun("components", "create", "-status", "1")
+	if code != 2 {
+		t.Errorf("run returned exit code %d, want 2", code)
+	}
+}
+
+func TestComponentGroupsCommands(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/components/groups/3", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		fmt.Fprint(w, `{"data":{"id":3,"name":"Websites","collapsed":1}}`)
+	})
+
+	code, stdout, stderr := testRun("groups", "update", "-collapsed", "1", "3")
+	if code != 0 {
+		t.Fatalf("run returned exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"name": "Websites"`) {
+		t.Errorf("run printed %q, want the updated group", stdout)
+	}
+}
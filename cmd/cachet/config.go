@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// config contains the settings to talk to a Cachet instance.
+type config struct {
+	Instance string `json:"instance"`
+	Token    string `json:"token"`
+}
+
+// resolveConfig builds the configuration out of the config file,
+// the environment and the global flags.
+// Flags take precedence over environment variables, environment variables over the config file.
+func resolveConfig(configFile, instance, token string, getenv func(string) string) (*config, error) {
+	explicit := true
+	if len(configFile) == 0 {
+		configFile = getenv("CACHET_CONFIG")
+	}
+	if len(configFile) == 0 {
+		explicit = false
+		if home := getenv("HOME"); len(home) > 0 {
+			configFile = filepath.Join(home, ".cachet.json")
+		}
+	}
+
+	cfg := &config{}
+	if len(configFile) > 0 {
+		c, err := readConfig(configFile)
+		if err != nil && (explicit || !os.IsNotExist(err)) {
+			return nil, err
+		}
+		if c != nil {
+			cfg = c
+		}
+	}
+
+	if v := getenv("CACHET_INSTANCE"); len(v) > 0 {
+		cfg.Instance = v
+	}
+	if v := getenv("CACHET_TOKEN"); len(v) > 0 {
+		cfg.Token = v
+	}
+	if len(instance) > 0 {
+		cfg.Instance = instance
+	}
+	if len(token) > 0 {
+		cfg.Token = token
+	}
+
+	return cfg, nil
+}
+
+// readConfig reads a JSON config file.
+func readConfig(path string) (*config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("Config file %s is not valid: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// client returns a Cachet client for the configured instance.
+func (c *config) client() (*cachet.Client, error) {
+	if len(c.Instance) == 0 {
+		return nil, fmt.Errorf("No Cachet instance given. Use -instance, CACHET_INSTANCE or a config file")
+	}
+
+	client, err := cachet.NewClient(c.Instance, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Token) > 0 {
+		client.Authentication.SetTokenAuth(c.Token)
+	}
+	return client, nil
+}
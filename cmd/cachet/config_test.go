@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cachet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "config.json")
+	err = ioutil.WriteFile(configFile, []byte(`{"instance":"https://file.example.com/","token":"file-token"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockData := []struct {
+		ConfigFile string
+		Instance   string
+		Token      string
+		Env        map[string]string
+		Expected   *config
+	}{
+		{"", "", "", nil, &config{}},
+		{configFile, "", "", nil, &config{Instance: "https://file.example.com/", Token: "file-token"}},
+		{"", "", "", map[string]string{"CACHET_CONFIG": configFile}, &config{Instance: "https://file.example.com/", Token: "file-token"}},
+		{"", "", "", map[string]string{"HOME": dir}, &config{}},
+		{configFile, "", "", map[string]string{"CACHET_TOKEN": "env-token"}, &config{Instance: "https://file.example.com/", Token: "env-token"}},
+		{configFile, "https://flag.example.com/", "flag-token", map[string]string{"CACHET_INSTANCE": "https://env.example.com/", "CACHET_TOKEN": "env-token"}, &config{Instance: "https://flag.example.com/", Token: "flag-token"}},
+	}
+
+	for _, mock := range mockData {
+		got, err := resolveConfig(mock.ConfigFile, mock.Instance, mock.Token, testEnv(mock.Env))
+		if err != nil {
+			t.Errorf("resolveConfig returned error: %v", err)
+		}
+		if !reflect.DeepEqual(got, mock.Expected) {
+			t.Errorf("resolveConfig returned %+v, want %+v", got, mock.Expected)
+		}
+	}
+}
+
+func TestResolveConfig_Errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cachet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	invalidFile := filepath.Join(dir, "invalid.json")
+	if err := ioutil.WriteFile(invalidFile, []byte(`instance: foo`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mockData := []string{
+		filepath.Join(dir, "not-existing.json"),
+		invalidFile,
+	}
+	for _, configFile := range mockData {
+		if _, err := resolveConfig(configFile, "", "", testEnv(nil)); err == nil {
+			t.Errorf("resolveConfig(%q) returned no error. Expected one.", configFile)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// componentStatuses maps human readable names to cachet.ComponentStatus* values.
+	componentStatuses = map[string]int{
+		"unknown":     cachet.ComponentStatusUnknown,
+		"operational": cachet.ComponentStatusOperational,
+		"performance": cachet.ComponentStatusPerformanceIssues,
+		"partial":     cachet.ComponentStatusPartialOutage,
+		"major":       cachet.ComponentStatusMajorOutage,
+	}
+
+	// incidentStatuses maps human readable names to cachet.IncidentStatus* values.
+	incidentStatuses = map[string]int{
+		"scheduled":     cachet.IncidentStatusScheduled,
+		"investigating": cachet.IncidentStatusInvestigating,
+		"identified":    cachet.IncidentStatusIdentified,
+		"watching":      cachet.IncidentStatusWatching,
+		"fixed":         cachet.IncidentStatusFixed,
+	}
+
+	// scheduleStatuses maps human readable names to cachet.Schedule* values.
+	scheduleStatuses = map[string]int{
+		"upcoming":    cachet.ScheduleUpcoming,
+		"in-progress": cachet.ScheduleInProgress,
+		"complete":    cachet.ScheduleComplete,
+	}
+)
+
+// flagParseError is returned if the flag package already reported a problem to the user.
+type flagParseError struct {
+	err error
+}
+
+func (e flagParseError) Error() string {
+	return e.err.Error()
+}
+
+// enumValue is a flag.Value that accepts either a number or one of the given names.
+type enumValue struct {
+	value *int
+	names map[string]int
+}
+
+// enumVar defines a flag that accepts a number or one of the keys of names.
+func enumVar(fs *flag.FlagSet, p *int, name string, names map[string]int, usage string) {
+	fs.Var(&enumValue{value: p, names: names}, name, fmt.Sprintf("%s (%s or a number)", usage, strings.Join(sortedNames(names), ", ")))
+}
+
+func (e *enumValue) String() string {
+	if e.value == nil {
+		return ""
+	}
+	return strconv.Itoa(*e.value)
+}
+
+func (e *enumValue) Set(s string) error {
+	if v, ok := e.names[strings.ToLower(s)]; ok {
+		*e.value = v
+		return nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("unknown value %q, expected one of %s", s, strings.Join(sortedNames(e.names), ", "))
+	}
+	*e.value = v
+	return nil
+}
+
+// sortedNames returns the keys of names sorted by their value.
+func sortedNames(names map[string]int) []string {
+	keys := make([]string, 0, len(names))
+	for k := range names {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if names[keys[i]] == names[keys[j]] {
+			return keys[i] < keys[j]
+		}
+		return names[keys[i]] < names[keys[j]]
+	})
+	return keys
+}
+
+// intList is a flag.Value for a comma separated list of IDs.
+type intList []int
+
+func (l *intList) String() string {
+	s := make([]string, 0, len(*l))
+	for _, i := range *l {
+		s = append(s, strconv.Itoa(i))
+	}
+	return strings.Join(s, ",")
+}
+
+func (l *intList) Set(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		i, err := strconv.Atoi(part)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid ID", part)
+		}
+		*l = append(*l, i)
+	}
+	return nil
+}
+
+// queryOptionsVar defines the general paging and sorting flags of list commands.
+func queryOptionsVar(fs *flag.FlagSet, o *cachet.QueryOptions) {
+	fs.IntVar(&o.Page, "page", 0, "Page to fetch")
+	fs.IntVar(&o.PerPage, "per-page", 0, "Number of results per page")
+	fs.StringVar(&o.SortField, "sort", "", "Field to sort by")
+	fs.StringVar(&o.OrderType, "sort-order", "", "Sort order (asc or desc)")
+}
+
+// parseArgs parses the flags in args and returns the positional arguments as IDs.
+// names describe the expected positional arguments, e.g. "incident" and "update".
+func parseArgs(fs *flag.FlagSet, args []string, names ...string) ([]int, error) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		return nil, flagParseError{err}
+	}
+
+	if fs.NArg() != len(names) {
+		if len(names) == 0 {
+			return nil, usageError(fmt.Sprintf("unexpected arguments: %s", strings.Join(fs.Args(), " ")))
+		}
+		return nil, usageError(fmt.Sprintf("expected %d argument(s): %s", len(names), strings.Join(names, " ")))
+	}
+
+	ids := make([]int, 0, len(names))
+	for i, name := range names {
+		id, err := strconv.Atoi(fs.Arg(i))
+		if err != nil {
+			return nil, usageError(fmt.Sprintf("%s ID %q is not a number", name, fs.Arg(i)))
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
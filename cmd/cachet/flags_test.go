@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestEnumValue_Set(t *testing.T) {
+	mockData := []struct {
+		Value    string
+		Expected int
+		Error    bool
+	}{
+		{"major", cachet.ComponentStatusMajorOutage, false},
+		{"Operational", cachet.ComponentStatusOperational, false},
+		{"3", cachet.ComponentStatusPartialOutage, false},
+		{"broken", 0, true},
+	}
+
+	for _, mock := range mockData {
+		var got int
+		v := &enumValue{value: &got, names: componentStatuses}
+		err := v.Set(mock.Value)
+		if (err != nil) != mock.Error {
+			t.Errorf("enumValue.Set(%q) returned error %v, want error: %v", mock.Value, err, mock.Error)
+		}
+		if got != mock.Expected {
+			t.Errorf("enumValue.Set(%q) set %d, want %d", mock.Value, got, mock.Expected)
+		}
+	}
+}
+
+func TestIntList_Set(t *testing.T) {
+	var l intList
+	if err := l.Set("1, 2,,3"); err != nil {
+		t.Errorf("intList.Set returned error: %v", err)
+	}
+	if expected := (intList{1, 2, 3}); !reflect.DeepEqual(l, expected) {
+		t.Errorf("intList.Set returned %v, want %v", l, expected)
+	}
+	if err := l.Set("a"); err == nil {
+		t.Error("intList.Set returned no error. Expected one.")
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	name := fs.String("name", "", "")
+
+	ids, err := parseArgs(fs, []string{"-name", "API", "1", "2"}, "incident", "update")
+	if err != nil {
+		t.Errorf("parseArgs returned error: %v", err)
+	}
+	if expected := []int{1, 2}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("parseArgs returned %v, want %v", ids, expected)
+	}
+	if *name != "API" {
+		t.Errorf("parseArgs parsed name %q, want %q", *name, "API")
+	}
+}
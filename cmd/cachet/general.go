@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var generalResource = &resource{
+	name: "general",
+	help: "Ping the API and show version and status",
+	commands: []*command{
+		{name: "ping", args: "", help: "Call the API test endpoint", run: generalPing},
+		{name: "version", args: "", help: "Show the Cachet version", run: generalVersion},
+		{name: "status", args: "", help: "Show the Cachet system status", run: generalStatus},
+	},
+}
+
+func generalPing(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.General.Ping()
+	return v, err
+}
+
+func generalVersion(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.General.Version()
+	return v, err
+}
+
+func generalStatus(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.General.Status()
+	return v, err
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var incidentsResource = &resource{
+	name: "incidents",
+	help: "Manage incidents",
+	commands: []*command{
+		{name: "list", args: "[flags]", help: "List all incidents", run: incidentsList},
+		{name: "get", args: "<incident>", help: "Show a single incident", run: incidentsGet},
+		{name: "create", args: "[flags]", help: "Create a new incident", run: incidentsCreate},
+		{name: "update", args: "[flags] <incident>", help: "Update an incident", run: incidentsUpdate},
+		{name: "delete", args: "<incident>", help: "Delete an incident", run: incidentsDelete},
+	},
+}
+
+var incidentUpdatesResource = &resource{
+	name: "updates",
+	help: "Manage incident updates",
+	commands: []*command{
+		{name: "list", args: "<incident>", help: "List all updates of an incident", run: incidentUpdatesList},
+		{name: "get", args: "<incident> <update>", help: "Show a single incident update", run: incidentUpdatesGet},
+		{name: "create", args: "[flags] <incident>", help: "Add an update to an incident", run: incidentUpdatesCreate},
+		{name: "update", args: "[flags] <incident> <update>", help: "Update an incident update", run: incidentUpdatesUpdate},
+		{name: "delete", args: "<incident> <update>", help: "Delete an incident update", run: incidentUpdatesDelete},
+	},
+}
+
+func incidentsList(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	filter := &cachet.IncidentsQueryParams{}
+	fs.StringVar(&filter.Name, "name", "", "Filter by name")
+	enumVar(fs, &filter.Status, "status", incidentStatuses, "Filter by status")
+	fs.IntVar(&filter.Visible, "visible", 0, "Filter by visibility (1 = public, 0 = logged in users)")
+	fs.IntVar(&filter.ComponentID, "component", 0, "Filter by component ID")
+	fs.BoolVar(&filter.Stickied, "stickied", false, "Only show stickied incidents")
+	queryOptionsVar(fs, &filter.QueryOptions)
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Incidents.GetAll(filter)
+	return v, err
+}
+
+func incidentsGet(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "incident")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Incidents.Get(ids[0])
+	return v, err
+}
+
+// incidentVar defines the flags to fill an incident.
+func incidentVar(fs *flag.FlagSet, i *cachet.Incident) {
+	fs.StringVar(&i.Name, "name", "", "Name of the incident")
+	fs.StringVar(&i.Message, "message", "", "Message of the incident (Markdown)")
+	enumVar(fs, &i.Status, "status", incidentStatuses, "Status of the incident")
+	fs.IntVar(&i.Visible, "visible", 0, "Visibility of the incident (1 = public, 0 = logged in users)")
+	fs.IntVar(&i.ComponentID, "component", 0, "ID of the affected component")
+	enumVar(fs, &i.ComponentStatus, "component-status", componentStatuses, "New status of the affected component")
+	fs.BoolVar(&i.Notify, "notify", false, "Notify subscribers")
+	fs.BoolVar(&i.Stickied, "stickied", false, "Stick the incident to the top of the status page")
+	fs.StringVar(&i.OccurredAt, "occurred-at", "", "Time the incident occurred at")
+	fs.StringVar(&i.Template, "template", "", "Slug of the incident template to use")
+}
+
+func incidentsCreate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	i := &cachet.Incident{}
+	incidentVar(fs, i)
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	if len(i.Name) == 0 {
+		return nil, usageError("-name is required")
+	}
+
+	v, _, err := c.Incidents.Create(i)
+	return v, err
+}
+
+func incidentsUpdate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	i := &cachet.Incident{}
+	incidentVar(fs, i)
+	ids, err := parseArgs(fs, args, "incident")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Incidents.Update(ids[0], i)
+	return v, err
+}
+
+func incidentsDelete(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "incident")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Incidents.Delete(ids[0])
+	return nil, err
+}
+
+func incidentUpdatesList(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "incident")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.IncidentUpdates.GetAll(ids[0])
+	return v, err
+}
+
+func incidentUpdatesGet(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "incident", "update")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.IncidentUpdates.Get(ids[0], ids[1])
+	return v, err
+}
+
+// incidentUpdateVar defines the flags to fill an incident update.
+func incidentUpdateVar(fs *flag.FlagSet, u *cachet.IncidentUpdate) {
+	enumVar(fs, &u.Status, "status", incidentStatuses, "Status of the incident")
+	fs.StringVar(&u.Message, "message", "", "Message of the update (Markdown)")
+	fs.IntVar(&u.ComponentID, "component", 0, "ID of the affected component")
+	enumVar(fs, &u.ComponentStatus, "component-status", componentStatuses, "New status of the affected component")
+}
+
+func incidentUpdatesCreate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	u := &cachet.IncidentUpdate{}
+	incidentUpdateVar(fs, u)
+	ids, err := parseArgs(fs, args, "incident")
+	if err != nil {
+		return nil, err
+	}
+	if len(u.Message) == 0 {
+		return nil, usageError("-message is required")
+	}
+
+	v, _, err := c.IncidentUpdates.Create(ids[0], u)
+	return v, err
+}
+
+func incidentUpdatesUpdate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	u := &cachet.IncidentUpdate{}
+	incidentUpdateVar(fs, u)
+	ids, err := parseArgs(fs, args, "incident", "update")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.IncidentUpdates.Update(ids[0], ids[1], u)
+	return v, err
+}
+
+func incidentUpdatesDelete(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "incident", "update")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.IncidentUpdates.Delete(ids[0], ids[1])
+	return nil, err
+}
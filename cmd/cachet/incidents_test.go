@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIncidentsCreate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		expected := map[string]interface{}{
+			"name":             "DB latency",
+			"message":          "Looking into it",
+			"status":           float64(1),
+			"visible":          float64(1),
+			"component_id":     float64(3),
+			"component_status": float64(2),
+		}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":42,"name":"DB latency","status":1}}`)
+	})
+
+	code, stdout, stderr := testRun("incidents", "create", "-name", "DB latency", "-message", "Looking into it", "-status", "investigating", "-visible", "1", "-component", "3", "-component-status", "performance")
+	if code != 0 {
+		t.Fatalf("run returned exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"id": 42`) {
+		t.Errorf("run printed %q, want the created incident", stdout)
+	}
+}
+
+func TestIncidentUpdatesCreate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents/42/updates", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		expected := map[string]interface{}{"status": float64(4), "message": "Fix deployed"}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":7,"incident_id":42,"status":4,"message":"Fix deployed"}}`)
+	})
+
+	code, stdout, stderr := testRun("updates", "create", "-status", "fixed", "-message", "Fix deployed", "42")
+	if code != 0 {
+		t.Fatalf("run returned exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"incident_id": 42`) {
+		t.Errorf("run printed %q, want the created update", stdout)
+	}
+}
+
+func TestIncidentUpdatesDelete(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents/42/updates/7", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	code, _, stderr := testRun("updates", "delete", "42", "7")
+	if code != 0 {
+		t.Errorf("run returned exit code %d: %s", code, stderr)
+	}
+}
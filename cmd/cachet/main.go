@@ -0,0 +1,207 @@
+/*
+Command cachet is a command line client for the Cachet API.
+
+It exposes every service of the cachet library as a resource with a set of actions:
+
+	cachet [global flags] <resource> <action> [flags] [arguments]
+
+For example:
+
+	cachet components list -status major
+	cachet incidents create -name "DB latency" -status investigating -component 3
+	cachet updates create -status fixed -message "All good again" 42
+
+The instance URL and the API token are read from (in order of precedence)
+the -instance and -token flags, the CACHET_INSTANCE and CACHET_TOKEN environment
+variables or a JSON config file (-config, CACHET_CONFIG or $HOME/.cachet.json):
+
+	{"instance": "https://status.example.com/", "token": "MY-SECRET-TOKEN"}
+
+Results are written as indented JSON to stdout. Lists include their pagination (meta).
+Use -output to select another format (table, json, yaml, csv or template),
+-columns to select the columns of tables and CSV and -template for the template format:
+
+	cachet -output table -columns id,name,status_name components list
+	cachet -output template -template "{{.Name}}: {{.HumanStatus}}" incidents list
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/format"
+)
+
+// command is a single action of a resource, e.g. "list" of "components".
+type command struct {
+	name string
+	args string
+	help string
+	run  func(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error)
+}
+
+// resource groups the commands that belong to one Cachet service.
+type resource struct {
+	name     string
+	help     string
+	commands []*command
+}
+
+// resources contains every resource the command line tool knows about.
+var resources = []*resource{
+	generalResource,
+	componentsResource,
+	componentGroupsResource,
+	incidentsResource,
+	incidentUpdatesResource,
+	metricsResource,
+	pointsResource,
+	schedulesResource,
+	subscribersResource,
+	subscriptionsResource,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run executes the command line tool with args and returns the exit code.
+// It is separated from main to be able to test it.
+func run(args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	fs := flag.NewFlagSet("cachet", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	instance := fs.String("instance", "", "URL of the Cachet instance (env: CACHET_INSTANCE)")
+	token := fs.String("token", "", "API token of the Cachet instance (env: CACHET_TOKEN)")
+	configFile := fs.String("config", "", "Path to a JSON config file (env: CACHET_CONFIG, default: $HOME/.cachet.json)")
+	output := &format.Options{}
+	fs.StringVar(&output.Format, "output", format.JSON, "Output format ("+strings.Join(format.Formats, ", ")+")")
+	columns := fs.String("columns", "", "Comma separated list of columns for the table and csv output")
+	fs.StringVar(&output.Template, "template", "", "Go template for the template output, executed for every entity")
+	fs.BoolVar(&output.NoHeader, "no-header", false, "Omit the header of the table and csv output")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: cachet [global flags] <resource> <action> [flags] [arguments]\n\nGlobal flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(stderr, "\nResources:\n")
+		for _, r := range resources {
+			fmt.Fprintf(stderr, "  %-14s %s\n", r.name, r.help)
+		}
+		fmt.Fprintf(stderr, "\nRun \"cachet <resource>\" to list the actions of a resource.\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		fs.Usage()
+		return 2
+	}
+	if len(*columns) > 0 {
+		output.Columns = strings.Split(*columns, ",")
+	}
+
+	r := findResource(rest[0])
+	if r == nil {
+		fmt.Fprintf(stderr, "Unknown resource %q\n\n", rest[0])
+		fs.Usage()
+		return 2
+	}
+
+	if len(rest) == 1 {
+		r.usage(stderr)
+		return 2
+	}
+
+	cmd := r.find(rest[1])
+	if cmd == nil {
+		fmt.Fprintf(stderr, "Unknown action %q for resource %q\n\n", rest[1], r.name)
+		r.usage(stderr)
+		return 2
+	}
+
+	cfg, err := resolveConfig(*configFile, *instance, *token, getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	client, err := cfg.client()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	cmdFlags := flag.NewFlagSet(r.name+" "+cmd.name, flag.ContinueOnError)
+	cmdFlags.SetOutput(stderr)
+	cmdFlags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: cachet %s %s %s\n\n%s\n", r.name, cmd.name, cmd.args, cmd.help)
+		cmdFlags.PrintDefaults()
+	}
+
+	v, err := cmd.run(client, cmdFlags, rest[2:])
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		if _, ok := err.(flagParseError); ok {
+			return 2
+		}
+		if _, ok := err.(usageError); ok {
+			fmt.Fprintf(stderr, "Error: %v\n\n", err)
+			cmdFlags.Usage()
+			return 2
+		}
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if v != nil {
+		if err := format.Write(stdout, v, output); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// findResource returns the resource with the given name or nil.
+func findResource(name string) *resource {
+	for _, r := range resources {
+		if r.name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// find returns the command with the given name or nil.
+func (r *resource) find(name string) *command {
+	for _, c := range r.commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// usage prints the available actions of the resource.
+func (r *resource) usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: cachet %s <action> [flags] [arguments]\n\nActions:\n", r.name)
+	for _, c := range r.commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.help)
+	}
+}
+
+// usageError is returned by commands if they were called with wrong arguments.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server to provide mock responses for the commands.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func testMethod(t *testing.T, r *http.Request, want string) {
+	if got := r.Method; got != want {
+		t.Errorf("Request method: %v, want %v", got, want)
+	}
+}
+
+// testEnv is a getenv replacement that does not leak the environment of the test runner.
+func testEnv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+// testRun runs the command line tool against the test server.
+func testRun(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"-instance", testServer.URL, "-token", "secret"}, args...)
+	code := run(args, &stdout, &stderr, testEnv(nil))
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	mockData := []struct {
+		Args   []string
+		Stderr string
+	}{
+		{[]string{}, "Resources:"},
+		{[]string{"unknown"}, `Unknown resource "unknown"`},
+		{[]string{"components"}, "Actions:"},
+		{[]string{"components", "unknown"}, `Unknown action "unknown" for resource "components"`},
+	}
+
+	for _, mock := range mockData {
+		var stdout, stderr bytes.Buffer
+		code := run(mock.Args, &stdout, &stderr, testEnv(nil))
+		if code != 2 {
+			t.Errorf("run(%v) returned exit code %d, want 2", mock.Args, code)
+		}
+		if !strings.Contains(stderr.String(), mock.Stderr) {
+			t.Errorf("run(%v) printed %q, want it to contain %q", mock.Args, stderr.String(), mock.Stderr)
+		}
+	}
+}
+
+func TestRun_NoInstance(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"general", "ping"}, &stdout, &stderr, testEnv(nil))
+	if code != 1 {
+		t.Errorf("run returned exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "No Cachet instance given") {
+		t.Errorf("run printed %q, want a missing instance error", stderr.String())
+	}
+}
+
+func TestRun_Token(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/ping", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.Header.Get("X-Cachet-Token"); got != "secret" {
+			t.Errorf("X-Cachet-Token header is %q, want %q", got, "secret")
+		}
+		fmt.Fprint(w, `{"data":"Pong!"}`)
+	})
+
+	code, stdout, stderr := testRun("general", "ping")
+	if code != 0 {
+		t.Fatalf("run returned exit code %d: %s", code, stderr)
+	}
+	if want := "\"Pong!\"\n"; stdout != want {
+		t.Errorf("run printed %q, want %q", stdout, want)
+	}
+}
+
+func TestRun_APIError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/components/1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+	})
+
+	code, _, stderr := testRun("components", "get", "1")
+	if code != 1 {
+		t.Errorf("run returned exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr, "404") {
+		t.Errorf("run printed %q, want the API error", stderr)
+	}
+}
+
+func TestRun_WrongArguments(t *testing.T) {
+	mockData := [][]string{
+		{"components", "get"},
+		{"components", "get", "abc"},
+		{"components", "get", "-unknown", "1"},
+		{"updates", "get", "1"},
+	}
+
+	setup()
+	defer teardown()
+
+	for _, args := range mockData {
+		code, _, _ := testRun(args...)
+		if code != 2 {
+			t.Errorf("run(%v) returned exit code %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRun_Output(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"data":[{"id":1,"name":"API","status":1,"status_name":"Operational"}]}`)
+	})
+
+	mockData := []struct {
+		Args   []string
+		Stdout string
+	}{
+		{[]string{"-output", "table", "-columns", "id,status_name", "components", "list"}, "ID  STATUS_NAME\n1   Operational\n"},
+		{[]string{"-output", "csv", "-no-header", "-columns", "name", "components", "list"}, "API\n"},
+		{[]string{"-output", "template", "-template", "{{.Name}}", "components", "list"}, "API\n"},
+	}
+
+	for _, mock := range mockData {
+		code, stdout, stderr := testRun(mock.Args...)
+		if code != 0 {
+			t.Errorf("run(%v) returned exit code %d: %s", mock.Args, code, stderr)
+		}
+		if stdout != mock.Stdout {
+			t.Errorf("run(%v) printed %q, want %q", mock.Args, stdout, mock.Stdout)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var metricsResource = &resource{
+	name: "metrics",
+	help: "Manage metrics",
+	commands: []*command{
+		{name: "list", args: "[flags]", help: "List all metrics", run: metricsList},
+		{name: "get", args: "<metric>", help: "Show a single metric", run: metricsGet},
+		{name: "create", args: "[flags]", help: "Create a new metric", run: metricsCreate},
+		{name: "delete", args: "<metric>", help: "Delete a metric", run: metricsDelete},
+	},
+}
+
+var pointsResource = &resource{
+	name: "points",
+	help: "Manage metric points",
+	commands: []*command{
+		{name: "list", args: "<metric>", help: "List all points of a metric", run: pointsList},
+		{name: "create", args: "[flags] <metric>", help: "Add a point to a metric", run: pointsCreate},
+		{name: "delete", args: "<metric> <point>", help: "Delete a metric point", run: pointsDelete},
+	},
+}
+
+var (
+	// metricCalculations maps human readable names to cachet.MetricsCalculation* values.
+	metricCalculations = map[string]int{
+		"sum":     cachet.MetricsCalculationSum,
+		"average": cachet.MetricsCalculationAverage,
+	}
+
+	// metricViews maps human readable names to cachet.MetricsView* values.
+	metricViews = map[string]int{
+		"hour":  cachet.MetricsViewLastHour,
+		"12h":   cachet.MetricsViewLast12Hours,
+		"week":  cachet.MetricsViewLastWeek,
+		"month": cachet.MetricsViewLastMonth,
+	}
+
+	// metricVisibilities maps human readable names to cachet.MetricsVisibility* values.
+	metricVisibilities = map[string]int{
+		"loggedin": cachet.MetricsVisibilityLoggedIn,
+		"public":   cachet.MetricsVisibilityPublic,
+		"hidden":   cachet.MetricsVisibilityHidden,
+	}
+)
+
+func metricsList(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	filter := &cachet.MetricQueryParams{}
+	queryOptionsVar(fs, &filter.QueryOptions)
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Metrics.GetAll(filter)
+	return v, err
+}
+
+func metricsGet(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "metric")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Metrics.Get(ids[0])
+	return v, err
+}
+
+func metricsCreate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	m := &cachet.Metric{}
+	fs.StringVar(&m.Name, "name", "", "Name of the metric")
+	fs.StringVar(&m.Suffix, "suffix", "", "Measurements in")
+	fs.StringVar(&m.Description, "description", "", "Description of the metric")
+	fs.IntVar(&m.DefaultValue, "default-value", 0, "Default value if no points are available")
+	enumVar(fs, &m.CalcType, "calc", metricCalculations, "Calculation of the metric")
+	fs.BoolVar(&m.DisplayChart, "display-chart", false, "Display the chart on the status page")
+	fs.IntVar(&m.Places, "places", 0, "Number of decimal places")
+	enumVar(fs, &m.DefaultView, "view", metricViews, "Default view")
+	fs.IntVar(&m.Threshold, "threshold", 0, "Minutes between metric points")
+	fs.IntVar(&m.Order, "order", 0, "Order of the metric")
+	enumVar(fs, &m.Visible, "visible", metricVisibilities, "Visibility of the metric")
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	if len(m.Name) == 0 {
+		return nil, usageError("-name is required")
+	}
+
+	v, _, err := c.Metrics.Create(m)
+	return v, err
+}
+
+func metricsDelete(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "metric")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Metrics.Delete(ids[0])
+	return nil, err
+}
+
+func pointsList(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "metric")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Metrics.GetPoints(ids[0])
+	return v, err
+}
+
+func pointsCreate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	value := fs.Int("value", 0, "Value of the point")
+	timestamp := fs.String("timestamp", "", "Unix timestamp of the point (default: now)")
+	ids, err := parseArgs(fs, args, "metric")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Metrics.AddPoint(ids[0], *value, *timestamp)
+	return v, err
+}
+
+func pointsDelete(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "metric", "point")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Metrics.DeletePoint(ids[0], ids[1])
+	return nil, err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMetricsCreate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		expected := map[string]interface{}{
+			"name":          "Response time",
+			"suffix":        "ms",
+			"default_value": float64(0),
+			"calc_type":     float64(1),
+			"visible":       float64(1),
+		}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":5,"name":"Response time","suffix":"ms"}}`)
+	})
+
+	code, stdout, stderr := testRun("metrics", "create", "-name", "Response time", "-suffix", "ms", "-calc", "average", "-visible", "public")
+	if code != 0 {
+		t.Fatalf("run returned exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"id": 5`) {
+		t.Errorf("run printed %q, want the created metric", stdout)
+	}
+}
+
+func TestPointsCreate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/metrics/5/points", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		expected := map[string]interface{}{"value": float64(120), "timestamp": "1500000000"}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":9,"metric_id":5,"value":120}}`)
+	})
+
+	code, stdout, stderr := testRun("points", "create", "-value", "120", "-timestamp", "1500000000", "5")
+	if code != 0 {
+		t.Fatalf("run returned exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"metric_id": 5`) {
+		t.Errorf("run printed %q, want the created point", stdout)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var schedulesResource = &resource{
+	name: "schedules",
+	help: "Manage scheduled maintenance",
+	commands: []*command{
+		{name: "list", args: "[flags]", help: "List all schedules", run: schedulesList},
+		{name: "get", args: "<schedule>", help: "Show a single schedule", run: schedulesGet},
+		{name: "create", args: "[flags]", help: "Create a new schedule", run: schedulesCreate},
+		{name: "update", args: "[flags] <schedule>", help: "Update a schedule", run: schedulesUpdate},
+		{name: "delete", args: "<schedule>", help: "Delete a schedule", run: schedulesDelete},
+	},
+}
+
+func schedulesList(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	filter := &cachet.SchedulesQueryParams{}
+	fs.StringVar(&filter.Name, "name", "", "Filter by name")
+	enumVar(fs, &filter.Status, "status", scheduleStatuses, "Filter by status")
+	queryOptionsVar(fs, &filter.QueryOptions)
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Schedules.GetAll(filter)
+	return v, err
+}
+
+func schedulesGet(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "schedule")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Schedules.Get(ids[0])
+	return v, err
+}
+
+// scheduleFlags contains the values of the flags to fill a schedule.
+type scheduleFlags struct {
+	schedule   cachet.Schedule
+	components intList
+}
+
+// scheduleVar defines the flags to fill a schedule.
+func scheduleVar(fs *flag.FlagSet) *scheduleFlags {
+	f := &scheduleFlags{}
+	fs.StringVar(&f.schedule.Name, "name", "", "Name of the schedule")
+	fs.StringVar(&f.schedule.Message, "message", "", "Message of the schedule (Markdown)")
+	enumVar(fs, &f.schedule.Status, "status", scheduleStatuses, "Status of the schedule")
+	fs.StringVar(&f.schedule.ScheduledAt, "scheduled-at", "", "Start of the schedule")
+	fs.StringVar(&f.schedule.CompletedAt, "completed-at", "", "End of the schedule")
+	fs.Var(&f.components, "components", "Comma separated list of affected component IDs")
+	return f
+}
+
+// build returns the schedule described by the flags.
+func (f *scheduleFlags) build() *cachet.Schedule {
+	s := f.schedule
+	for _, id := range f.components {
+		s.Components = append(s.Components, cachet.Component{ID: id})
+	}
+	return &s
+}
+
+func schedulesCreate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	f := scheduleVar(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	if len(f.schedule.Name) == 0 {
+		return nil, usageError("-name is required")
+	}
+
+	v, _, err := c.Schedules.Create(f.build())
+	return v, err
+}
+
+func schedulesUpdate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	f := scheduleVar(fs)
+	ids, err := parseArgs(fs, args, "schedule")
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Schedules.Update(ids[0], f.build())
+	return v, err
+}
+
+func schedulesDelete(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "schedule")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Schedules.Delete(ids[0])
+	return nil, err
+}
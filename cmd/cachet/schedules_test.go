@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestSchedulesCreate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := &cachet.Schedule{}
+		json.NewDecoder(r.Body).Decode(body)
+		expected := &cachet.Schedule{
+			Name:        "DB patching",
+			ScheduledAt: "2017-09-26 10:00:00",
+			Components:  []cachet.Component{{ID: 1}, {ID: 2}},
+		}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":3,"name":"DB patching","status":0}}`)
+	})
+
+	code, stdout, stderr := testRun("schedules", "create", "-name", "DB patching", "-scheduled-at", "2017-09-26 10:00:00", "-components", "1,2")
+	if code != 0 {
+		t.Fatalf("run returned exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"id": 3`) {
+		t.Errorf("run printed %q, want the created schedule", stdout)
+	}
+}
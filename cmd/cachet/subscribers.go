@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var subscribersResource = &resource{
+	name: "subscribers",
+	help: "Manage subscribers",
+	commands: []*command{
+		{name: "list", args: "[flags]", help: "List all subscribers", run: subscribersList},
+		{name: "create", args: "[flags]", help: "Create a new subscriber", run: subscribersCreate},
+		{name: "delete", args: "<subscriber>", help: "Delete a subscriber", run: subscribersDelete},
+	},
+}
+
+var subscriptionsResource = &resource{
+	name: "subscriptions",
+	help: "Manage subscriptions",
+	commands: []*command{
+		{name: "delete", args: "<subscription>", help: "Delete a subscription", run: subscriptionsDelete},
+	},
+}
+
+func subscribersList(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	filter := &cachet.SubscribersQueryParams{}
+	queryOptionsVar(fs, &filter.QueryOptions)
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.Subscribers.GetAll(filter)
+	return v, err
+}
+
+func subscribersCreate(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	email := fs.String("email", "", "Email address of the subscriber")
+	verify := fs.Bool("verify", false, "Mark the subscriber as verified without sending a verification email")
+	if _, err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	if len(*email) == 0 {
+		return nil, usageError("-email is required")
+	}
+
+	v := 0
+	if *verify {
+		v = 1
+	}
+	s, _, err := c.Subscribers.Create(*email, v)
+	return s, err
+}
+
+func subscribersDelete(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "subscriber")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Subscribers.Delete(ids[0])
+	return nil, err
+}
+
+func subscriptionsDelete(c *cachet.Client, fs *flag.FlagSet, args []string) (interface{}, error) {
+	ids, err := parseArgs(fs, args, "subscription")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Subscriptions.Delete(ids[0])
+	return nil, err
+}
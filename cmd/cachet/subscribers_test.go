@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSubscribersCreate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/subscribers", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		expected := map[string]interface{}{"email": "oncall@example.com", "verify": float64(1)}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":2,"email":"oncall@example.com"}}`)
+	})
+
+	code, stdout, stderr := testRun("subscribers", "create", "-email", "oncall@example.com", "-verify")
+	if code != 0 {
+		t.Fatalf("run returned exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"email": "oncall@example.com"`) {
+		t.Errorf("run printed %q, want the created subscriber", stdout)
+	}
+}
+
+func TestSubscriptionsDelete(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/subscription/4", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	code, _, stderr := testRun("subscriptions", "delete", "4")
+	if code != 0 {
+		t.Errorf("run returned exit code %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Package dedup prevents duplicate open incidents for the same component.
+
+An Ensurer looks for an unresolved incident of the component before creating a new one.
+If there is one, the new message is added to it as incident update instead:
+
+	e := dedup.NewEnsurer(client, &dedup.Options{MatchName: true})
+
+	incident, created, err := e.EnsureIncident(&cachet.Incident{
+		Name:            "API is down",
+		Message:         "Health checks are failing",
+		Status:          cachet.IncidentStatusInvestigating,
+		ComponentID:     3,
+		ComponentStatus: cachet.ComponentStatusMajorOutage,
+		Visible:         cachet.IncidentVisibilityPublic,
+	})
+
+Calls for the same component are serialized within the process,
+so concurrent alerts can not create duplicates. Use one Ensurer per instance.
+
+Integrations with their own rules for open incidents use WithOpenIncident,
+which passes the open incident of a component to a function under the same lock:
+
+	err := e.WithOpenIncident(3, func(open *cachet.Incident) error {
+		if open == nil {
+			// Create the incident.
+		}
+		// ...
+	})
+*/
+package dedup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// digits matches runs of digits that are ignored by the default fingerprint.
+var digits = regexp.MustCompile(`[0-9]+`)
+
+// Fingerprint is the default fingerprint of incident names.
+// It ignores case, whitespace and numbers, so "Error rate 53%" and "error rate 61%" match.
+func Fingerprint(name string) string {
+	name = digits.ReplaceAllString(strings.ToLower(name), "#")
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// Options configures the deduplication.
+type Options struct {
+	// MatchName additionally requires the fingerprints of the names to match.
+	// Incidents without component are always matched by name.
+	MatchName bool
+	// Fingerprint overrides the default Fingerprint function.
+	Fingerprint func(name string) string
+}
+
+// Ensurer creates incidents unless there is an unresolved incident for the same component.
+// It is safe for concurrent use.
+type Ensurer struct {
+	client  *cachet.Client
+	options Options
+
+	mu    sync.Mutex
+	locks map[string]*lock
+}
+
+// lock is a mutex of a key along with the number of callers holding or waiting for it.
+type lock struct {
+	sync.Mutex
+	users int
+}
+
+// NewEnsurer returns a new Ensurer. o can be nil.
+func NewEnsurer(client *cachet.Client, o *Options) *Ensurer {
+	e := &Ensurer{client: client, locks: map[string]*lock{}}
+	if o != nil {
+		e.options = *o
+	}
+	if e.options.Fingerprint == nil {
+		e.options.Fingerprint = Fingerprint
+	}
+	return e
+}
+
+// EnsureIncident creates the incident i unless there is a matching unresolved incident.
+// Otherwise the message, status and component status of i are added as update to the
+// most recent matching incident, which is returned.
+// Without status, the matching incident keeps its status and new incidents are investigated.
+// created reports whether a new incident was created.
+func (e *Ensurer) EnsureIncident(i *cachet.Incident) (incident *cachet.Incident, created bool, err error) {
+	matchName := e.options.MatchName || i.ComponentID == 0
+	key := fmt.Sprintf("%d", i.ComponentID)
+	if matchName {
+		key += "/" + e.options.Fingerprint(i.Name)
+	}
+
+	unlock := e.lock(key)
+	defer unlock()
+
+	existing, err := e.find(i, matchName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if existing == nil {
+		if i.Status == 0 {
+			create := *i
+			create.Status = cachet.IncidentStatusInvestigating
+			i = &create
+		}
+		incident, _, err = e.client.Incidents.Create(i)
+		if err != nil {
+			return nil, false, err
+		}
+		return incident, true, nil
+	}
+
+	update := &cachet.IncidentUpdate{
+		Status:          i.Status,
+		Message:         i.Message,
+		ComponentStatus: i.ComponentStatus,
+	}
+	if update.Status == 0 {
+		update.Status = CurrentStatus(existing)
+	}
+	if update.ComponentStatus > 0 {
+		// Cachet only changes the component of an update with the component ID.
+		update.ComponentID = existing.ComponentID
+	}
+	if _, _, err := e.client.IncidentUpdates.Create(existing.ID, update); err != nil {
+		return nil, false, fmt.Errorf("Failed to update incident %d: %v", existing.ID, err)
+	}
+	return existing, false, nil
+}
+
+// WithOpenIncident calls fn with the most recent unresolved incident of the component or nil.
+// Calls for the same component are serialized with each other and with EnsureIncident without MatchName,
+// so fn can create an incident without creating duplicates.
+func (e *Ensurer) WithOpenIncident(componentID int, fn func(open *cachet.Incident) error) error {
+	unlock := e.lock(fmt.Sprintf("%d", componentID))
+	defer unlock()
+
+	open, err := e.find(&cachet.Incident{ComponentID: componentID}, false)
+	if err != nil {
+		return err
+	}
+	return fn(open)
+}
+
+// find returns the most recent unresolved incident matching i or nil.
+func (e *Ensurer) find(i *cachet.Incident, matchName bool) (*cachet.Incident, error) {
+	incidents, err := fetch.Incidents(e.client, &cachet.IncidentsQueryParams{ComponentID: i.ComponentID})
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := e.options.Fingerprint(i.Name)
+	var match *cachet.Incident
+	for k := range incidents {
+		candidate := &incidents[k]
+		if candidate.ComponentID != i.ComponentID || Resolved(candidate) {
+			continue
+		}
+		if matchName && e.options.Fingerprint(candidate.Name) != fingerprint {
+			continue
+		}
+		if match == nil || candidate.ID > match.ID {
+			match = candidate
+		}
+	}
+	return match, nil
+}
+
+// lock locks the key and returns the function to unlock it.
+// Locks are removed once no caller uses them anymore.
+func (e *Ensurer) lock(key string) func() {
+	e.mu.Lock()
+	l, ok := e.locks[key]
+	if !ok {
+		l = &lock{}
+		e.locks[key] = l
+	}
+	l.users++
+	e.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		e.mu.Lock()
+		l.users--
+		if l.users == 0 {
+			delete(e.locks, key)
+		}
+		e.mu.Unlock()
+	}
+}
+
+// Resolved reports whether the incident is fixed or a scheduled incident.
+func Resolved(i *cachet.Incident) bool {
+	status := CurrentStatus(i)
+	return i.IsResolved || status == cachet.IncidentStatusFixed || status == cachet.IncidentStatusScheduled
+}
+
+// CurrentStatus returns the status of the latest update or the status of the incident.
+func CurrentStatus(i *cachet.Incident) int {
+	if i.LatestStatus > 0 {
+		return i.LatestStatus
+	}
+	return i.Status
+}
@@ -0,0 +1,210 @@
+package dedup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func TestFingerprint(t *testing.T) {
+	if a, b := Fingerprint("Error rate 53%"), Fingerprint("  error   RATE 61% "); a != b {
+		t.Errorf("Fingerprint returned %q and %q, want equal fingerprints", a, b)
+	}
+	if a, b := Fingerprint("API down"), Fingerprint("API slow"); a == b {
+		t.Errorf("Fingerprint returned %q for different names", a)
+	}
+}
+
+func TestEnsurer_EnsureIncident_Update(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Request method = %v, want GET", r.Method)
+		}
+		if got := r.URL.Query().Get("component_id"); got != "3" {
+			t.Errorf("Incidents are filtered by component %q, want 3", got)
+		}
+		fmt.Fprint(w, `{"data":[
+			{"id":1,"name":"API down","component_id":3,"status":4},
+			{"id":2,"name":"API down","component_id":3,"status":1,"latest_status":2},
+			{"id":4,"name":"API slow","component_id":3,"status":1}
+		]}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents/2/updates", func(w http.ResponseWriter, r *http.Request) {
+		var got cachet.IncidentUpdate
+		json.NewDecoder(r.Body).Decode(&got)
+		expected := cachet.IncidentUpdate{Status: cachet.IncidentStatusIdentified, Message: "Still failing", ComponentID: 3, ComponentStatus: cachet.ComponentStatusMajorOutage}
+		if got != expected {
+			t.Errorf("Request body = %+v", got)
+		}
+		fmt.Fprint(w, `{"data":{"id":5,"incident_id":2}}`)
+	})
+
+	e := NewEnsurer(testClient, &Options{MatchName: true})
+	i, created, err := e.EnsureIncident(&cachet.Incident{Name: "API DOWN", Message: "Still failing", ComponentID: 3, ComponentStatus: cachet.ComponentStatusMajorOutage})
+	if err != nil {
+		t.Fatalf("EnsureIncident returned error: %v", err)
+	}
+	if created || i.ID != 2 {
+		t.Errorf("EnsureIncident returned incident %d, created %v, want 2 and false", i.ID, created)
+	}
+}
+
+func TestEnsurer_EnsureIncident_Create(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var posted cachet.Incident
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			json.NewDecoder(r.Body).Decode(&posted)
+			fmt.Fprint(w, `{"data":{"id":9,"name":"Mails delayed"}}`)
+			return
+		}
+		// An open incident of another component and one without component but another name.
+		fmt.Fprint(w, `{"data":[{"id":1,"name":"Mails delayed","component_id":3,"status":1},{"id":2,"name":"API down","status":1}]}`)
+	})
+
+	// New incidents without status are investigated.
+	e := NewEnsurer(testClient, nil)
+	i, created, err := e.EnsureIncident(&cachet.Incident{Name: "Mails delayed"})
+	if err != nil {
+		t.Fatalf("EnsureIncident returned error: %v", err)
+	}
+	if !created || i.ID != 9 {
+		t.Errorf("EnsureIncident returned incident %d, created %v, want 9 and true", i.ID, created)
+	}
+	if posted.Status != cachet.IncidentStatusInvestigating {
+		t.Errorf("EnsureIncident created status %d, want %d", posted.Status, cachet.IncidentStatusInvestigating)
+	}
+}
+
+func TestEnsurer_EnsureIncident_Concurrent(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var mu sync.Mutex
+	var incidents []cachet.Incident
+	creates, updates := 0, 0
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == "POST" {
+			creates++
+			i := cachet.Incident{ID: 10 + creates, Name: "API down", ComponentID: 3, Status: cachet.IncidentStatusInvestigating}
+			incidents = append(incidents, i)
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": i})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": incidents})
+	})
+	testMux.HandleFunc("/api/v1/incidents/11/updates", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		updates++
+		mu.Unlock()
+		fmt.Fprint(w, `{"data":{"id":1,"incident_id":11}}`)
+	})
+
+	e := NewEnsurer(testClient, nil)
+	var wg sync.WaitGroup
+	for k := 0; k < 3; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := e.EnsureIncident(&cachet.Incident{Name: "API down", Message: "Alert", ComponentID: 3, Status: cachet.IncidentStatusInvestigating}); err != nil {
+				t.Errorf("EnsureIncident returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if creates != 1 || updates != 2 {
+		t.Errorf("EnsureIncident created %d incidents and %d updates, want 1 and 2", creates, updates)
+	}
+	if len(e.locks) != 0 {
+		t.Errorf("Ensurer kept %d locks, want 0", len(e.locks))
+	}
+}
+
+func TestEnsurer_WithOpenIncident(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"id":1,"name":"API down","component_id":3,"status":1,"latest_status":4},
+			{"id":2,"name":"API slow","component_id":3,"status":2},
+			{"id":3,"name":"Maintenance","component_id":3,"status":0}
+		]}`)
+	})
+
+	e := NewEnsurer(testClient, nil)
+	var got *cachet.Incident
+	err := e.WithOpenIncident(3, func(open *cachet.Incident) error {
+		got = open
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithOpenIncident returned error: %v", err)
+	}
+	if got == nil || got.ID != 2 {
+		t.Errorf("WithOpenIncident passed %+v, want incident 2", got)
+	}
+
+	expected := fmt.Errorf("Failed")
+	if err := e.WithOpenIncident(3, func(*cachet.Incident) error { return expected }); err != expected {
+		t.Errorf("WithOpenIncident returned %v, want %v", err, expected)
+	}
+	if len(e.locks) != 0 {
+		t.Errorf("Ensurer kept %d locks, want 0", len(e.locks))
+	}
+}
+
+func TestResolved(t *testing.T) {
+	mockData := []struct {
+		incident cachet.Incident
+		expected bool
+	}{
+		{cachet.Incident{Status: cachet.IncidentStatusInvestigating}, false},
+		{cachet.Incident{Status: cachet.IncidentStatusWatching, LatestStatus: cachet.IncidentStatusFixed}, true},
+		{cachet.Incident{Status: cachet.IncidentStatusFixed, LatestStatus: cachet.IncidentStatusIdentified}, false},
+		{cachet.Incident{Status: cachet.IncidentStatusIdentified, IsResolved: true}, true},
+		{cachet.Incident{Status: cachet.IncidentStatusScheduled}, true},
+	}
+
+	for _, data := range mockData {
+		if got := Resolved(&data.incident); got != data.expected {
+			t.Errorf("Resolved(%+v) returned %v, want %v", data.incident, got, data.expected)
+		}
+	}
+}
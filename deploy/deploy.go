@@ -0,0 +1,380 @@
+/*
+Package deploy announces deployments on the status page.
+
+A Handler receives the deployment and deployment_status webhooks of GitHub
+(or any service sending the same events). A started deployment creates a schedule
+in progress for the affected components. A successful deployment completes the schedule,
+a failed deployment completes it as well and opens an incident.
+While the incident of a failed deployment is open, further failures (or redelivered events)
+are added to it as updates, see package dedup:
+
+	h, err := deploy.NewHandler(client, &deploy.Options{
+		Secret: os.Getenv("WEBHOOK_SECRET"),
+		Targets: []deploy.Target{
+			{Repository: "example/api", Environment: "production", Components: []int{3}},
+			{Repository: "example/website", Components: []int{4}},
+		},
+	})
+	http.Handle("/github", h)
+
+Payloads are verified with the HMAC-SHA256 signature of the X-Hub-Signature-256 header.
+Deployments of repositories and environments without target are ignored.
+*/
+package deploy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/dedup"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// DefaultDuration is the expected duration of deployments.
+const DefaultDuration = 15 * time.Minute
+
+// maxPayload is the maximum size of webhook payloads.
+const maxPayload = 5 << 20
+
+// Target maps the deployments of a repository to components.
+type Target struct {
+	// Repository is the full name of the repository, e.g. "example/api".
+	Repository string
+	// Environment limits the target to one environment. Empty matches all environments.
+	Environment string
+	Components  []int
+}
+
+// Options configures the handler.
+type Options struct {
+	// Secret is the secret of the webhook. It is required.
+	Secret  string
+	Targets []Target
+	// Duration is the expected duration of deployments. It sets the end of the schedules.
+	// It defaults to DefaultDuration.
+	Duration time.Duration
+	// FailureStatus is the component status set by incidents of failed deployments.
+	// 0 leaves the component untouched.
+	FailureStatus int
+	// Location is the time zone of the Cachet instance. It defaults to UTC.
+	Location *time.Location
+	// Ensurer finds and creates the incidents of components. Share one Ensurer between all
+	// integrations of an instance, so their changes to the same component are serialized.
+	// It defaults to an Ensurer of the handler only, which matches incidents by name.
+	Ensurer *dedup.Ensurer
+}
+
+// Event is the payload of deployment and deployment_status events.
+type Event struct {
+	Action           string            `json:"action"`
+	Deployment       Deployment        `json:"deployment"`
+	DeploymentStatus *DeploymentStatus `json:"deployment_status"`
+	Repository       struct {
+		FullName string `json:"full_name"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+}
+
+// Deployment is a deployment of an Event.
+type Deployment struct {
+	ID          int    `json:"id"`
+	SHA         string `json:"sha"`
+	Ref         string `json:"ref"`
+	Task        string `json:"task"`
+	Environment string `json:"environment"`
+	Description string `json:"description"`
+	Creator     struct {
+		Login string `json:"login"`
+	} `json:"creator"`
+}
+
+// DeploymentStatus is the status of a deployment_status Event.
+type DeploymentStatus struct {
+	State       string `json:"state"`
+	Description string `json:"description"`
+	TargetURL   string `json:"target_url"`
+	LogURL      string `json:"log_url"`
+}
+
+// Handler is an http.Handler receiving deployment webhooks.
+type Handler struct {
+	client  *cachet.Client
+	options Options
+	ensurer *dedup.Ensurer
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+
+	mu sync.Mutex
+	// schedules maps deployment IDs to the IDs of their schedules.
+	schedules map[int]int
+}
+
+// NewHandler returns a new handler.
+func NewHandler(client *cachet.Client, o *Options) (*Handler, error) {
+	if o == nil || len(o.Secret) == 0 {
+		return nil, fmt.Errorf("The deployment handler needs the secret of the webhook")
+	}
+
+	h := &Handler{
+		client:    client,
+		options:   *o,
+		ensurer:   o.Ensurer,
+		now:       time.Now,
+		schedules: map[int]int{},
+	}
+	if h.ensurer == nil {
+		h.ensurer = dedup.NewEnsurer(client, &dedup.Options{MatchName: true})
+	}
+	if h.options.Duration == 0 {
+		h.options.Duration = DefaultDuration
+	}
+	if h.options.Location == nil {
+		h.options.Location = time.UTC
+	}
+	return h, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxPayload))
+	if err != nil {
+		http.Error(w, "Failed to read the payload", http.StatusBadRequest)
+		return
+	}
+	if !h.verify(body, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	name := r.Header.Get("X-GitHub-Event")
+	if name != "deployment" && name != "deployment_status" {
+		// Other events like ping are acknowledged, but ignored.
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	e := &Event{}
+	if err := json.Unmarshal(body, e); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid webhook payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if name == "deployment_status" && e.DeploymentStatus == nil {
+		http.Error(w, "Deployment status event without deployment status", http.StatusBadRequest)
+		return
+	}
+	if name == "deployment" {
+		e.DeploymentStatus = nil
+	}
+
+	if err := h.Handle(e); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// verify checks the HMAC-SHA256 signature of the payload.
+func (h *Handler) verify(body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.options.Secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// Handle applies a deployment event. Events without DeploymentStatus are deployment events.
+func (h *Handler) Handle(e *Event) error {
+	components, ok := h.target(e)
+	if !ok {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if e.DeploymentStatus == nil {
+		return h.start(e, components)
+	}
+
+	switch e.DeploymentStatus.State {
+	case "queued", "pending", "in_progress":
+		return h.start(e, components)
+	case "success":
+		_, err := h.complete(e)
+		return err
+	case "failure", "error":
+		return h.fail(e, components)
+	}
+	return nil
+}
+
+// target returns the components of the first target of the deployment.
+func (h *Handler) target(e *Event) ([]int, bool) {
+	for _, t := range h.options.Targets {
+		if t.Repository != e.Repository.FullName {
+			continue
+		}
+		if len(t.Environment) > 0 && t.Environment != e.Deployment.Environment {
+			continue
+		}
+		return t.Components, true
+	}
+	return nil, false
+}
+
+// start creates the schedule of the deployment unless it exists already.
+func (h *Handler) start(e *Event, components []int) error {
+	id, err := h.schedule(e)
+	if err != nil || id > 0 {
+		return err
+	}
+
+	now := h.now()
+	s := &cachet.Schedule{
+		Name:        scheduleName(e),
+		Message:     message(e),
+		Status:      cachet.ScheduleInProgress,
+		ScheduledAt: now.In(h.options.Location).Format(api.TimestampLayout),
+		CompletedAt: now.Add(h.options.Duration).In(h.options.Location).Format(api.TimestampLayout),
+	}
+	for _, id := range components {
+		s.Components = append(s.Components, cachet.Component{ID: id})
+	}
+
+	created, _, err := h.client.Schedules.Create(s)
+	if err != nil {
+		return fmt.Errorf("Failed to create the schedule of deployment %d: %v", e.Deployment.ID, err)
+	}
+	if created == nil {
+		return fmt.Errorf("Empty response from the Cachet API")
+	}
+	h.schedules[e.Deployment.ID] = created.ID
+	return nil
+}
+
+// complete marks the schedule of the deployment as complete and returns whether there was one.
+func (h *Handler) complete(e *Event) (bool, error) {
+	id, err := h.schedule(e)
+	if err != nil || id == 0 {
+		return false, err
+	}
+
+	s := &cachet.Schedule{
+		Status:      cachet.ScheduleComplete,
+		CompletedAt: h.now().In(h.options.Location).Format(api.TimestampLayout),
+	}
+	if _, _, err := h.client.Schedules.Update(id, s); err != nil {
+		return false, fmt.Errorf("Failed to complete schedule %d: %v", id, err)
+	}
+	delete(h.schedules, e.Deployment.ID)
+	return true, nil
+}
+
+// fail completes the schedule of the deployment and opens an incident unless one is open already.
+// The incident affects the first component of the target.
+func (h *Handler) fail(e *Event, components []int) error {
+	if _, err := h.complete(e); err != nil {
+		return err
+	}
+
+	i := &cachet.Incident{
+		Name:    fmt.Sprintf("Deployment of %s failed", e.Repository.FullName),
+		Message: message(e),
+		Status:  cachet.IncidentStatusInvestigating,
+		Visible: cachet.IncidentVisibilityPublic,
+	}
+	if len(components) > 0 {
+		i.ComponentID = components[0]
+		i.ComponentStatus = h.options.FailureStatus
+	}
+	if _, _, err := h.ensurer.EnsureIncident(i); err != nil {
+		return fmt.Errorf("Failed to open the incident of deployment %d: %v", e.Deployment.ID, err)
+	}
+
+	if i.ComponentID > 0 && i.ComponentStatus > 0 {
+		if _, _, err := h.client.Components.Update(i.ComponentID, &cachet.Component{Status: i.ComponentStatus}); err != nil {
+			return fmt.Errorf("Failed to update status of component %d: %v", i.ComponentID, err)
+		}
+	}
+	return nil
+}
+
+// schedule returns the ID of the open schedule of the deployment or 0.
+// Schedules unknown to the handler (e.g. after a restart) are looked up by name.
+func (h *Handler) schedule(e *Event) (int, error) {
+	if id, ok := h.schedules[e.Deployment.ID]; ok {
+		return id, nil
+	}
+
+	name := scheduleName(e)
+	schedules, err := fetch.Schedules(h.client, &cachet.SchedulesQueryParams{Name: name})
+	if err != nil {
+		return 0, err
+	}
+	for _, s := range schedules {
+		if s.Name == name && s.Status != cachet.ScheduleComplete {
+			h.schedules[e.Deployment.ID] = s.ID
+			return s.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// scheduleName returns the name of the schedule of a deployment. It identifies the deployment.
+func scheduleName(e *Event) string {
+	name := fmt.Sprintf("Deployment #%d of %s", e.Deployment.ID, e.Repository.FullName)
+	if len(e.Deployment.Environment) > 0 {
+		name += " to " + e.Deployment.Environment
+	}
+	return name
+}
+
+// message describes the deployment and its status.
+func message(e *Event) string {
+	lines := []string{}
+	if len(e.Deployment.Description) > 0 {
+		lines = append(lines, e.Deployment.Description)
+	}
+	if len(e.Deployment.Ref) > 0 {
+		ref := e.Deployment.Ref
+		if len(e.Deployment.SHA) >= 7 && e.Deployment.SHA != ref {
+			ref += " (" + e.Deployment.SHA[:7] + ")"
+		}
+		lines = append(lines, "Deploying "+ref+".")
+	}
+	if s := e.DeploymentStatus; s != nil {
+		if len(s.Description) > 0 {
+			lines = append(lines, s.Description)
+		}
+		if len(s.TargetURL) > 0 {
+			lines = append(lines, s.TargetURL)
+		}
+	}
+	if len(lines) == 0 {
+		return scheduleName(e)
+	}
+	return strings.Join(lines, "\n\n")
+}
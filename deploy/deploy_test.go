@@ -0,0 +1,201 @@
+package deploy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/dedup"
+	"github.com/andygrunwald/cachet/internal/cachettest"
+)
+
+const testSecret = "s3cret"
+
+// newTestServer returns an instance with the component 3 and without schedules.
+func newTestServer() *cachettest.Server {
+	s := cachettest.NewServer()
+	s.Add("components", cachet.Component{ID: 3, Name: "API"})
+	return s
+}
+
+func newTestHandler(t *testing.T, client *cachet.Client, o *Options) *Handler {
+	o.Secret = testSecret
+	o.Targets = []Target{{Repository: "example/api", Environment: "production", Components: []int{3}}}
+	h, err := NewHandler(client, o)
+	if err != nil {
+		t.Fatalf("NewHandler returned error: %v", err)
+	}
+	h.now = func() time.Time { return time.Date(2017, 10, 10, 10, 0, 0, 0, time.UTC) }
+	return h
+}
+
+// send posts a signed event to h and returns the status code.
+func send(h http.Handler, event, body string) int {
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(body))
+
+	r := httptest.NewRequest("POST", "/github", strings.NewReader(body))
+	r.Header.Set("X-GitHub-Event", event)
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func event(environment, state string) string {
+	status := ""
+	if len(state) > 0 {
+		status = fmt.Sprintf(`,"deployment_status":{"state":%q,"target_url":"https://ci.example.com/1"}`, state)
+	}
+	return fmt.Sprintf(`{"action":"created","deployment":{"id":42,"ref":"v1.2.0","sha":"0123456789abcdef","environment":%q,"description":"Release 1.2"}%s,
+		"repository":{"full_name":"example/api"}}`, environment, status)
+}
+
+func TestHandler_Success(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	h := newTestHandler(t, s.Client, &Options{})
+
+	if code := send(h, "deployment", event("production", "")); code != http.StatusOK {
+		t.Fatalf("Handler returned %d, want %d", code, http.StatusOK)
+	}
+	if code := send(h, "deployment_status", event("production", "in_progress")); code != http.StatusOK {
+		t.Fatalf("Handler returned %d, want %d", code, http.StatusOK)
+	}
+	if code := send(h, "deployment_status", event("production", "success")); code != http.StatusOK {
+		t.Fatalf("Handler returned %d, want %d", code, http.StatusOK)
+	}
+
+	expected := []cachettest.Write{
+		{Method: "POST", Path: "/api/v1/schedules", Body: map[string]interface{}{
+			"name": "Deployment #42 of example/api to production", "message": "Release 1.2\n\nDeploying v1.2.0 (0123456).", "status": float64(1),
+			"scheduled_at": "2017-10-10 10:00:00", "completed_at": "2017-10-10 10:15:00",
+			"components": []interface{}{map[string]interface{}{"id": float64(3)}},
+		}},
+		{Method: "PUT", Path: "/api/v1/schedules/1", Body: map[string]interface{}{"status": float64(2), "completed_at": "2017-10-10 10:00:00"}},
+	}
+	if got := s.Writes(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Handler sent\n%v\nwant\n%v", got, expected)
+	}
+}
+
+func TestHandler_Failure(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	h := newTestHandler(t, s.Client, &Options{FailureStatus: cachet.ComponentStatusPartialOutage})
+
+	send(h, "deployment", event("production", ""))
+	s.Writes()
+	if code := send(h, "deployment_status", event("production", "failure")); code != http.StatusOK {
+		t.Fatalf("Handler returned %d, want %d", code, http.StatusOK)
+	}
+
+	expected := []cachettest.Write{
+		{Method: "PUT", Path: "/api/v1/schedules/1", Body: map[string]interface{}{"status": float64(2), "completed_at": "2017-10-10 10:00:00"}},
+		{Method: "POST", Path: "/api/v1/incidents", Body: map[string]interface{}{
+			"name": "Deployment of example/api failed", "message": "Release 1.2\n\nDeploying v1.2.0 (0123456).\n\nhttps://ci.example.com/1",
+			"status": float64(1), "visible": float64(1), "component_id": float64(3), "component_status": float64(3),
+		}},
+		{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(3)}},
+	}
+	if got := s.Writes(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Handler sent\n%v\nwant\n%v", got, expected)
+	}
+}
+
+func TestHandler_Failure_Redelivered(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	h := newTestHandler(t, s.Client, &Options{FailureStatus: cachet.ComponentStatusPartialOutage})
+
+	send(h, "deployment", event("production", ""))
+	send(h, "deployment_status", event("production", "failure"))
+	s.Writes()
+	if code := send(h, "deployment_status", event("production", "failure")); code != http.StatusOK {
+		t.Fatalf("Handler returned %d, want %d", code, http.StatusOK)
+	}
+
+	expected := []cachettest.Write{
+		{Method: "POST", Path: "/api/v1/incidents/1/updates", Body: map[string]interface{}{
+			"message": "Release 1.2\n\nDeploying v1.2.0 (0123456).\n\nhttps://ci.example.com/1", "status": float64(1),
+			"component_id": float64(3), "component_status": float64(3),
+		}},
+		{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(3)}},
+	}
+	if got := s.Writes(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Handler sent for the redelivered event\n%v\nwant\n%v", got, expected)
+	}
+}
+
+func TestHandler_Failure_SharedEnsurer(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	s.Add("incidents", cachet.Incident{ID: 5, Name: "High error rate", ComponentID: 3, Status: cachet.IncidentStatusIdentified})
+	// The shared Ensurer does not match by name, so the failure is added to the open incident of the component.
+	h := newTestHandler(t, s.Client, &Options{Ensurer: dedup.NewEnsurer(s.Client, nil)})
+
+	send(h, "deployment", event("production", ""))
+	s.Writes()
+	if code := send(h, "deployment_status", event("production", "failure")); code != http.StatusOK {
+		t.Fatalf("Handler returned %d, want %d", code, http.StatusOK)
+	}
+
+	got := s.Writes()
+	if len(got) != 2 || got[1].Method != "POST" || got[1].Path != "/api/v1/incidents/5/updates" {
+		t.Errorf("Handler sent %v, want an update of incident 5", got)
+	}
+}
+
+func TestHandler_Ignored(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	h := newTestHandler(t, s.Client, &Options{})
+
+	for _, data := range []struct{ event, body string }{
+		{"ping", `{"zen":"Keep it simple."}`},
+		{"deployment", event("staging", "")},
+		{"deployment_status", event("production", "inactive")},
+	} {
+		if code := send(h, data.event, data.body); code != http.StatusOK {
+			t.Errorf("Handler returned %d for %s, want %d", code, data.event, http.StatusOK)
+		}
+	}
+	if got := s.Writes(); len(got) > 0 {
+		t.Errorf("Handler sent %v for ignored events", got)
+	}
+}
+
+func TestHandler_InvalidRequests(t *testing.T) {
+	h := newTestHandler(t, nil, &Options{})
+
+	r := httptest.NewRequest("POST", "/github", strings.NewReader(event("production", "")))
+	r.Header.Set("X-GitHub-Event", "deployment")
+	r.Header.Set("X-Hub-Signature-256", "sha256=00")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Handler returned %d for an invalid signature, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	if code := send(h, "deployment_status", event("production", "")); code != http.StatusBadRequest {
+		t.Errorf("Handler returned %d for a status event without status, want %d", code, http.StatusBadRequest)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/github", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Handler returned %d for GET, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	if _, err := NewHandler(nil, &Options{}); err == nil {
+		t.Error("NewHandler returned no error without secret")
+	}
+}
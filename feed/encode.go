@@ -0,0 +1,163 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+// Formats of a feed.
+const (
+	Atom = "atom"
+	RSS  = "rss"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title    string        `xml:"title"`
+	ID       string        `xml:"id"`
+	Link     atomLink      `xml:"link"`
+	Updated  string        `xml:"updated"`
+	Category *atomCategory `xml:"category,omitempty"`
+	Content  atomContent   `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Category    string  `xml:"category,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// Write writes the feed in the given format to w.
+func (f *Feed) Write(w io.Writer, format string) error {
+	if strings.EqualFold(format, RSS) {
+		return f.WriteRSS(w)
+	}
+	return f.WriteAtom(w)
+}
+
+// WriteAtom writes the feed as Atom document to w.
+func (f *Feed) WriteAtom(w io.Writer) error {
+	a := &atomFeed{
+		Title:   f.Title,
+		ID:      f.Link,
+		Link:    atomLink{Href: f.Link},
+		Updated: atomTime(f.Updated),
+		Author:  atomAuthor{Name: f.Title},
+	}
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			Title:   e.Title,
+			ID:      e.ID,
+			Link:    atomLink{Href: e.Link, Rel: "alternate"},
+			Updated: atomTime(e.Updated),
+			Content: atomContent{Type: "text", Body: e.Content},
+		}
+		if len(e.Component) > 0 {
+			entry.Category = &atomCategory{Term: e.Component}
+		}
+		a.Entries = append(a.Entries, entry)
+	}
+	return writeXML(w, a)
+}
+
+// WriteRSS writes the feed as RSS 2.0 document to w.
+func (f *Feed) WriteRSS(w io.Writer) error {
+	r := &rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Description: f.Description,
+		},
+	}
+	if !f.Updated.IsZero() {
+		r.Channel.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+	}
+	for _, e := range f.Entries {
+		item := rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Content,
+			GUID:        rssGUID{Value: e.ID},
+			Category:    e.Component,
+		}
+		if !e.Updated.IsZero() {
+			item.PubDate = e.Updated.Format(time.RFC1123Z)
+		}
+		r.Channel.Items = append(r.Channel.Items, item)
+	}
+	return writeXML(w, r)
+}
+
+// atomTime formats t as required by Atom.
+// Atom requires a timestamp, so a zero time is formatted as the unix epoch.
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(0, 0)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,85 @@
+package feed
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFeed = &Feed{
+	Title:       "Example status",
+	Link:        "https://status.example.com/",
+	Description: "Status updates",
+	Updated:     time.Date(2017, 10, 10, 11, 0, 0, 0, time.UTC),
+	Entries: []Entry{{
+		ID:        "https://status.example.com/#incident-1",
+		Title:     "Outage: Fixed",
+		Link:      "https://status.example.com/incidents/1",
+		Content:   "Fixed <finally>",
+		Updated:   time.Date(2017, 10, 10, 11, 0, 0, 0, time.UTC),
+		Component: "API",
+	}},
+}
+
+func TestFeed_WriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testFeed.WriteAtom(&buf); err != nil {
+		t.Fatalf("WriteAtom returned error: %v", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example status</title>
+  <id>https://status.example.com/</id>
+  <link href="https://status.example.com/"></link>
+  <updated>2017-10-10T11:00:00Z</updated>
+  <author>
+    <name>Example status</name>
+  </author>
+  <entry>
+    <title>Outage: Fixed</title>
+    <id>https://status.example.com/#incident-1</id>
+    <link href="https://status.example.com/incidents/1" rel="alternate"></link>
+    <updated>2017-10-10T11:00:00Z</updated>
+    <category term="API"></category>
+    <content type="text">Fixed &lt;finally&gt;</content>
+  </entry>
+</feed>
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("WriteAtom returned\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestFeed_WriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testFeed.Write(&buf, RSS); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Example status</title>
+    <link>https://status.example.com/</link>
+    <description>Status updates</description>
+    <lastBuildDate>Tue, 10 Oct 2017 11:00:00 +0000</lastBuildDate>
+    <item>
+      <title>Outage: Fixed</title>
+      <link>https://status.example.com/incidents/1</link>
+      <description>Fixed &lt;finally&gt;</description>
+      <guid isPermaLink="false">https://status.example.com/#incident-1</guid>
+      <pubDate>Tue, 10 Oct 2017 11:00:00 +0000</pubDate>
+      <category>API</category>
+    </item>
+  </channel>
+</rss>
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("WriteRSS returned\n%s\nwant\n%s", got, expected)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") {
+		t.Error("WriteRSS did not write the XML header")
+	}
+}
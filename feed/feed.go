@@ -0,0 +1,178 @@
+/*
+Package feed builds Atom and RSS 2.0 feeds of incidents and schedules.
+
+Every public incident and every incident update becomes an entry, optionally followed by schedules:
+
+	f, err := feed.Build(client, &feed.Options{
+		Title: "Example status",
+		Link:  "https://status.example.com/",
+	})
+	err = f.WriteAtom(os.Stdout)
+
+A Handler serves a feed over HTTP and caches it:
+
+	http.Handle("/feed.atom", feed.NewHandler(client, feed.Atom, options, 5*time.Minute))
+	http.Handle("/feed.rss", feed.NewHandler(client, feed.RSS, options, 5*time.Minute))
+*/
+package feed
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// DefaultLimit is the number of incidents included by default.
+const DefaultLimit = 20
+
+// Options configures a feed.
+type Options struct {
+	// Title of the feed. It defaults to "Status".
+	Title string
+	// Link is the URL of the status page. It is required.
+	Link string
+	// Description of the feed. It defaults to "Status updates".
+	Description string
+
+	// Limit is the number of the most recent incidents included. It defaults to DefaultLimit.
+	Limit int
+	// Schedules includes the most recent schedules.
+	Schedules bool
+	// Location is the time zone of the Cachet instance. It defaults to UTC.
+	Location *time.Location
+}
+
+// Feed is a feed independent of its format.
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Updated     time.Time
+	Entries     []Entry
+}
+
+// Entry is a single entry of a feed.
+type Entry struct {
+	ID      string
+	Title   string
+	Link    string
+	Content string
+	Updated time.Time
+	// Status is the human status of the incident or schedule.
+	Status string
+	// Component is the name of the affected component.
+	Component string
+}
+
+// Build fetches the most recent incidents with their updates and builds a feed.
+// Entries are sorted by time, the most recent first.
+func Build(client *cachet.Client, o *Options) (*Feed, error) {
+	if o == nil || len(o.Link) == 0 {
+		return nil, fmt.Errorf("A feed needs the link of the status page")
+	}
+	limit := o.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	loc := o.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	f := &Feed{Title: o.Title, Link: o.Link, Description: o.Description}
+	if len(f.Title) == 0 {
+		f.Title = "Status"
+	}
+	if len(f.Description) == 0 {
+		f.Description = "Status updates"
+	}
+
+	components, err := fetch.Components(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := map[int]string{}
+	for _, c := range components {
+		names[c.ID] = c.Name
+	}
+
+	// The feed is public, incidents only visible to logged in users are left out.
+	recent := cachet.QueryOptions{PerPage: limit, SortField: "id", OrderType: "desc"}
+	incidents, _, err := client.Incidents.GetAll(&cachet.IncidentsQueryParams{Visible: cachet.IncidentVisibilityPublic, QueryOptions: recent})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range incidents.Incidents {
+		if i.Visible != cachet.IncidentVisibilityPublic {
+			continue
+		}
+		link := i.Permalink
+		if len(link) == 0 {
+			link = fmt.Sprintf("%s#incident-%d", o.Link, i.ID)
+		}
+		f.Entries = append(f.Entries, Entry{
+			ID:        fmt.Sprintf("%s#incident-%d", o.Link, i.ID),
+			Title:     fmt.Sprintf("%s: %s", i.Name, i.HumanStatus),
+			Link:      link,
+			Content:   i.Message,
+			Updated:   api.ParseTime(loc, i.OccurredAt, i.CreatedAt),
+			Status:    i.HumanStatus,
+			Component: names[i.ComponentID],
+		})
+
+		updates, err := fetch.IncidentUpdates(client, i.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range updates {
+			entry := Entry{
+				ID:        fmt.Sprintf("%s#incident-%d-update-%d", o.Link, i.ID, u.ID),
+				Title:     fmt.Sprintf("%s: %s", i.Name, u.HumanStatus),
+				Link:      u.Permalink,
+				Content:   u.Message,
+				Updated:   api.ParseTime(loc, u.CreatedAt),
+				Status:    u.HumanStatus,
+				Component: names[i.ComponentID],
+			}
+			if len(entry.Link) == 0 {
+				entry.Link = link
+			}
+			f.Entries = append(f.Entries, entry)
+		}
+	}
+
+	if o.Schedules {
+		schedules, _, err := client.Schedules.GetAll(&cachet.SchedulesQueryParams{QueryOptions: recent})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range schedules.Schedules {
+			entry := Entry{
+				ID:      fmt.Sprintf("%s#schedule-%d", o.Link, s.ID),
+				Title:   fmt.Sprintf("Maintenance %s: %s", s.Name, s.HumanStatus),
+				Link:    o.Link,
+				Content: s.Message,
+				Updated: api.ParseTime(loc, s.UpdatedAt, s.CreatedAt, s.ScheduledAt),
+				Status:  s.HumanStatus,
+			}
+			for _, c := range s.Components {
+				if len(entry.Component) > 0 {
+					entry.Component += ", "
+				}
+				entry.Component += names[c.ID]
+			}
+			f.Entries = append(f.Entries, entry)
+		}
+	}
+
+	sort.SliceStable(f.Entries, func(i, j int) bool { return f.Entries[i].Updated.After(f.Entries[j].Updated) })
+	if len(f.Entries) > 0 {
+		f.Updated = f.Entries[0].Updated
+	}
+	return f, nil
+}
@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+// handleInstance serves one public and one hidden incident, two pages of updates and one schedule.
+// It returns the number of times the components were requested.
+func handleInstance(t *testing.T) *int {
+	requests := 0
+	testMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"data":[{"id":2,"name":"API"}]}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Query().Get("order"), "desc"; got != want {
+			t.Errorf("Incidents are ordered %q, want %q", got, want)
+		}
+		if got, want := r.URL.Query().Get("visible"), "1"; got != want {
+			t.Errorf("Incidents are filtered by visible %q, want %q", got, want)
+		}
+		// Incident 5 is only visible to logged in users and must not end up in the feed.
+		fmt.Fprint(w, `{"data":[{"id":5,"name":"Internal","visible":0,"occurred_at":"2017-10-10 12:00:00"},
+			{"id":1,"name":"Outage","message":"Looking into it","human_status":"Investigating","component_id":2,"visible":1,
+			"occurred_at":"2017-10-10 10:00:00","permalink":"https://status.example.com/incidents/1"}]}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents/1/updates", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":1,"total_pages":2}},
+				"data":[{"id":3,"incident_id":1,"message":"Fixed","human_status":"Fixed","created_at":"2017-10-10 11:00:00"}]}`)
+		case "2":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":2,"total_pages":2}},
+				"data":[{"id":2,"incident_id":1,"message":"Found it","human_status":"Identified","created_at":"2017-10-10 10:30:00"}]}`)
+		}
+	})
+	testMux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":4,"name":"Upgrade","message":"Upgrading","human_status":"Upcoming","created_at":"2017-10-09 10:00:00","components":[{"id":2}]}]}`)
+	})
+	return &requests
+}
+
+func TestBuild(t *testing.T) {
+	setup()
+	defer teardown()
+	handleInstance(t)
+
+	got, err := Build(testClient, &Options{Link: "https://status.example.com/", Schedules: true})
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+
+	at := func(day, hour int) time.Time { return time.Date(2017, 10, day, hour, 0, 0, 0, time.UTC) }
+	expected := &Feed{
+		Title:       "Status",
+		Link:        "https://status.example.com/",
+		Description: "Status updates",
+		Updated:     at(10, 11),
+		Entries: []Entry{
+			{
+				ID:        "https://status.example.com/#incident-1-update-3",
+				Title:     "Outage: Fixed",
+				Link:      "https://status.example.com/incidents/1",
+				Content:   "Fixed",
+				Updated:   at(10, 11),
+				Status:    "Fixed",
+				Component: "API",
+			},
+			{
+				ID:        "https://status.example.com/#incident-1-update-2",
+				Title:     "Outage: Identified",
+				Link:      "https://status.example.com/incidents/1",
+				Content:   "Found it",
+				Updated:   time.Date(2017, 10, 10, 10, 30, 0, 0, time.UTC),
+				Status:    "Identified",
+				Component: "API",
+			},
+			{
+				ID:        "https://status.example.com/#incident-1",
+				Title:     "Outage: Investigating",
+				Link:      "https://status.example.com/incidents/1",
+				Content:   "Looking into it",
+				Updated:   at(10, 10),
+				Status:    "Investigating",
+				Component: "API",
+			},
+			{
+				ID:        "https://status.example.com/#schedule-4",
+				Title:     "Maintenance Upgrade: Upcoming",
+				Link:      "https://status.example.com/",
+				Content:   "Upgrading",
+				Updated:   at(9, 10),
+				Status:    "Upcoming",
+				Component: "API",
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Build returned %+v, want %+v", got, expected)
+	}
+}
+
+func TestBuild_NoLink(t *testing.T) {
+	if _, err := Build(nil, &Options{}); err == nil {
+		t.Error("Build returned no error without a link. Expected one.")
+	}
+}
@@ -0,0 +1,104 @@
+package feed
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// contentTypes maps the formats to their content type.
+var contentTypes = map[string]string{
+	Atom: "application/atom+xml; charset=utf-8",
+	RSS:  "application/rss+xml; charset=utf-8",
+}
+
+// Handler serves a feed and caches it.
+// If building the feed fails, the last feed is served until it can be rebuilt.
+type Handler struct {
+	client  *cachet.Client
+	format  string
+	options Options
+	ttl     time.Duration
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+
+	mu      sync.Mutex
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+// NewHandler returns a handler that serves the feed in the given format (Atom or RSS).
+// The feed is rebuilt at most once per ttl.
+func NewHandler(client *cachet.Client, format string, o *Options, ttl time.Duration) *Handler {
+	if _, ok := contentTypes[format]; !ok {
+		format = Atom
+	}
+	h := &Handler{client: client, format: format, ttl: ttl, now: time.Now}
+	if o != nil {
+		h.options = *o
+	}
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, etag, err := h.feed()
+	if err != nil {
+		http.Error(w, "Failed to build the feed", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypes[h.format])
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.ttl.Seconds())))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if r.Method == "HEAD" {
+		return
+	}
+	w.Write(body)
+}
+
+// feed returns the cached feed and rebuilds it if it expired.
+func (h *Handler) feed() ([]byte, string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	if h.body != nil && now.Before(h.expires) {
+		return h.body, h.etag, nil
+	}
+
+	var buf bytes.Buffer
+	f, err := Build(h.client, &h.options)
+	if err == nil {
+		err = f.Write(&buf, h.format)
+	}
+	if err != nil {
+		if h.body != nil {
+			// Retry after ttl to not hammer an instance that is down.
+			h.expires = now.Add(h.ttl)
+			return h.body, h.etag, nil
+		}
+		return nil, "", err
+	}
+
+	h.body = buf.Bytes()
+	h.etag = fmt.Sprintf(`"%x"`, sha1.Sum(h.body))
+	h.expires = now.Add(h.ttl)
+	return h.body, h.etag, nil
+}
@@ -0,0 +1,66 @@
+package feed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	setup()
+	defer teardown()
+	requests := handleInstance(t)
+
+	now := time.Date(2017, 10, 10, 12, 0, 0, 0, time.UTC)
+	h := NewHandler(testClient, RSS, &Options{Link: "https://status.example.com/"}, time.Minute)
+	h.now = func() time.Time { return now }
+
+	serve := func(etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/feed.rss", nil)
+		if len(etag) > 0 {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<rss") {
+		t.Fatalf("Handler returned %d %q, want an RSS feed", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != contentTypes[RSS] {
+		t.Errorf("Handler returned content type %q, want %q", got, contentTypes[RSS])
+	}
+
+	if w := serve(w.Header().Get("ETag")); w.Code != http.StatusNotModified {
+		t.Errorf("Handler returned %d for a matching ETag, want %d", w.Code, http.StatusNotModified)
+	}
+	if *requests != 1 {
+		t.Errorf("Handler built the feed %d times within the ttl, want once", *requests)
+	}
+
+	now = now.Add(2 * time.Minute)
+	serve("")
+	if *requests != 2 {
+		t.Errorf("Handler built the feed %d times after the ttl, want twice", *requests)
+	}
+}
+
+func TestHandler_Error(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	})
+
+	h := NewHandler(testClient, Atom, &Options{Link: "https://status.example.com/"}, time.Minute)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/feed.atom", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Handler returned %d, want %d", w.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,217 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// defaultColumns are the columns shown for known entities if no columns are selected.
+var defaultColumns = map[reflect.Type][]string{
+	reflect.TypeOf(cachet.Component{}):      {"id", "name", "status_name", "group_id", "enabled"},
+	reflect.TypeOf(cachet.ComponentGroup{}): {"id", "name", "order", "collapsed", "lowest_human_status"},
+	reflect.TypeOf(cachet.Incident{}):       {"id", "name", "human_status", "component_id", "occurred_at"},
+	reflect.TypeOf(cachet.IncidentUpdate{}): {"id", "incident_id", "human_status", "message", "created_at"},
+	reflect.TypeOf(cachet.Metric{}):         {"id", "name", "suffix", "default_value", "default_view_name"},
+	reflect.TypeOf(cachet.Point{}):          {"id", "metric_id", "value", "created_at"},
+	reflect.TypeOf(cachet.Schedule{}):       {"id", "name", "human_status", "scheduled_at", "completed_at"},
+	reflect.TypeOf(cachet.Subscriber{}):     {"id", "email", "verified_at", "created_at"},
+}
+
+// entities is the normalized form of a value that will be rendered.
+type entities struct {
+	// items contains the dereferenced entities.
+	items []reflect.Value
+	// typ is the dereferenced type of a single entity.
+	typ reflect.Type
+	// list is true if the value was a list (and should be rendered as one).
+	list bool
+}
+
+// column is a field of an entity that can be rendered in a table.
+type column struct {
+	// name is the JSON name of the field.
+	name string
+	// index is the index of the field for reflect.Value.FieldByIndex.
+	// A nil index renders the entity itself.
+	index []int
+}
+
+// newEntities extracts the entities out of v.
+// List responses are unwrapped by their "data" field.
+func newEntities(v interface{}) *entities {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return &entities{}
+	}
+
+	if rv.Kind() == reflect.Struct {
+		if data := dataField(rv); data.IsValid() {
+			rv = data
+		}
+	}
+
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return &entities{items: []reflect.Value{rv}, typ: rv.Type()}
+	}
+
+	e := &entities{list: true, typ: rv.Type().Elem()}
+	for e.typ.Kind() == reflect.Ptr {
+		e.typ = e.typ.Elem()
+	}
+	for i := 0; i < rv.Len(); i++ {
+		item := indirect(rv.Index(i))
+		if item.IsValid() {
+			e.items = append(e.items, item)
+		}
+	}
+	return e
+}
+
+// columns returns the columns with the given names.
+// If names is empty, the default columns of the entity are returned.
+func (e *entities) columns(names []string) ([]column, error) {
+	if e.typ == nil || e.typ.Kind() != reflect.Struct {
+		return []column{{name: "value"}}, nil
+	}
+
+	available := structColumns(e.typ)
+	if len(names) == 0 {
+		names = defaultColumns[e.typ]
+	}
+	if len(names) == 0 {
+		return available, nil
+	}
+
+	columns := make([]column, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, c := range available {
+			if strings.EqualFold(c.name, strings.TrimSpace(name)) {
+				columns = append(columns, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			l := make([]string, 0, len(available))
+			for _, c := range available {
+				l = append(l, c.name)
+			}
+			return nil, fmt.Errorf("Unknown column %q. Available columns: %s", name, strings.Join(l, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// structColumns returns all exported fields of t as columns.
+func structColumns(t reflect.Type) []column {
+	var columns []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 || f.Anonymous {
+			continue
+		}
+		name := jsonName(f)
+		if name == "-" {
+			continue
+		}
+		columns = append(columns, column{name: name, index: f.Index})
+	}
+	return columns
+}
+
+// cell returns the value of column c of item as string.
+func (c column) cell(item reflect.Value) string {
+	if c.index == nil {
+		return cell(item)
+	}
+	return cell(item.FieldByIndex(c.index))
+}
+
+// cell formats v for a table cell.
+// Lists of entities are rendered by the names (or IDs) of the entities.
+func cell(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array:
+		l := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			l = append(l, label(v.Index(i)))
+		}
+		return strings.Join(l, ", ")
+	case reflect.Struct, reflect.Map:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		return string(b)
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+// label returns a short name for v: the Name or ID field of structs or the formatted value otherwise.
+func label(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() || v.Kind() != reflect.Struct {
+		return cell(v)
+	}
+	for _, name := range []string{"Name", "ID"} {
+		if f := v.FieldByName(name); f.IsValid() && !isZero(f) {
+			return cell(f)
+		}
+	}
+	return cell(v)
+}
+
+// dataField returns the slice in the field with the JSON name "data" of the struct v.
+func dataField(v reflect.Value) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 || jsonName(f) != "data" {
+			continue
+		}
+		data := indirect(v.Field(i))
+		if data.Kind() == reflect.Slice || data.Kind() == reflect.Array {
+			return data
+		}
+	}
+	return reflect.Value{}
+}
+
+// jsonName returns the name of f in JSON documents.
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if len(name) == 0 {
+		return f.Name
+	}
+	return name
+}
+
+// indirect dereferences pointers and interfaces.
+// It returns the zero Value for nil pointers.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isZero reports whether v is the zero value of its type.
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
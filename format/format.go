@@ -0,0 +1,128 @@
+/*
+Package format renders the entities of the cachet library for humans and scripts.
+
+Any list response (e.g. cachet.ComponentResponse or cachet.IncidentResponse),
+slice of entities or single entity (e.g. *cachet.Component) can be written as
+an aligned text table, JSON, YAML, CSV or via a Go text/template.
+JSON and YAML contain the whole value, including the pagination of list responses.
+The other formats render the entities of the "data" part:
+
+	components, _, err := client.Components.GetAll(nil)
+
+	err = format.Write(os.Stdout, components, &format.Options{
+		Format:  format.Table,
+		Columns: []string{"id", "name", "status_name"},
+	})
+
+	// ID  NAME  STATUS_NAME
+	// 1   API   Operational
+
+Columns are selected by the JSON names of the entity fields.
+Templates are executed once per entity and get the entity itself as data:
+
+	err = format.Write(os.Stdout, components, &format.Options{
+		Format:   format.Template,
+		Template: "{{.Name}} is {{.StatusName}}",
+	})
+*/
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Table renders entities as an aligned text table
+	Table = "table"
+	// JSON renders entities as indented JSON
+	JSON = "json"
+	// YAML renders entities as YAML
+	YAML = "yaml"
+	// CSV renders entities as comma separated values including a header row
+	CSV = "csv"
+	// Template renders every entity with a Go text/template
+	Template = "template"
+)
+
+// Formats contains all supported output formats.
+var Formats = []string{Table, JSON, YAML, CSV, Template}
+
+// Options configures how entities are rendered.
+type Options struct {
+	// Format is one of Formats. Table is used if empty.
+	Format string
+
+	// Columns are the JSON names of the fields shown by Table and CSV.
+	// If empty, a set of default columns for the entity is used.
+	Columns []string
+
+	// Template is the text/template used by the Template format.
+	Template string
+
+	// NoHeader omits the header row of Table and CSV.
+	NoHeader bool
+}
+
+// Write renders v to w.
+// v can be a list response of the cachet library, a slice of entities or a single entity.
+// Table, CSV and Template render the "data" part of list responses, JSON and YAML the whole response.
+// If o is nil, v is rendered as a table with the default columns.
+func Write(w io.Writer, v interface{}, o *Options) error {
+	if o == nil {
+		o = &Options{}
+	}
+
+	switch o.Format {
+	case Table, "":
+		return writeTable(w, newEntities(v), o)
+	case JSON:
+		return writeJSON(w, v)
+	case YAML:
+		return writeYAML(w, v)
+	case CSV:
+		return writeCSV(w, newEntities(v), o)
+	case Template:
+		return writeTemplate(w, newEntities(v), o)
+	}
+
+	return fmt.Errorf("Unknown format %q. Supported formats: %v", o.Format, Formats)
+}
+
+// writeJSON writes v as indented JSON to w.
+func writeJSON(w io.Writer, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// writeYAML writes v as YAML to w.
+// The entities are converted via JSON to use the same field names and
+// to keep the order of the fields.
+func writeYAML(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON is valid YAML. Decoding it into a yaml.MapSlice keeps the order of all (nested) keys.
+	// The value is wrapped, because a yaml.MapSlice can only hold mappings.
+	wrapped := yaml.MapSlice{}
+	b = append(append([]byte(`{"v":`), b...), '}')
+	if err := yaml.Unmarshal(b, &wrapped); err != nil {
+		return err
+	}
+
+	b, err = yaml.Marshal(wrapped[0].Value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,102 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// testComponents is a list response used by most tests.
+var testComponents = &cachet.ComponentResponse{
+	Meta: cachet.Meta{Pagination: cachet.Pagination{Total: 2}},
+	Components: []cachet.Component{
+		{ID: 1, Name: "API", Status: cachet.ComponentStatusOperational, StatusName: "Operational", Enabled: true},
+		{ID: 2, Name: "Website", Status: cachet.ComponentStatusMajorOutage, StatusName: "Major Outage", GroupID: 3},
+	},
+}
+
+func TestWrite_JSON(t *testing.T) {
+	mockData := []struct {
+		Value    interface{}
+		Expected string
+	}{
+		{&cachet.Component{ID: 1, Name: "API"}, "{\n  \"id\": 1,\n  \"name\": \"API\"\n}\n"},
+		{[]cachet.Incident{{ID: 4}}, "[\n  {\n    \"id\": 4\n  }\n]\n"},
+		{
+			&cachet.IncidentResponse{Meta: cachet.Meta{Pagination: cachet.Pagination{Total: 21, TotalPages: 2}}, Incidents: []cachet.Incident{{ID: 4}}},
+			`{
+  "meta": {
+    "pagination": {
+      "total": 21,
+      "count": 0,
+      "per_page": 0,
+      "current_page": 0,
+      "total_pages": 2,
+      "links": {
+        "next_page": "",
+        "previous_page": ""
+      }
+    }
+  },
+  "data": [
+    {
+      "id": 4
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, mock := range mockData {
+		var buf bytes.Buffer
+		if err := Write(&buf, mock.Value, &Options{Format: JSON}); err != nil {
+			t.Errorf("Write returned error: %v", err)
+		}
+		if got := buf.String(); got != mock.Expected {
+			t.Errorf("Write returned %q, want %q", got, mock.Expected)
+		}
+	}
+}
+
+func TestWrite_YAML(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, testComponents, &Options{Format: YAML})
+	if err != nil {
+		t.Errorf("Write returned error: %v", err)
+	}
+
+	expected := `meta:
+  pagination:
+    total: 2
+    count: 0
+    per_page: 0
+    current_page: 0
+    total_pages: 0
+    links:
+      next_page: ""
+      previous_page: ""
+data:
+- id: 1
+  name: API
+  status: 1
+  enabled: true
+  status_name: Operational
+- id: 2
+  name: Website
+  status: 4
+  group_id: 3
+  status_name: Major Outage
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("Write returned %q, want %q", got, expected)
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testComponents, &Options{Format: "xml"}); err == nil {
+		t.Error("Write returned no error for an unknown format. Expected one.")
+	}
+}
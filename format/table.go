@@ -0,0 +1,76 @@
+package format
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// writeTable writes the entities as an aligned text table to w.
+func writeTable(w io.Writer, e *entities, o *Options) error {
+	columns, err := e.columns(o.Columns)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if !o.NoHeader {
+		header := make([]string, 0, len(columns))
+		for _, c := range columns {
+			header = append(header, strings.ToUpper(c.name))
+		}
+		if _, err := io.WriteString(tw, strings.Join(header, "\t")+"\n"); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range e.items {
+		row := make([]string, 0, len(columns))
+		for _, c := range columns {
+			row = append(row, tableCell(c.cell(item)))
+		}
+		if _, err := io.WriteString(tw, strings.Join(row, "\t")+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// tableCell makes sure that a value does not break the layout of the table.
+func tableCell(s string) string {
+	return strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ").Replace(s)
+}
+
+// writeCSV writes the entities as comma separated values to w.
+func writeCSV(w io.Writer, e *entities, o *Options) error {
+	columns, err := e.columns(o.Columns)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if !o.NoHeader {
+		header := make([]string, 0, len(columns))
+		for _, c := range columns {
+			header = append(header, c.name)
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range e.items {
+		row := make([]string, 0, len(columns))
+		for _, c := range columns {
+			row = append(row, c.cell(item))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
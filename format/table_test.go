@@ -0,0 +1,77 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestWrite_Table(t *testing.T) {
+	mockData := []struct {
+		Value    interface{}
+		Options  *Options
+		Expected string
+	}{
+		{
+			testComponents,
+			nil,
+			"ID  NAME     STATUS_NAME   GROUP_ID  ENABLED\n" +
+				"1   API      Operational   0         true\n" +
+				"2   Website  Major Outage  3         false\n",
+		},
+		{
+			testComponents.Components,
+			&Options{Columns: []string{"name", "STATUS"}, NoHeader: true},
+			"API      1\n" +
+				"Website  4\n",
+		},
+		{
+			&cachet.Schedule{ID: 1, Name: "DB patching", Components: []cachet.Component{{ID: 1, Name: "API"}, {ID: 2}}},
+			&Options{Columns: []string{"id", "components"}},
+			"ID  COMPONENTS\n" +
+				"1   API, 2\n",
+		},
+		{
+			"Pong!",
+			&Options{Format: Table},
+			"VALUE\n" +
+				"Pong!\n",
+		},
+	}
+
+	for _, mock := range mockData {
+		var buf bytes.Buffer
+		if err := Write(&buf, mock.Value, mock.Options); err != nil {
+			t.Errorf("Write returned error: %v", err)
+		}
+		if got := buf.String(); got != mock.Expected {
+			t.Errorf("Write returned %q, want %q", got, mock.Expected)
+		}
+	}
+}
+
+func TestWrite_TableUnknownColumn(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testComponents, &Options{Columns: []string{"foo"}}); err == nil {
+		t.Error("Write returned no error for an unknown column. Expected one.")
+	}
+}
+
+func TestWrite_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, &cachet.IncidentUpdateResponse{
+		IncidentUpdates: []cachet.IncidentUpdate{
+			{ID: 1, IncidentID: 2, HumanStatus: "Fixed", Message: "Fixed, \"finally\"", CreatedAt: "2017-09-26 10:00:00"},
+		},
+	}, &Options{Format: CSV})
+	if err != nil {
+		t.Errorf("Write returned error: %v", err)
+	}
+
+	expected := "id,incident_id,human_status,message,created_at\n" +
+		"1,2,Fixed,\"Fixed, \"\"finally\"\"\",2017-09-26 10:00:00\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("Write returned %q, want %q", got, expected)
+	}
+}
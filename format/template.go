@@ -0,0 +1,41 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the additional functions available in templates.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// writeTemplate executes the template once per entity and writes the results, each on its own line, to w.
+func writeTemplate(w io.Writer, e *entities, o *Options) error {
+	if len(o.Template) == 0 {
+		return fmt.Errorf("No template given")
+	}
+
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(o.Template)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range e.items {
+		if err := tmpl.Execute(w, item.Interface()); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
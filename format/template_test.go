@@ -0,0 +1,29 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrite_Template(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, testComponents, &Options{Format: Template, Template: `{{.ID}}: {{upper .Name}} is {{.StatusName}}`})
+	if err != nil {
+		t.Errorf("Write returned error: %v", err)
+	}
+
+	expected := "1: API is Operational\n2: WEBSITE is Major Outage\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("Write returned %q, want %q", got, expected)
+	}
+}
+
+func TestWrite_TemplateErrors(t *testing.T) {
+	mockData := []string{"", "{{.Name", "{{.Unknown}}"}
+	for _, tmpl := range mockData {
+		var buf bytes.Buffer
+		if err := Write(&buf, testComponents, &Options{Format: Template, Template: tmpl}); err == nil {
+			t.Errorf("Write with template %q returned no error. Expected one.", tmpl)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+/*
+Package grafana manages incidents with alerts of Grafana unified alerting.
+
+A Handler receives the webhooks of a Grafana contact point. Alerts are mapped to
+components by rules matching their labels and annotations:
+
+	h, err := grafana.NewHandler(client, &grafana.Options{
+		Rules: []grafana.Rule{
+			{
+				Labels:    map[string]string{"team": "api", "severity": "critical"},
+				Component: 3,
+				Status:    cachet.ComponentStatusMajorOutage,
+				Name:      "API: {{.Labels.alertname}}",
+				Message:   "{{.Annotations.description}} (current value: {{.ValueString}})",
+			},
+			{Labels: map[string]string{"team": "api"}, Component: 3},
+		},
+	})
+	http.Handle("/grafana", h)
+
+The first matching rule wins, alerts without matching rule are ignored.
+Incidents are created, updated and resolved like in package alertmanager:
+a firing alert creates an incident for its component or updates the open one,
+once all alerts of a component are resolved, the incident is fixed and the component is operational again.
+*/
+package grafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/alertmanager"
+	"github.com/andygrunwald/cachet/dedup"
+)
+
+// Labels used to pass the mapped alerts to the alertmanager handler.
+const (
+	componentLabel = "cachet_component"
+	statusLabel    = "cachet_component_status"
+)
+
+// Message is the payload of a Grafana unified alerting webhook.
+type Message struct {
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	OrgID             int               `json:"orgId"`
+	Alerts            []Alert           `json:"alerts"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Title             string            `json:"title"`
+	State             string            `json:"state"`
+	Message           string            `json:"message"`
+}
+
+// Alert is a single alert of a webhook.
+type Alert struct {
+	Status       string             `json:"status"`
+	Labels       map[string]string  `json:"labels"`
+	Annotations  map[string]string  `json:"annotations"`
+	StartsAt     time.Time          `json:"startsAt"`
+	EndsAt       time.Time          `json:"endsAt"`
+	GeneratorURL string             `json:"generatorURL"`
+	Fingerprint  string             `json:"fingerprint"`
+	SilenceURL   string             `json:"silenceURL"`
+	DashboardURL string             `json:"dashboardURL"`
+	PanelURL     string             `json:"panelURL"`
+	Values       map[string]float64 `json:"values"`
+	ValueString  string             `json:"valueString"`
+}
+
+// Rule maps matching alerts to a component.
+type Rule struct {
+	// Labels and Annotations have to match all given keys.
+	// Values are regular expressions matching the whole value.
+	Labels      map[string]string
+	Annotations map[string]string
+
+	// Component is the ID of the component.
+	Component int
+	// Status is the component status of firing alerts. It defaults to cachet.ComponentStatusPartialOutage.
+	Status int
+
+	// Name and Message are text/templates rendering the name and message of incidents with the *Alert as data.
+	// They default to the summary and description annotations.
+	Name    string
+	Message string
+}
+
+// Options configures the handler.
+type Options struct {
+	Rules []Rule
+	// Visible is the visibility of new incidents. It defaults to cachet.IncidentVisibilityPublic.
+	Visible int
+	// Notify notifies subscribers about new incidents.
+	Notify bool
+	// Ensurer finds and creates the incidents of components, see alertmanager.Options.
+	Ensurer *dedup.Ensurer
+}
+
+// rule is a compiled Rule.
+type rule struct {
+	Rule
+	labels      map[string]*regexp.Regexp
+	annotations map[string]*regexp.Regexp
+	name        *template.Template
+	message     *template.Template
+}
+
+// Handler is an http.Handler receiving Grafana webhooks.
+type Handler struct {
+	rules  []*rule
+	alerts *alertmanager.Handler
+}
+
+// NewHandler returns a new handler. Invalid rules are reported as error.
+func NewHandler(client *cachet.Client, o *Options) (*Handler, error) {
+	if o == nil || len(o.Rules) == 0 {
+		return nil, fmt.Errorf("The Grafana handler needs at least one rule")
+	}
+
+	h := &Handler{}
+	for k, r := range o.Rules {
+		compiled, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("Rule %d: %v", k+1, err)
+		}
+		h.rules = append(h.rules, compiled)
+	}
+
+	severities := map[string]int{}
+	for _, status := range []int{cachet.ComponentStatusPerformanceIssues, cachet.ComponentStatusPartialOutage, cachet.ComponentStatusMajorOutage} {
+		severities[strconv.Itoa(status)] = status
+	}
+	h.alerts = alertmanager.NewHandler(client, &alertmanager.Options{
+		ComponentLabel: componentLabel,
+		SeverityLabel:  statusLabel,
+		Severities:     severities,
+		Visible:        o.Visible,
+		Notify:         o.Notify,
+		Ensurer:        o.Ensurer,
+	})
+	return h, nil
+}
+
+// compile validates the rule r and compiles its expressions and templates.
+func compile(r Rule) (*rule, error) {
+	if r.Component <= 0 {
+		return nil, fmt.Errorf("No component given")
+	}
+	if r.Status == 0 {
+		r.Status = cachet.ComponentStatusPartialOutage
+	}
+	if r.Status < cachet.ComponentStatusPerformanceIssues || r.Status > cachet.ComponentStatusMajorOutage {
+		return nil, fmt.Errorf("Invalid component status %d", r.Status)
+	}
+
+	c := &rule{Rule: r}
+	var err error
+	if c.labels, err = compileMatchers(r.Labels); err != nil {
+		return nil, err
+	}
+	if c.annotations, err = compileMatchers(r.Annotations); err != nil {
+		return nil, err
+	}
+	if c.name, err = template.New("name").Option("missingkey=zero").Parse(r.Name); err != nil {
+		return nil, err
+	}
+	if c.message, err = template.New("message").Option("missingkey=zero").Parse(r.Message); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func compileMatchers(m map[string]string) (map[string]*regexp.Regexp, error) {
+	compiled := map[string]*regexp.Regexp{}
+	for key, expr := range m {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("Invalid expression for %q: %v", key, err)
+		}
+		compiled[key] = re
+	}
+	return compiled, nil
+}
+
+// matches reports whether the alert matches all matchers of the rule.
+func (r *rule) matches(a *Alert) bool {
+	for key, re := range r.labels {
+		if !re.MatchString(a.Labels[key]) {
+			return false
+		}
+	}
+	for key, re := range r.annotations {
+		if !re.MatchString(a.Annotations[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	m := &Message{}
+	if err := json.NewDecoder(r.Body).Decode(m); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid webhook payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Handle(m); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Handle maps all alerts of the message and applies them.
+func (h *Handler) Handle(m *Message) error {
+	converted := &alertmanager.Message{Status: m.Status, Receiver: m.Receiver, ExternalURL: m.ExternalURL}
+	for k := range m.Alerts {
+		a, ok, err := h.convert(&m.Alerts[k])
+		if err != nil {
+			return err
+		}
+		if ok {
+			converted.Alerts = append(converted.Alerts, a)
+		}
+	}
+	return h.alerts.Handle(converted)
+}
+
+// convert maps a Grafana alert with the first matching rule to an alert of the alertmanager handler.
+func (h *Handler) convert(a *Alert) (alertmanager.Alert, bool, error) {
+	for _, r := range h.rules {
+		if !r.matches(a) {
+			continue
+		}
+
+		name, err := render(r.name, a, a.Annotations["summary"], a.Labels["alertname"])
+		if err != nil {
+			return alertmanager.Alert{}, false, err
+		}
+		message, err := render(r.message, a, a.Annotations["description"], name)
+		if err != nil {
+			return alertmanager.Alert{}, false, err
+		}
+
+		labels := map[string]string{
+			componentLabel: strconv.Itoa(r.Component),
+			statusLabel:    strconv.Itoa(r.Status),
+		}
+		fingerprint := a.Fingerprint
+		if len(fingerprint) == 0 {
+			fingerprint = alertFingerprint(a)
+		}
+		return alertmanager.Alert{
+			Status:       a.Status,
+			Labels:       labels,
+			Annotations:  map[string]string{"summary": name, "description": message},
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  fingerprint,
+		}, true, nil
+	}
+	return alertmanager.Alert{}, false, nil
+}
+
+// render executes t with the alert. Empty results fall back to the first non-empty default.
+func render(t *template.Template, a *Alert, defaults ...string) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, a); err != nil {
+		return "", fmt.Errorf("Failed to render alert %q: %v", a.Labels["alertname"], err)
+	}
+	if s := strings.TrimSpace(buf.String()); len(s) > 0 {
+		return s, nil
+	}
+	for _, d := range defaults {
+		if len(d) > 0 {
+			return d, nil
+		}
+	}
+	return "", nil
+}
+
+// alertFingerprint identifies alerts of Grafana versions without fingerprint by their labels.
+func alertFingerprint(a *Alert) string {
+	b, _ := json.Marshal(a.Labels)
+	return string(b)
+}
@@ -0,0 +1,145 @@
+package grafana
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/dedup"
+	"github.com/andygrunwald/cachet/internal/cachettest"
+)
+
+// testRules maps critical alerts of the API team to a major outage of component 3 and all other alerts of the team to a partial outage.
+var testRules = []Rule{
+	{
+		Labels:    map[string]string{"team": "api", "severity": "critical|page"},
+		Component: 3,
+		Status:    cachet.ComponentStatusMajorOutage,
+		Name:      "API: {{.Labels.alertname}}",
+		Message:   "{{.Annotations.description}} (current value: {{.ValueString}})",
+	},
+	{Labels: map[string]string{"team": "api"}, Component: 3},
+}
+
+// post sends the alerts as webhook to h and returns the status code.
+func post(h http.Handler, status string, alerts ...string) int {
+	body := fmt.Sprintf(`{"receiver":"cachet","status":%q,"orgId":1,"version":"1","alerts":[%s]}`, status, strings.Join(alerts, ","))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/grafana", strings.NewReader(body)))
+	return w.Code
+}
+
+func TestHandler(t *testing.T) {
+	s := cachettest.NewServer()
+	defer s.Close()
+	s.Add("components", cachet.Component{ID: 3, Name: "API"})
+
+	h, err := NewHandler(s.Client, &Options{Rules: testRules})
+	if err != nil {
+		t.Fatalf("NewHandler returned error: %v", err)
+	}
+
+	firing := `{"status":"firing","fingerprint":"abc","labels":{"alertname":"HighLatency","team":"api","severity":"page"},
+		"annotations":{"description":"Latency above 2s"},"valueString":"[ var='A' value=2.5 ]"}`
+	if code := post(h, "firing", firing); code != http.StatusOK {
+		t.Fatalf("Handler returned %d, want %d", code, http.StatusOK)
+	}
+	expected := []cachettest.Write{
+		{Method: "POST", Path: "/api/v1/incidents", Body: map[string]interface{}{
+			"name": "API: HighLatency", "message": "Latency above 2s (current value: [ var='A' value=2.5 ])", "status": float64(1), "visible": float64(1),
+			"component_id": float64(3), "component_status": float64(4),
+		}},
+		{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(4)}},
+	}
+	if got := s.Writes(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Handler sent\n%v\nwant\n%v", got, expected)
+	}
+
+	resolved := strings.Replace(firing, `"status":"firing"`, `"status":"resolved"`, 1)
+	ignored := `{"status":"firing","labels":{"alertname":"DiskFull","team":"storage"}}`
+	if code := post(h, "resolved", resolved, ignored); code != http.StatusOK {
+		t.Fatalf("Handler returned %d, want %d", code, http.StatusOK)
+	}
+	expected = []cachettest.Write{
+		{Method: "POST", Path: "/api/v1/incidents/1/updates", Body: map[string]interface{}{
+			"message": "Resolved: API: HighLatency", "status": float64(4), "component_status": float64(1),
+		}},
+		{Method: "PUT", Path: "/api/v1/incidents/1", Body: map[string]interface{}{"status": float64(4)}},
+		{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(1)}},
+	}
+	if got := s.Writes(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Handler sent\n%v\nwant\n%v", got, expected)
+	}
+}
+
+func TestHandler_Ensurer(t *testing.T) {
+	s := cachettest.NewServer()
+	defer s.Close()
+	s.Add("components", cachet.Component{ID: 3, Name: "API"})
+	s.Add("incidents", cachet.Incident{ID: 5, Name: "Deployment failed", ComponentID: 3, Status: cachet.IncidentStatusIdentified})
+
+	// The shared Ensurer matches incidents by name, so the open incident of another integration is left alone.
+	e := dedup.NewEnsurer(s.Client, &dedup.Options{MatchName: true})
+	h, err := NewHandler(s.Client, &Options{Rules: testRules, Ensurer: e})
+	if err != nil {
+		t.Fatalf("NewHandler returned error: %v", err)
+	}
+
+	firing := `{"status":"firing","fingerprint":"abc","labels":{"alertname":"HighLatency","team":"api","severity":"page"}}`
+	if code := post(h, "firing", firing); code != http.StatusOK {
+		t.Fatalf("Handler returned %d, want %d", code, http.StatusOK)
+	}
+	if got := s.Writes(); len(got) == 0 || got[0].Method != "POST" || got[0].Path != "/api/v1/incidents" {
+		t.Errorf("Handler sent %v, want a new incident", got)
+	}
+}
+
+func TestHandler_convert(t *testing.T) {
+	h, _ := NewHandler(nil, &Options{Rules: testRules})
+
+	a, ok, err := h.convert(&Alert{
+		Status:      "firing",
+		Labels:      map[string]string{"alertname": "Errors", "team": "api", "severity": "warning"},
+		Annotations: map[string]string{"summary": "Error rate is high"},
+	})
+	if err != nil || !ok {
+		t.Fatalf("convert returned %v, %v", ok, err)
+	}
+	if a.Labels[componentLabel] != "3" || a.Labels[statusLabel] != "3" {
+		t.Errorf("convert returned labels %v, want component 3 with a partial outage", a.Labels)
+	}
+	if a.Annotations["summary"] != "Error rate is high" || a.Annotations["description"] != "Error rate is high" {
+		t.Errorf("convert returned annotations %v, want the summary as name and message", a.Annotations)
+	}
+	if len(a.Fingerprint) == 0 {
+		t.Error("convert returned no fingerprint for an alert without fingerprint")
+	}
+
+	if _, ok, _ := h.convert(&Alert{Labels: map[string]string{"team": "api-gateway"}}); ok {
+		t.Error("convert matched a label value only partially")
+	}
+}
+
+func TestNewHandler_Invalid(t *testing.T) {
+	mockData := []struct {
+		options  *Options
+		expected string
+	}{
+		{nil, "The Grafana handler needs at least one rule"},
+		{&Options{Rules: []Rule{{}}}, "Rule 1: No component given"},
+		{&Options{Rules: []Rule{{Component: 1, Status: cachet.ComponentStatusOperational}}}, "Rule 1: Invalid component status 1"},
+		{&Options{Rules: []Rule{{Component: 1, Labels: map[string]string{"team": "("}}}}, "Rule 1: Invalid expression for \"team\": error parsing regexp: missing closing ): `^(?:()$`"},
+		{&Options{Rules: []Rule{{Component: 1, Name: "{{.Labels"}}}, "Rule 1: template: name:1: unclosed action"},
+	}
+
+	for _, data := range mockData {
+		_, err := NewHandler(nil, data.options)
+		if err == nil || err.Error() != data.expected {
+			t.Errorf("NewHandler returned %v, want %q", err, data.expected)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Package ical converts schedules to and from iCalendar (RFC 5545).
+
+Export writes all schedules of an instance as a calendar with one VEVENT per schedule:
+
+	f, _ := os.Create("maintenance.ics")
+	err := ical.Export(client, f, nil)
+
+Import creates a schedule for every event of a calendar.
+Events that already exist as schedule with the same name and start are skipped,
+so the same calendar can be imported repeatedly:
+
+	f, _ := os.Open("maintenance.ics")
+	result, err := ical.Import(client, f, nil)
+
+Recurring events are rejected, package recurrence creates series of schedules.
+
+Besides the standard properties, events carry the status and the affected components
+of a schedule in the properties X-CACHET-STATUS and X-CACHET-COMPONENTS.
+*/
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+const (
+	// utcLayout is the layout of UTC date-times in iCalendar.
+	utcLayout = "20060102T150405Z"
+	// lineLength is the maximum length of a line in octets, excluding the line break.
+	lineLength = 75
+)
+
+// statusNames maps the status of schedules to the value of X-CACHET-STATUS.
+var statusNames = map[int]string{
+	cachet.ScheduleUpcoming:   "upcoming",
+	cachet.ScheduleInProgress: "in-progress",
+	cachet.ScheduleComplete:   "complete",
+}
+
+// eventStatuses maps the status of schedules to the STATUS of events.
+var eventStatuses = map[int]string{
+	cachet.ScheduleUpcoming:   "TENTATIVE",
+	cachet.ScheduleInProgress: "CONFIRMED",
+	cachet.ScheduleComplete:   "CONFIRMED",
+}
+
+// Options configures the conversion.
+type Options struct {
+	// Location is the time zone of the Cachet instance. It defaults to UTC.
+	Location *time.Location
+	// Domain is used in the UID of events. It defaults to "cachet".
+	Domain string
+}
+
+func (o *Options) location() *time.Location {
+	if o == nil || o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+func (o *Options) domain() string {
+	if o == nil || len(o.Domain) == 0 {
+		return "cachet"
+	}
+	return o.Domain
+}
+
+// Export writes all schedules of the instance as calendar to w.
+func Export(client *cachet.Client, w io.Writer, o *Options) error {
+	schedules, err := fetch.Schedules(client, nil)
+	if err != nil {
+		return err
+	}
+	return Encode(w, schedules, o)
+}
+
+// Encode writes the schedules as calendar to w.
+// Schedules without a valid ScheduledAt are skipped.
+func Encode(w io.Writer, schedules []cachet.Schedule, o *Options) error {
+	bw := bufio.NewWriter(w)
+	loc := o.location()
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:-//andygrunwald//cachet//EN")
+	writeLine(bw, "CALSCALE:GREGORIAN")
+
+	for _, s := range schedules {
+		start, err := time.ParseInLocation(api.TimestampLayout, s.ScheduledAt, loc)
+		if err != nil {
+			continue
+		}
+
+		// DTSTAMP is taken from the schedule to get a stable output.
+		stamp := start
+		for _, v := range []string{s.UpdatedAt, s.CreatedAt} {
+			if t, err := time.ParseInLocation(api.TimestampLayout, v, loc); err == nil {
+				stamp = t
+				break
+			}
+		}
+
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, fmt.Sprintf("UID:schedule-%d@%s", s.ID, o.domain()))
+		writeLine(bw, "DTSTAMP:"+stamp.UTC().Format(utcLayout))
+		writeLine(bw, "DTSTART:"+start.UTC().Format(utcLayout))
+		if end, err := time.ParseInLocation(api.TimestampLayout, s.CompletedAt, loc); err == nil {
+			writeLine(bw, "DTEND:"+end.UTC().Format(utcLayout))
+		}
+		writeLine(bw, "SUMMARY:"+escape(s.Name))
+		if len(s.Message) > 0 {
+			writeLine(bw, "DESCRIPTION:"+escape(s.Message))
+		}
+		if status, ok := eventStatuses[s.Status]; ok {
+			writeLine(bw, "STATUS:"+status)
+			writeLine(bw, "X-CACHET-STATUS:"+statusNames[s.Status])
+		}
+		if len(s.Components) > 0 {
+			ids := make([]string, 0, len(s.Components))
+			for _, c := range s.Components {
+				ids = append(ids, strconv.Itoa(c.ID))
+			}
+			writeLine(bw, "X-CACHET-COMPONENTS:"+strings.Join(ids, ","))
+		}
+		writeLine(bw, "END:VEVENT")
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// writeLine writes a content line folded after 75 octets.
+// Lines are only folded between runes to not break UTF-8 sequences.
+func writeLine(w *bufio.Writer, line string) {
+	n := 0
+	for i, r := range line {
+		size := len(string(r))
+		if n+size > lineLength {
+			w.WriteString("\r\n ")
+			// The leading space counts towards the length of the continuation line.
+			n = 1
+		}
+		w.WriteString(line[i : i+size])
+		n += size
+	}
+	w.WriteString("\r\n")
+}
+
+// escape escapes a TEXT value.
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
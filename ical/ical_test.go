@@ -0,0 +1,101 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func TestEncode(t *testing.T) {
+	schedules := []cachet.Schedule{
+		{
+			ID:          1,
+			Name:        "Database upgrade; part 1",
+			Message:     "Short interruptions,\nplease be patient",
+			Status:      cachet.ScheduleUpcoming,
+			ScheduledAt: "2017-10-10 22:00:00",
+			CompletedAt: "2017-10-11 00:00:00",
+			UpdatedAt:   "2017-10-01 08:00:00",
+			Components:  []cachet.Component{{ID: 2}, {ID: 3}},
+		},
+		{ID: 2, Name: "Invalid"},
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, schedules, &Options{Domain: "status.example.com"}); err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//andygrunwald//cachet//EN",
+		"CALSCALE:GREGORIAN",
+		"BEGIN:VEVENT",
+		"UID:schedule-1@status.example.com",
+		"DTSTAMP:20171001T080000Z",
+		"DTSTART:20171010T220000Z",
+		"DTEND:20171011T000000Z",
+		`SUMMARY:Database upgrade\; part 1`,
+		`DESCRIPTION:Short interruptions\,\nplease be patient`,
+		"STATUS:TENTATIVE",
+		"X-CACHET-STATUS:upcoming",
+		"X-CACHET-COMPONENTS:2,3",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if got := buf.String(); got != expected {
+		t.Errorf("Encode returned\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestWriteLine_Fold(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	writeLine(w, "DESCRIPTION:"+strings.Repeat("ä", 40))
+	w.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	if len(lines) != 2 {
+		t.Fatalf("writeLine wrote %d lines, want 2", len(lines))
+	}
+	for _, line := range lines {
+		if len(line) > lineLength {
+			t.Errorf("Line %q is %d octets long, want at most %d", line, len(line), lineLength)
+		}
+	}
+
+	got, _ := unfold(strings.NewReader(strings.Join(lines, "\r\n")))
+	if expected := "DESCRIPTION:" + strings.Repeat("ä", 40); len(got) != 1 || got[0] != expected {
+		t.Errorf("unfold returned %q, want %q", got, expected)
+	}
+}
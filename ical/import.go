@@ -0,0 +1,65 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// ImportResult contains the changes made by Import.
+type ImportResult struct {
+	// Created contains the schedules created for events.
+	Created []cachet.Schedule
+	// Skipped contains the events that already existed as schedule.
+	Skipped []cachet.Schedule
+}
+
+// Import creates a schedule for every event of the calendar in r.
+// Events are skipped if a schedule with the same name and start exists.
+// The component IDs of X-CACHET-COMPONENTS are only meaningful for the instance the calendar was exported from.
+// The returned ImportResult contains the changes made so far, even if an error occurred.
+func Import(client *cachet.Client, r io.Reader, o *Options) (*ImportResult, error) {
+	result := &ImportResult{}
+
+	schedules, err := Parse(r, o)
+	if err != nil {
+		return result, err
+	}
+
+	existing, err := fetch.Schedules(client, nil)
+	if err != nil {
+		return result, err
+	}
+	keys := map[string]bool{}
+	for _, s := range existing {
+		keys[s.Name+"\x00"+s.ScheduledAt] = true
+	}
+
+	for _, s := range schedules {
+		key := s.Name + "\x00" + s.ScheduledAt
+		if keys[key] {
+			result.Skipped = append(result.Skipped, s)
+			continue
+		}
+
+		body := &api.Schedule{
+			Name:        s.Name,
+			Message:     s.Message,
+			Status:      s.Status,
+			ScheduledAt: s.ScheduledAt,
+			CompletedAt: s.CompletedAt,
+			Components:  s.Components,
+		}
+		created, err := api.CreateSchedule(client, body)
+		if err != nil {
+			return result, fmt.Errorf("Failed to create schedule %q: %v", s.Name, err)
+		}
+		keys[key] = true
+		result.Created = append(result.Created, *created)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package ical
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var created []map[string]interface{}
+	testMux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":[{"id":1,"name":"Upgrade","scheduled_at":"2017-10-10 22:00:00"}]}`)
+			return
+		}
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		created = append(created, body)
+		fmt.Fprint(w, `{"data":{"id":2,"name":"Upgrade"}}`)
+	})
+
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"DTSTART:20171010T220000Z",
+		"SUMMARY:Upgrade",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20991017T220000Z",
+		"DTEND:20991017T230000Z",
+		"SUMMARY:Upgrade",
+		"X-CACHET-COMPONENTS:4",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	got, err := Import(testClient, strings.NewReader(calendar), nil)
+	if err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	if len(got.Created) != 1 || len(got.Skipped) != 1 {
+		t.Errorf("Import returned %+v, want one created and one skipped schedule", got)
+	}
+
+	expected := []map[string]interface{}{{
+		"name": "Upgrade", "message": "", "status": float64(0),
+		"scheduled_at": "2099-10-17 22:00:00", "completed_at": "2099-10-17 23:00:00",
+		"components": []interface{}{map[string]interface{}{"id": float64(4)}},
+	}}
+	if !reflect.DeepEqual(created, expected) {
+		t.Errorf("Import created %+v, want %+v", created, expected)
+	}
+}
+
+func TestExport(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":1,"name":"Upgrade","status":2,"scheduled_at":"2017-10-10 22:00:00"}]}`)
+	})
+
+	var buf bytes.Buffer
+	if err := Export(testClient, &buf, nil); err != nil {
+		t.Fatalf("Export returned error: %v", err)
+	}
+	for _, line := range []string{"UID:schedule-1@cachet", "SUMMARY:Upgrade", "STATUS:CONFIRMED", "X-CACHET-STATUS:complete"} {
+		if !strings.Contains(buf.String(), line+"\r\n") {
+			t.Errorf("Export returned\n%s\nwithout %q", buf.String(), line)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+)
+
+// property is a content line of a calendar.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse returns a schedule for every VEVENT of the calendar in r.
+// Cancelled events are skipped. Recurring events (RRULE or RDATE) are not supported and result in an error.
+// Properties of components nested in events (e.g. VALARM) are ignored.
+// Without X-CACHET-STATUS the status is derived from the start and end of the event.
+func Parse(r io.Reader, o *Options) ([]cachet.Schedule, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var schedules []cachet.Schedule
+	var event []property
+	// depth is the nesting level of components inside the current event.
+	// It is 0 outside of events and 1 for the properties of the event itself.
+	depth := 0
+
+	for n, line := range lines {
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %v", n+1, err)
+		}
+
+		switch {
+		case depth == 0 && p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			depth = 1
+			event = nil
+		case depth == 1 && p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			depth = 0
+			s, err := eventSchedule(event, o)
+			if err != nil {
+				return nil, fmt.Errorf("Event ending in line %d: %v", n+1, err)
+			}
+			if s != nil {
+				schedules = append(schedules, *s)
+			}
+		case depth > 0 && p.name == "BEGIN":
+			depth++
+		case depth > 1 && p.name == "END":
+			depth--
+		case depth == 1:
+			event = append(event, p)
+		}
+	}
+	return schedules, nil
+}
+
+// eventSchedule converts the properties of an event into a schedule.
+// It returns nil for cancelled events.
+func eventSchedule(event []property, o *Options) (*cachet.Schedule, error) {
+	loc := o.location()
+	s := &cachet.Schedule{Status: -1}
+
+	var start, end time.Time
+	var duration time.Duration
+	for _, p := range event {
+		var err error
+		switch p.name {
+		case "SUMMARY":
+			s.Name = unescape(p.value)
+		case "DESCRIPTION":
+			s.Message = unescape(p.value)
+		case "DTSTART":
+			start, err = parseTime(p, loc)
+		case "DTEND":
+			end, err = parseTime(p, loc)
+		case "DURATION":
+			duration, err = parseDuration(p.value)
+		case "STATUS":
+			if strings.EqualFold(p.value, "CANCELLED") {
+				return nil, nil
+			}
+		case "RRULE", "RDATE":
+			return nil, fmt.Errorf("Recurring events (%s) are not supported. Use package recurrence for series of schedules", p.name)
+		case "X-CACHET-STATUS":
+			for status, name := range statusNames {
+				if strings.EqualFold(p.value, name) {
+					s.Status = status
+				}
+			}
+		case "X-CACHET-COMPONENTS":
+			for _, v := range strings.Split(p.value, ",") {
+				id, err := strconv.Atoi(strings.TrimSpace(v))
+				if err != nil {
+					return nil, fmt.Errorf("Invalid component ID %q", v)
+				}
+				s.Components = append(s.Components, cachet.Component{ID: id})
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s: %v", p.name, err)
+		}
+	}
+
+	if len(s.Name) == 0 {
+		return nil, fmt.Errorf("Event has no SUMMARY")
+	}
+	if start.IsZero() {
+		return nil, fmt.Errorf("Event %q has no DTSTART", s.Name)
+	}
+	if end.IsZero() && duration > 0 {
+		end = start.Add(duration)
+	}
+
+	s.ScheduledAt = start.In(loc).Format(api.TimestampLayout)
+	if !end.IsZero() {
+		s.CompletedAt = end.In(loc).Format(api.TimestampLayout)
+	}
+
+	if s.Status < 0 {
+		now := time.Now()
+		switch {
+		case !end.IsZero() && !now.Before(end):
+			s.Status = cachet.ScheduleComplete
+		case !now.Before(start):
+			s.Status = cachet.ScheduleInProgress
+		default:
+			s.Status = cachet.ScheduleUpcoming
+		}
+	}
+	return s, nil
+}
+
+// unfold reads all content lines of r and joins folded lines.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) == 0 {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty parses a content line like "DTSTART;TZID=Europe/Berlin:20171010T220000".
+func parseProperty(line string) (property, error) {
+	p := property{params: map[string]string{}}
+
+	// The value starts at the first colon outside of a quoted parameter value.
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return p, fmt.Errorf("Invalid content line %q", line)
+	}
+	p.value = line[colon+1:]
+
+	parts := strings.Split(line[:colon], ";")
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) == 2 {
+			p.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return p, nil
+}
+
+// parseTime parses a DATE or DATE-TIME value.
+// Floating times are interpreted in loc.
+func parseTime(p property, loc *time.Location) (time.Time, error) {
+	if tzid, ok := p.params["TZID"]; ok {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, err
+		}
+		loc = l
+	}
+
+	if strings.HasSuffix(p.value, "Z") {
+		return time.Parse(utcLayout, p.value)
+	}
+	for _, layout := range []string{"20060102T150405", "20060102"} {
+		if t, err := time.ParseInLocation(layout, p.value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", p.value)
+}
+
+// parseDuration parses a DURATION value like "PT2H" or "P1DT30M".
+func parseDuration(s string) (time.Duration, error) {
+	if !strings.HasPrefix(s, "P") && !strings.HasPrefix(s, "+P") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	s = s[strings.Index(s, "P")+1:]
+
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			num += string(r)
+		case r == 'T':
+			inTime = true
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			num = ""
+
+			switch {
+			case r == 'W' && !inTime:
+				d += time.Duration(n) * 7 * 24 * time.Hour
+			case r == 'D' && !inTime:
+				d += time.Duration(n) * 24 * time.Hour
+			case r == 'H' && inTime:
+				d += time.Duration(n) * time.Hour
+			case r == 'M' && inTime:
+				d += time.Duration(n) * time.Minute
+			case r == 'S' && inTime:
+				d += time.Duration(n) * time.Second
+			default:
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+		}
+	}
+	if len(num) > 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// unescape unescapes a TEXT value.
+func unescape(s string) string {
+	var b bytes.Buffer
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped && (r == 'n' || r == 'N'):
+			b.WriteRune('\n')
+		case escaped:
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		escaped = false
+	}
+	return b.String()
+}
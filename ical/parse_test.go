@@ -0,0 +1,134 @@
+package ical
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestParse(t *testing.T) {
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:1@example.com",
+		"DTSTART;TZID=Europe/Berlin:20171010T220000",
+		"DURATION:PT1H30M",
+		"SUMMARY:Database ",
+		" upgrade",
+		`DESCRIPTION:Expect interruptions\, sorry\nThe ops team`,
+		"X-CACHET-STATUS:in-progress",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"TRIGGER:-PT15M",
+		"DESCRIPTION:Reminder",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20171012T080000Z",
+		"SUMMARY:Cancelled",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20000101",
+		"DTEND;VALUE=DATE:20000102",
+		"SUMMARY:Past",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	if _, err := time.LoadLocation("Europe/Berlin"); err != nil {
+		t.Skip("Time zone database not available")
+	}
+
+	got, err := Parse(strings.NewReader(calendar), nil)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	expected := []cachet.Schedule{
+		{
+			Name:        "Database upgrade",
+			Message:     "Expect interruptions, sorry\nThe ops team",
+			Status:      cachet.ScheduleInProgress,
+			ScheduledAt: "2017-10-10 20:00:00",
+			CompletedAt: "2017-10-10 21:30:00",
+		},
+		{
+			Name:        "Past",
+			Status:      cachet.ScheduleComplete,
+			ScheduledAt: "2000-01-01 00:00:00",
+			CompletedAt: "2000-01-02 00:00:00",
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Parse returned %+v, want %+v", got, expected)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	mockData := []string{
+		"BEGIN:VEVENT\r\nSUMMARY:No start\r\nEND:VEVENT",
+		"BEGIN:VEVENT\r\nDTSTART:20171010T220000Z\r\nEND:VEVENT",
+		"BEGIN:VEVENT\r\nSUMMARY:X\r\nDTSTART:tomorrow\r\nEND:VEVENT",
+		"BEGIN:VEVENT\r\nSUMMARY:X\r\nDTSTART:20171010T220000Z\r\nDURATION:2H\r\nEND:VEVENT",
+		"no content line",
+		"BEGIN:VEVENT\r\nSUMMARY:X\r\nDTSTART:20171010T220000Z\r\nRRULE:FREQ=WEEKLY\r\nEND:VEVENT",
+		"BEGIN:VEVENT\r\nSUMMARY:X\r\nDTSTART:20171010T220000Z\r\nRDATE:20171017T220000Z\r\nEND:VEVENT",
+	}
+
+	for _, data := range mockData {
+		if _, err := Parse(strings.NewReader(data), nil); err == nil {
+			t.Errorf("Parse(%q) returned no error. Expected one.", data)
+		}
+	}
+}
+
+func TestEncodeParse(t *testing.T) {
+	schedules := []cachet.Schedule{{
+		Name:        `Back\slash; comma, newline` + "\n",
+		Message:     strings.Repeat("Long message ", 20),
+		Status:      cachet.ScheduleUpcoming,
+		ScheduledAt: "2017-10-10 22:00:00",
+		CompletedAt: "2017-10-11 00:00:00",
+		Components:  []cachet.Component{{ID: 2}},
+	}}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, schedules, nil); err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	got, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, schedules) {
+		t.Errorf("Parse returned %+v, want %+v", got, schedules)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	mockData := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"PT2H", 2 * time.Hour},
+		{"P1DT30M", 24*time.Hour + 30*time.Minute},
+		{"P1W", 7 * 24 * time.Hour},
+		{"PT45S", 45 * time.Second},
+	}
+
+	for _, data := range mockData {
+		got, err := parseDuration(data.value)
+		if err != nil {
+			t.Errorf("parseDuration(%q) returned error: %v", data.value, err)
+		}
+		if got != data.expected {
+			t.Errorf("parseDuration(%q) returned %v, want %v", data.value, got, data.expected)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Package api contains the request bodies and formats of the Cachet API that the cachet package does not cover.
+
+The entities of the cachet package omit empty values in requests.
+The bodies of this package send them, otherwise e.g. upcoming schedules (status 0),
+disabled components or hidden component groups and metrics could not be created.
+*/
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// TimestampLayout is the layout of timestamps in requests to and responses of the Cachet API.
+const TimestampLayout = "2006-01-02 15:04:05"
+
+// Location returns loc or UTC if loc is nil, the default time zone of Cachet instances.
+func Location(loc *time.Location) *time.Location {
+	if loc == nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Now returns now or the current time if now is zero.
+func Now(now time.Time) time.Time {
+	if now.IsZero() {
+		return time.Now()
+	}
+	return now
+}
+
+// ParseTime returns the first valid timestamp of values in the time zone loc or the zero time.
+func ParseTime(loc *time.Location, values ...string) time.Time {
+	for _, v := range values {
+		if t, err := time.ParseInLocation(TimestampLayout, v, loc); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Since returns the duration from start to t or 0 if t is zero or before start.
+func Since(start, t time.Time) time.Duration {
+	if t.IsZero() || t.Before(start) {
+		return 0
+	}
+	return t.Sub(start)
+}
+
+// Less orders entities like Cachet does, by their order first and their ID second.
+func Less(order1, id1, order2, id2 int) bool {
+	if order1 != order2 {
+		return order1 < order2
+	}
+	return id1 < id2
+}
+
+// ComponentGroup is sent to create and update component groups.
+type ComponentGroup struct {
+	Name      string `json:"name"`
+	Order     int    `json:"order"`
+	Collapsed int    `json:"collapsed"`
+	Visible   int    `json:"visible"`
+}
+
+// Component is sent to create and update components.
+// A nil Status keeps the status of updated components.
+type Component struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Link        string `json:"link"`
+	Status      *int   `json:"status,omitempty"`
+	Order       int    `json:"order"`
+	GroupID     int    `json:"group_id"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// Metric is sent to create and update metrics.
+type Metric struct {
+	Name         string `json:"name"`
+	Suffix       string `json:"suffix"`
+	Description  string `json:"description"`
+	DefaultValue int    `json:"default_value"`
+	CalcType     int    `json:"calc_type"`
+	DisplayChart bool   `json:"display_chart"`
+	Places       int    `json:"places"`
+	DefaultView  int    `json:"default_view"`
+	Threshold    int    `json:"threshold"`
+	Order        int    `json:"order"`
+	Visible      int    `json:"visible"`
+}
+
+// Schedule is sent to create schedules.
+type Schedule struct {
+	Name        string             `json:"name"`
+	Message     string             `json:"message"`
+	Status      int                `json:"status"`
+	ScheduledAt string             `json:"scheduled_at"`
+	CompletedAt string             `json:"completed_at,omitempty"`
+	Components  []cachet.Component `json:"components,omitempty"`
+}
+
+// CreateSchedule creates the schedule s.
+func CreateSchedule(client *cachet.Client, s *Schedule) (*cachet.Schedule, error) {
+	v := &struct {
+		Data *cachet.Schedule `json:"data"`
+	}{}
+	if _, err := client.Call("POST", "api/v1/schedules", s, v); err != nil {
+		return nil, err
+	}
+	if v.Data == nil {
+		return nil, fmt.Errorf("Empty response from the Cachet API")
+	}
+	return v.Data, nil
+}
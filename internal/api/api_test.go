@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestCreateSchedule(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client, _ := cachet.NewClient(server.URL, nil)
+
+	mux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Request method: %v, want POST", r.Method)
+		}
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		expected := map[string]interface{}{"name": "Upgrade", "message": "", "status": float64(0), "scheduled_at": "2017-10-10 10:00:00"}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":1,"name":"Upgrade"}}`)
+	})
+
+	got, err := CreateSchedule(client, &Schedule{Name: "Upgrade", Status: cachet.ScheduleUpcoming, ScheduledAt: "2017-10-10 10:00:00"})
+	if err != nil {
+		t.Fatalf("CreateSchedule returned error: %v", err)
+	}
+	if expected := (&cachet.Schedule{ID: 1, Name: "Upgrade"}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("CreateSchedule returned %+v, want %+v", got, expected)
+	}
+}
+
+func TestCreateSchedule_EmptyResponse(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client, _ := cachet.NewClient(server.URL, nil)
+
+	mux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	})
+
+	if _, err := CreateSchedule(client, &Schedule{Name: "Upgrade"}); err == nil {
+		t.Error("CreateSchedule returned no error for an empty response. Expected one.")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+	mockData := []struct {
+		values   []string
+		expected time.Time
+	}{
+		{[]string{"2017-10-10 10:00:00"}, time.Date(2017, 10, 10, 10, 0, 0, 0, loc)},
+		{[]string{"", "invalid", "2017-10-10 10:00:00", "2017-10-11 10:00:00"}, time.Date(2017, 10, 10, 10, 0, 0, 0, loc)},
+		{[]string{"", "invalid"}, time.Time{}},
+		{nil, time.Time{}},
+	}
+
+	for _, data := range mockData {
+		if got := ParseTime(loc, data.values...); !got.Equal(data.expected) {
+			t.Errorf("ParseTime(%q) returned %v, want %v", data.values, got, data.expected)
+		}
+	}
+}
+
+func TestSince(t *testing.T) {
+	start := time.Date(2017, 10, 10, 10, 0, 0, 0, time.UTC)
+	mockData := []struct {
+		t        time.Time
+		expected time.Duration
+	}{
+		{start.Add(90 * time.Minute), 90 * time.Minute},
+		{start, 0},
+		{start.Add(-time.Hour), 0},
+		{time.Time{}, 0},
+	}
+
+	for _, data := range mockData {
+		if got := Since(start, data.t); got != data.expected {
+			t.Errorf("Since(%v) returned %v, want %v", data.t, got, data.expected)
+		}
+	}
+}
+
+func TestLocation(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+	if got := Location(loc); got != loc {
+		t.Errorf("Location returned %v, want %v", got, loc)
+	}
+	if got := Location(nil); got != time.UTC {
+		t.Errorf("Location(nil) returned %v, want UTC", got)
+	}
+}
+
+func TestNow(t *testing.T) {
+	now := time.Date(2017, 10, 10, 10, 0, 0, 0, time.UTC)
+	if got := Now(now); !got.Equal(now) {
+		t.Errorf("Now returned %v, want %v", got, now)
+	}
+	if got := Now(time.Time{}); got.IsZero() {
+		t.Error("Now returned the zero time, want the current time")
+	}
+}
+
+func TestLess(t *testing.T) {
+	mockData := []struct {
+		order1, id1, order2, id2 int
+		expected                 bool
+	}{
+		{1, 2, 2, 1, true},
+		{2, 1, 1, 2, false},
+		{1, 1, 1, 2, true},
+		{1, 2, 1, 1, false},
+		{1, 1, 1, 1, false},
+	}
+
+	for _, data := range mockData {
+		if got := Less(data.order1, data.id1, data.order2, data.id2); got != data.expected {
+			t.Errorf("Less(%d, %d, %d, %d) returned %v, want %v", data.order1, data.id1, data.order2, data.id2, got, data.expected)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Package cachettest provides an in-memory Cachet instance for tests.
+
+The server stores the entities of the collections it is seeded with or that are created through the API,
+e.g. components, incidents, incident updates and schedules. Write requests are recorded along with their decoded bodies:
+
+	s := cachettest.NewServer()
+	defer s.Close()
+	s.Add("components", cachet.Component{ID: 3, Name: "API"})
+
+	// ... exercise s.Client ...
+
+	writes := s.Writes()
+*/
+package cachettest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// filters are the query parameters that filter lists by the field of the same name.
+var filters = []string{"component_id", "name"}
+
+// Write is a write request sent to the server.
+type Write struct {
+	Method string
+	Path   string
+	// Body is the decoded request body. It is nil for requests without body.
+	Body map[string]interface{}
+}
+
+// Server is an in-memory Cachet instance.
+// New entities get the next ID of their collection.
+type Server struct {
+	*httptest.Server
+	// Client is configured to talk to the server.
+	Client *cachet.Client
+
+	mu       sync.Mutex
+	entities map[string][]map[string]interface{}
+	writes   []Write
+	failures map[string]int
+}
+
+// NewServer starts and returns a new server. Call Close when finished.
+func NewServer() *Server {
+	s := &Server{entities: map[string][]map[string]interface{}{}, failures: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.Client, _ = cachet.NewClient(s.URL, nil)
+	return s
+}
+
+// Add adds entities to the collection, e.g. "components" or "incidents/1/updates".
+// Entities without ID get the next ID of the collection.
+func (s *Server) Add(collection string, entities ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range entities {
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic(err)
+		}
+		var e map[string]interface{}
+		if err := json.Unmarshal(b, &e); err != nil {
+			panic(err)
+		}
+		s.create(collection, e)
+	}
+}
+
+// Fail makes the next request with the method to the path fail with an internal server error.
+func (s *Server) Fail(method, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method+" "+path]++
+}
+
+// Writes returns the write requests since the last call.
+func (s *Server) Writes() []Write {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.writes
+	s.writes = nil
+	return w
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.Method + " " + r.URL.Path
+	if s.failures[key] > 0 {
+		s.failures[key]--
+		http.Error(w, "Injected failure", http.StatusInternalServerError)
+		return
+	}
+
+	var body map[string]interface{}
+	if r.Method != "GET" {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(b) > 0 {
+			if err := json.Unmarshal(b, &body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		s.writes = append(s.writes, Write{Method: r.Method, Path: r.URL.Path, Body: body})
+	}
+
+	// The collection is the path without the ID of an entity, e.g. "incidents/1/updates" for "incidents/1/updates/2".
+	collection := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/")
+	id := 0
+	if i := strings.LastIndex(collection, "/"); i >= 0 {
+		if n, err := strconv.Atoi(collection[i+1:]); err == nil {
+			collection, id = collection[:i], n
+		}
+	}
+
+	if id == 0 {
+		switch r.Method {
+		case "GET":
+			s.list(w, r, collection)
+		case "POST":
+			e := map[string]interface{}{}
+			for k, v := range body {
+				e[k] = v
+			}
+			respond(w, http.StatusOK, s.create(collection, e))
+		default:
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	k := s.find(collection, id)
+	if k < 0 {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	e := s.entities[collection][k]
+	switch r.Method {
+	case "GET":
+		respond(w, http.StatusOK, e)
+	case "PUT":
+		for k, v := range body {
+			e[k] = v
+		}
+		respond(w, http.StatusOK, e)
+	case "DELETE":
+		s.entities[collection] = append(s.entities[collection][:k], s.entities[collection][k+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// list writes all entities of the collection that match the filters of the query as a single page.
+func (s *Server) list(w http.ResponseWriter, r *http.Request, collection string) {
+	data := []map[string]interface{}{}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page <= 1 {
+	entities:
+		for _, e := range s.entities[collection] {
+			for _, f := range filters {
+				if v := r.URL.Query().Get(f); len(v) > 0 && fmt.Sprint(e[f]) != v {
+					continue entities
+				}
+			}
+			data = append(data, e)
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"meta": cachet.Meta{Pagination: cachet.Pagination{
+			Total:       len(data),
+			Count:       len(data),
+			PerPage:     len(data),
+			CurrentPage: 1,
+			TotalPages:  1,
+		}},
+		"data": data,
+	})
+}
+
+// create adds the entity to the collection and returns it.
+func (s *Server) create(collection string, e map[string]interface{}) map[string]interface{} {
+	if id, _ := e["id"].(float64); id == 0 {
+		next := 1
+		for _, existing := range s.entities[collection] {
+			if id := int(existing["id"].(float64)); id >= next {
+				next = id + 1
+			}
+		}
+		e["id"] = float64(next)
+	}
+	s.entities[collection] = append(s.entities[collection], e)
+	return e
+}
+
+// find returns the index of the entity with the ID id in the collection or -1.
+func (s *Server) find(collection string, id int) int {
+	for k, e := range s.entities[collection] {
+		if e["id"] == float64(id) {
+			return k
+		}
+	}
+	return -1
+}
+
+func respond(w http.ResponseWriter, code int, e map[string]interface{}) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": e})
+}
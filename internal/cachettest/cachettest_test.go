@@ -0,0 +1,70 @@
+package cachettest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Add("incidents", cachet.Incident{ID: 4, Name: "API down", ComponentID: 3}, cachet.Incident{ID: 5, Name: "Mails delayed"})
+
+	created, _, err := s.Client.Incidents.Create(&cachet.Incident{Name: "Database down", ComponentID: 3, Status: cachet.IncidentStatusInvestigating})
+	if err != nil {
+		t.Fatalf("Incidents.Create returned error: %v", err)
+	}
+	if created.ID != 6 {
+		t.Errorf("Incidents.Create returned ID %d, want 6", created.ID)
+	}
+	if _, _, err := s.Client.Incidents.Update(6, &cachet.Incident{Status: cachet.IncidentStatusFixed}); err != nil {
+		t.Fatalf("Incidents.Update returned error: %v", err)
+	}
+
+	incidents, _, err := s.Client.Incidents.GetAll(&cachet.IncidentsQueryParams{ComponentID: 3})
+	if err != nil {
+		t.Fatalf("Incidents.GetAll returned error: %v", err)
+	}
+	expected := []cachet.Incident{
+		{ID: 4, Name: "API down", ComponentID: 3},
+		{ID: 6, Name: "Database down", ComponentID: 3, Status: cachet.IncidentStatusFixed},
+	}
+	if !reflect.DeepEqual(incidents.Incidents, expected) {
+		t.Errorf("Incidents.GetAll returned %+v, want %+v", incidents.Incidents, expected)
+	}
+
+	writes := []Write{
+		{Method: "POST", Path: "/api/v1/incidents", Body: map[string]interface{}{"name": "Database down", "component_id": float64(3), "status": float64(1)}},
+		{Method: "PUT", Path: "/api/v1/incidents/6", Body: map[string]interface{}{"status": float64(4)}},
+	}
+	if got := s.Writes(); !reflect.DeepEqual(got, writes) {
+		t.Errorf("Writes returned %v, want %v", got, writes)
+	}
+	if got := s.Writes(); got != nil {
+		t.Errorf("Writes returned %v after the last call, want nil", got)
+	}
+}
+
+func TestServer_Fail(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Add("components", cachet.Component{ID: 3, Name: "API"})
+
+	s.Fail("PUT", "/api/v1/components/3")
+	if _, _, err := s.Client.Components.Update(3, &cachet.Component{Status: cachet.ComponentStatusMajorOutage}); err == nil {
+		t.Error("Components.Update returned no error. Expected one.")
+	}
+	c, _, err := s.Client.Components.Update(3, &cachet.Component{Status: cachet.ComponentStatusMajorOutage})
+	if err != nil {
+		t.Fatalf("Components.Update returned error on retry: %v", err)
+	}
+	if c.Status != cachet.ComponentStatusMajorOutage {
+		t.Errorf("Components.Update returned status %d, want %d", c.Status, cachet.ComponentStatusMajorOutage)
+	}
+
+	if _, _, err := s.Client.Components.Get(4); err == nil {
+		t.Error("Components.Get returned no error for an unknown component. Expected one.")
+	}
+}
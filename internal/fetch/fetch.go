@@ -0,0 +1,183 @@
+// Package fetch walks through all pages of the list endpoints of the Cachet API.
+package fetch
+
+import (
+	"fmt"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// hasNextPage returns true if there is a page after the one described by meta.
+// count is the number of entities on the current page. An empty page stops the
+// iteration, even if the pagination is broken.
+func hasNextPage(meta cachet.Meta, count int) bool {
+	return count > 0 && meta.Pagination.CurrentPage < meta.Pagination.TotalPages
+}
+
+// Components returns the components of all pages.
+// filter can be nil.
+func Components(c *cachet.Client, filter *cachet.ComponentsQueryParams) ([]cachet.Component, error) {
+	f := cachet.ComponentsQueryParams{}
+	if filter != nil {
+		f = *filter
+	}
+
+	var all []cachet.Component
+	for page := 1; ; page++ {
+		f.Page = page
+		v, _, err := c.Components.GetAll(&f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, v.Components...)
+		if !hasNextPage(v.Meta, len(v.Components)) {
+			return all, nil
+		}
+	}
+}
+
+// ComponentGroups returns the component groups of all pages.
+// filter can be nil.
+func ComponentGroups(c *cachet.Client, filter *cachet.ComponentGroupsQueryParams) ([]cachet.ComponentGroup, error) {
+	f := cachet.ComponentGroupsQueryParams{}
+	if filter != nil {
+		f = *filter
+	}
+
+	var all []cachet.ComponentGroup
+	for page := 1; ; page++ {
+		f.Page = page
+		v, _, err := c.ComponentGroups.GetAll(&f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, v.ComponentGroups...)
+		if !hasNextPage(v.Meta, len(v.ComponentGroups)) {
+			return all, nil
+		}
+	}
+}
+
+// Incidents returns the incidents of all pages.
+// filter can be nil.
+func Incidents(c *cachet.Client, filter *cachet.IncidentsQueryParams) ([]cachet.Incident, error) {
+	f := cachet.IncidentsQueryParams{}
+	if filter != nil {
+		f = *filter
+	}
+
+	var all []cachet.Incident
+	for page := 1; ; page++ {
+		f.Page = page
+		v, _, err := c.Incidents.GetAll(&f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, v.Incidents...)
+		if !hasNextPage(v.Meta, len(v.Incidents)) {
+			return all, nil
+		}
+	}
+}
+
+// Metrics returns the metrics of all pages.
+// filter can be nil.
+func Metrics(c *cachet.Client, filter *cachet.MetricQueryParams) ([]cachet.Metric, error) {
+	f := cachet.MetricQueryParams{}
+	if filter != nil {
+		f = *filter
+	}
+
+	var all []cachet.Metric
+	for page := 1; ; page++ {
+		f.Page = page
+		v, _, err := c.Metrics.GetAll(&f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, v.Metrics...)
+		if !hasNextPage(v.Meta, len(v.Metrics)) {
+			return all, nil
+		}
+	}
+}
+
+// Schedules returns the schedules of all pages.
+// filter can be nil.
+func Schedules(c *cachet.Client, filter *cachet.SchedulesQueryParams) ([]cachet.Schedule, error) {
+	f := cachet.SchedulesQueryParams{}
+	if filter != nil {
+		f = *filter
+	}
+
+	var all []cachet.Schedule
+	for page := 1; ; page++ {
+		f.Page = page
+		v, _, err := c.Schedules.GetAll(&f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, v.Schedules...)
+		if !hasNextPage(v.Meta, len(v.Schedules)) {
+			return all, nil
+		}
+	}
+}
+
+// Subscribers returns the subscribers of all pages.
+// filter can be nil.
+func Subscribers(c *cachet.Client, filter *cachet.SubscribersQueryParams) ([]cachet.Subscriber, error) {
+	f := cachet.SubscribersQueryParams{}
+	if filter != nil {
+		f = *filter
+	}
+
+	var all []cachet.Subscriber
+	for page := 1; ; page++ {
+		f.Page = page
+		v, _, err := c.Subscribers.GetAll(&f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, v.Subscribers...)
+		if !hasNextPage(v.Meta, len(v.Subscribers)) {
+			return all, nil
+		}
+	}
+}
+
+// IncidentUpdates returns the updates of all pages of the incident with the ID incidentID.
+// In contrast to IncidentUpdatesService.GetAll it does not stop after the first page.
+// Cachet returns the newest updates first.
+func IncidentUpdates(c *cachet.Client, incidentID int) ([]cachet.IncidentUpdate, error) {
+	var all []cachet.IncidentUpdate
+	for page := 1; ; page++ {
+		v := new(cachet.IncidentUpdateResponse)
+		if _, err := c.Call("GET", fmt.Sprintf("api/v1/incidents/%d/updates?page=%d", incidentID, page), nil, v); err != nil {
+			return nil, err
+		}
+		all = append(all, v.IncidentUpdates...)
+		if !hasNextPage(v.Meta, len(v.IncidentUpdates)) {
+			return all, nil
+		}
+	}
+}
+
+// Points returns the points of all pages of the metric with the ID metricID.
+// In contrast to MetricsService.GetPoints it does not stop after the first page.
+func Points(c *cachet.Client, metricID int) ([]cachet.Point, error) {
+	var all []cachet.Point
+	for page := 1; ; page++ {
+		v := &struct {
+			Meta cachet.Meta    `json:"meta"`
+			Data []cachet.Point `json:"data"`
+		}{}
+		if _, err := c.Call("GET", fmt.Sprintf("api/v1/metrics/%d/points?page=%d", metricID, page), nil, v); err != nil {
+			return nil, err
+		}
+		all = append(all, v.Data...)
+		if !hasNextPage(v.Meta, len(v.Data)) {
+			return all, nil
+		}
+	}
+}
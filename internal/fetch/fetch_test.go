@@ -0,0 +1,147 @@
+package fetch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func TestComponents(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Query().Get("group_id"), "2"; got != want {
+			t.Errorf("group_id query param is %q, want %q", got, want)
+		}
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":1,"total_pages":2}},"data":[{"id":1}]}`)
+		case "2":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":2,"total_pages":2}},"data":[{"id":2}]}`)
+		default:
+			t.Errorf("Unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+
+	got, err := Components(testClient, &cachet.ComponentsQueryParams{GroupID: 2})
+	if err != nil {
+		t.Errorf("Components returned error: %v", err)
+	}
+
+	expected := []cachet.Component{{ID: 1}, {ID: 2}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Components returned %+v, want %+v", got, expected)
+	}
+}
+
+func TestIncidents_EmptyPageStops(t *testing.T) {
+	setup()
+	defer teardown()
+
+	calls := 0
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"meta":{"pagination":{"current_page":1,"total_pages":5}},"data":[]}`)
+	})
+
+	got, err := Incidents(testClient, nil)
+	if err != nil {
+		t.Errorf("Incidents returned error: %v", err)
+	}
+	if len(got) != 0 || calls != 1 {
+		t.Errorf("Incidents returned %+v after %d calls, want nothing after 1 call", got, calls)
+	}
+}
+
+func TestMetrics_Error(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	})
+
+	if _, err := Metrics(testClient, nil); err == nil {
+		t.Error("Metrics returned no error. Expected one.")
+	}
+}
+
+func TestIncidentUpdates(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents/3/updates", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":1,"total_pages":2}},"data":[{"id":2}]}`)
+		case "2":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":2,"total_pages":2}},"data":[{"id":1}]}`)
+		default:
+			t.Errorf("Unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+
+	got, err := IncidentUpdates(testClient, 3)
+	if err != nil {
+		t.Errorf("IncidentUpdates returned error: %v", err)
+	}
+
+	expected := []cachet.IncidentUpdate{{ID: 2}, {ID: 1}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("IncidentUpdates returned %+v, want %+v", got, expected)
+	}
+}
+
+func TestPoints(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/metrics/5/points", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":1,"total_pages":2}},"data":[{"id":1,"value":10}]}`)
+		case "2":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":2,"total_pages":2}},"data":[{"id":2,"value":20}]}`)
+		default:
+			t.Errorf("Unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+
+	got, err := Points(testClient, 5)
+	if err != nil {
+		t.Errorf("Points returned error: %v", err)
+	}
+
+	expected := []cachet.Point{{ID: 1, Value: 10}, {ID: 2, Value: 20}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Points returned %+v, want %+v", got, expected)
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Package janitor resolves stale incidents.
+
+Incidents opened by automation are sometimes forgotten, e.g. in the status watching.
+A Janitor scans all open incidents. Incidents without an update within MaxAge get a
+closing update with the status fixed, their component is restored to operational
+and they are optionally un-stickied:
+
+	j, err := janitor.NewJanitor(client, &janitor.Options{
+		MaxAge:  48 * time.Hour,
+		Unstick: true,
+	})
+
+	err = j.Run(ctx, time.Hour, func(err error) { log.Print(err) })
+*/
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/dedup"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// DefaultMessage is the message of the closing update.
+const DefaultMessage = "This incident was resolved automatically because there were no updates for {{age}}."
+
+// Options configures the janitor.
+type Options struct {
+	// MaxAge is the time without update after which an incident is stale. It is required.
+	MaxAge time.Duration
+	// Statuses limits the janitor to open incidents with these statuses. All open incidents are scanned by default.
+	Statuses []int
+	// Message overrides DefaultMessage. {{age}} is replaced by MaxAge.
+	Message string
+	// Unstick removes the sticky flag of resolved incidents.
+	Unstick bool
+	// DryRun only reports stale incidents without changing them.
+	DryRun bool
+	// Location is the time zone of the Cachet instance. It defaults to UTC.
+	Location *time.Location
+}
+
+// Janitor resolves stale incidents.
+type Janitor struct {
+	client  *cachet.Client
+	options Options
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+// SweepError is returned if some stale incidents could not be resolved.
+type SweepError struct {
+	// Errors maps the IDs of the failed incidents to their errors.
+	Errors map[int]error
+}
+
+func (e *SweepError) Error() string {
+	ids := make([]int, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	msgs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		msgs = append(msgs, fmt.Sprintf("%d: %v", id, e.Errors[id]))
+	}
+	return fmt.Sprintf("Failed to resolve %d incident(s): %s", len(ids), strings.Join(msgs, "; "))
+}
+
+// incidentBody is sent to resolve incidents.
+// In contrast to cachet.Incident it can remove the sticky flag.
+type incidentBody struct {
+	Status   int   `json:"status"`
+	Stickied *bool `json:"stickied,omitempty"`
+}
+
+// NewJanitor returns a new janitor.
+func NewJanitor(client *cachet.Client, o *Options) (*Janitor, error) {
+	if o == nil || o.MaxAge <= 0 {
+		return nil, fmt.Errorf("The janitor needs a maximum age of incidents")
+	}
+	j := &Janitor{client: client, options: *o, now: time.Now}
+	if len(j.options.Message) == 0 {
+		j.options.Message = DefaultMessage
+	}
+	if j.options.Location == nil {
+		j.options.Location = time.UTC
+	}
+	return j, nil
+}
+
+// Sweep resolves all stale incidents and returns them.
+// Incidents that could not be resolved are reported as *SweepError and retried with the next sweep.
+func (j *Janitor) Sweep() ([]cachet.Incident, error) {
+	incidents, err := fetch.Incidents(j.client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := j.now()
+	var open, stale []cachet.Incident
+	for _, i := range incidents {
+		if dedup.Resolved(&i) {
+			continue
+		}
+		open = append(open, i)
+		if !j.watched(&i) {
+			continue
+		}
+
+		last, err := j.lastActivity(&i)
+		if err != nil {
+			return nil, err
+		}
+		if now.Sub(last) >= j.options.MaxAge {
+			stale = append(stale, i)
+		}
+	}
+
+	if j.options.DryRun {
+		return stale, nil
+	}
+
+	// Components of incidents that stay open are not restored.
+	staleIDs := map[int]bool{}
+	for _, i := range stale {
+		staleIDs[i.ID] = true
+	}
+	affected := map[int]bool{}
+	for _, i := range open {
+		if !staleIDs[i.ID] && i.ComponentID > 0 {
+			affected[i.ComponentID] = true
+		}
+	}
+
+	var done []cachet.Incident
+	errors := map[int]error{}
+	for _, i := range stale {
+		if err := j.resolve(&i, !affected[i.ComponentID]); err != nil {
+			errors[i.ID] = err
+			continue
+		}
+		done = append(done, i)
+	}
+
+	if len(errors) > 0 {
+		return done, &SweepError{Errors: errors}
+	}
+	return done, nil
+}
+
+// Run calls Sweep every interval until the context is done.
+// Errors of single sweeps are passed to the optional onError.
+func (j *Janitor) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.Sweep(); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// watched reports whether the status of the incident is scanned.
+func (j *Janitor) watched(i *cachet.Incident) bool {
+	if len(j.options.Statuses) == 0 {
+		return true
+	}
+	status := dedup.CurrentStatus(i)
+	for _, s := range j.options.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// lastActivity returns the time of the latest update or, without updates, of the incident itself.
+func (j *Janitor) lastActivity(i *cachet.Incident) (time.Time, error) {
+	last := api.ParseTime(j.options.Location, i.OccurredAt, i.CreatedAt)
+	if created := api.ParseTime(j.options.Location, i.CreatedAt); created.After(last) {
+		last = created
+	}
+
+	updates, _, err := j.client.IncidentUpdates.GetAll(i.ID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, u := range updates.IncidentUpdates {
+		if t := api.ParseTime(j.options.Location, u.CreatedAt); t.After(last) {
+			last = t
+		}
+	}
+	return last, nil
+}
+
+// resolve posts the closing update, fixes the incident and optionally restores its component.
+func (j *Janitor) resolve(i *cachet.Incident, restore bool) error {
+	update := &cachet.IncidentUpdate{
+		Status:  cachet.IncidentStatusFixed,
+		Message: strings.Replace(j.options.Message, "{{age}}", j.options.MaxAge.String(), -1),
+	}
+	if restore && i.ComponentID > 0 {
+		update.ComponentStatus = cachet.ComponentStatusOperational
+	}
+	if _, _, err := j.client.IncidentUpdates.Create(i.ID, update); err != nil {
+		return fmt.Errorf("Failed to add the closing update: %v", err)
+	}
+
+	body := &incidentBody{Status: cachet.IncidentStatusFixed}
+	if j.options.Unstick && i.Stickied {
+		unstick := false
+		body.Stickied = &unstick
+	}
+	u := fmt.Sprintf("api/v1/incidents/%d", i.ID)
+	if _, err := j.client.Call("PUT", u, body, &struct {
+		Data *cachet.Incident `json:"data"`
+	}{}); err != nil {
+		return fmt.Errorf("Failed to fix the incident: %v", err)
+	}
+
+	if restore && i.ComponentID > 0 {
+		if _, _, err := j.client.Components.Update(i.ComponentID, &cachet.Component{Status: cachet.ComponentStatusOperational}); err != nil {
+			return fmt.Errorf("Failed to restore component %d: %v", i.ComponentID, err)
+		}
+	}
+	return nil
+}
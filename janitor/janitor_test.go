@@ -0,0 +1,179 @@
+package janitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/cachettest"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+// handleInstance serves open incidents and records all write requests with their bodies.
+//
+// Incident 1 is stale and stickied, incident 2 got a recent update, incident 3 is fixed,
+// incident 4 is stale, but its component is also affected by incident 2.
+func handleInstance(t *testing.T) *[]cachettest.Write {
+	var mu sync.Mutex
+	var writes []cachettest.Write
+	record := func(r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		writes = append(writes, cachettest.Write{Method: r.Method, Path: r.URL.Path, Body: body})
+		mu.Unlock()
+	}
+
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"id":1,"name":"API down","status":3,"component_id":5,"stickied":true,"created_at":"2017-10-07 10:00:00"},
+			{"id":2,"name":"Slow","status":1,"component_id":6,"created_at":"2017-10-07 10:00:00"},
+			{"id":3,"name":"Fixed","status":1,"latest_status":4,"component_id":5,"created_at":"2017-10-01 10:00:00"},
+			{"id":4,"name":"Slower","status":2,"component_id":6,"created_at":"2017-10-07 10:00:00"}
+		]}`)
+	})
+	for id, updates := range map[int]string{
+		1: `[{"id":1,"created_at":"2017-10-08 09:00:00"}]`,
+		2: `[{"id":2,"created_at":"2017-10-09 23:00:00"}]`,
+		4: `[]`,
+	} {
+		updates := updates
+		testMux.HandleFunc(fmt.Sprintf("/api/v1/incidents/%d/updates", id), func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				record(r)
+				fmt.Fprint(w, `{"data":{"id":9}}`)
+				return
+			}
+			fmt.Fprintf(w, `{"data":%s}`, updates)
+		})
+		testMux.HandleFunc(fmt.Sprintf("/api/v1/incidents/%d", id), func(w http.ResponseWriter, r *http.Request) {
+			record(r)
+			fmt.Fprint(w, `{"data":{"id":1}}`)
+		})
+	}
+	testMux.HandleFunc("/api/v1/incidents/3/updates", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Updates of a fixed incident were requested")
+	})
+	testMux.HandleFunc("/api/v1/components/", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		fmt.Fprint(w, `{"data":{"id":5}}`)
+	})
+	return &writes
+}
+
+func testNow() time.Time {
+	return time.Date(2017, 10, 10, 10, 0, 0, 0, time.UTC)
+}
+
+func TestNewJanitor(t *testing.T) {
+	if _, err := NewJanitor(nil, &Options{}); err == nil {
+		t.Error("NewJanitor returned no error without maximum age")
+	}
+}
+
+func TestJanitor_Sweep(t *testing.T) {
+	setup()
+	defer teardown()
+	writes := handleInstance(t)
+
+	j, _ := NewJanitor(testClient, &Options{MaxAge: 48 * time.Hour, Unstick: true})
+	j.now = testNow
+
+	resolved, err := j.Sweep()
+	if err != nil {
+		t.Fatalf("Sweep returned error: %v", err)
+	}
+
+	var ids []int
+	for _, i := range resolved {
+		ids = append(ids, i.ID)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 4}) {
+		t.Errorf("Sweep resolved %v, want [1 4]", ids)
+	}
+
+	message := "This incident was resolved automatically because there were no updates for 48h0m0s."
+	expected := []cachettest.Write{
+		{Method: "POST", Path: "/api/v1/incidents/1/updates", Body: map[string]interface{}{"message": message, "status": float64(4), "component_status": float64(1)}},
+		{Method: "PUT", Path: "/api/v1/incidents/1", Body: map[string]interface{}{"status": float64(4), "stickied": false}},
+		{Method: "PUT", Path: "/api/v1/components/5", Body: map[string]interface{}{"status": float64(1)}},
+		{Method: "POST", Path: "/api/v1/incidents/4/updates", Body: map[string]interface{}{"message": message, "status": float64(4)}},
+		{Method: "PUT", Path: "/api/v1/incidents/4", Body: map[string]interface{}{"status": float64(4)}},
+	}
+	if !reflect.DeepEqual(*writes, expected) {
+		t.Errorf("Sweep sent\n%v\nwant\n%v", *writes, expected)
+	}
+}
+
+func TestJanitor_Sweep_Statuses(t *testing.T) {
+	setup()
+	defer teardown()
+	writes := handleInstance(t)
+
+	j, _ := NewJanitor(testClient, &Options{MaxAge: 48 * time.Hour, Statuses: []int{cachet.IncidentStatusWatching}, DryRun: true})
+	j.now = testNow
+
+	stale, err := j.Sweep()
+	if err != nil {
+		t.Fatalf("Sweep returned error: %v", err)
+	}
+	if len(stale) != 1 || stale[0].ID != 1 {
+		t.Errorf("Sweep returned %+v, want incident 1", stale)
+	}
+	if len(*writes) > 0 {
+		t.Errorf("Sweep sent %v in a dry run", *writes)
+	}
+}
+
+func TestJanitor_Sweep_Error(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":1,"status":3,"created_at":"2017-10-01 10:00:00"}]}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents/1/updates", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			http.Error(w, "Boom", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"data":[]}`)
+	})
+
+	j, _ := NewJanitor(testClient, &Options{MaxAge: time.Hour})
+	j.now = testNow
+
+	_, err := j.Sweep()
+	serr, ok := err.(*SweepError)
+	if !ok || serr.Errors[1] == nil {
+		t.Fatalf("Sweep returned %v, want a *SweepError for incident 1", err)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Package lifecycle drives incidents through their lifecycle.
+
+An incident moves from investigating over identified and watching to fixed:
+
+	incident, err := lifecycle.Open(client, &cachet.Incident{
+		Name:        "API is down",
+		Message:     "We are looking into it.",
+		Visible:     cachet.IncidentVisibilityPublic,
+		ComponentID: 2,
+	}, cachet.ComponentStatusMajorOutage)
+
+	err = incident.Identify("The database is overloaded.", cachet.ComponentStatusMajorOutage)
+	err = incident.Watch("We added more capacity.", cachet.ComponentStatusPerformanceIssues)
+	err = incident.Fix("Everything is back to normal.")
+
+Every step posts an incident update, updates the status of the incident and
+sets the status of the affected component.
+Steps may be repeated to post further updates, but not skipped.
+Fixed incidents only accept Reopen.
+*/
+package lifecycle
+
+import (
+	"fmt"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// transitions contains the statuses an incident may move to from a status.
+// Watching may fall back to identified if the fix did not work.
+// Fixed incidents can only be reopened, see Incident.Reopen.
+var transitions = map[int][]int{
+	cachet.IncidentStatusInvestigating: {cachet.IncidentStatusInvestigating, cachet.IncidentStatusIdentified},
+	cachet.IncidentStatusIdentified:    {cachet.IncidentStatusIdentified, cachet.IncidentStatusWatching},
+	cachet.IncidentStatusWatching:      {cachet.IncidentStatusWatching, cachet.IncidentStatusIdentified, cachet.IncidentStatusFixed},
+}
+
+// statusNames contains readable names of the incident statuses used in errors.
+var statusNames = map[int]string{
+	cachet.IncidentStatusScheduled:     "scheduled",
+	cachet.IncidentStatusInvestigating: "investigating",
+	cachet.IncidentStatusIdentified:    "identified",
+	cachet.IncidentStatusWatching:      "watching",
+	cachet.IncidentStatusFixed:         "fixed",
+}
+
+// TransitionError is returned if an incident can not move to a status.
+type TransitionError struct {
+	IncidentID int
+	From, To   int
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("Incident %d can not move from %s to %s", e.IncidentID, statusName(e.From), statusName(e.To))
+}
+
+// CanTransition reports whether an incident may move from the status from to the status to.
+func CanTransition(from, to int) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Incident is an incident driven through its lifecycle.
+type Incident struct {
+	client   *cachet.Client
+	incident cachet.Incident
+}
+
+// Open creates a new incident.
+// Every incident starts as investigating, the status of i has to be empty or investigating.
+// componentStatus is set on the affected component, 0 leaves the component unchanged.
+func Open(client *cachet.Client, i *cachet.Incident, componentStatus int) (*Incident, error) {
+	incident := *i
+	if incident.Status == cachet.IncidentStatusScheduled {
+		incident.Status = cachet.IncidentStatusInvestigating
+	}
+	if incident.Status != cachet.IncidentStatusInvestigating {
+		return nil, fmt.Errorf("Incidents can not be opened as %s, only as investigating", statusName(incident.Status))
+	}
+	if incident.ComponentID > 0 {
+		incident.ComponentStatus = componentStatus
+	}
+
+	created, _, err := client.Incidents.Create(&incident)
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		return nil, fmt.Errorf("Empty response from the Cachet API")
+	}
+
+	// Fill in what the API does not return.
+	if created.Status == 0 {
+		created.Status = incident.Status
+	}
+	if created.ComponentID == 0 {
+		created.ComponentID = incident.ComponentID
+	}
+	return &Incident{client: client, incident: *created}, nil
+}
+
+// Load returns the lifecycle of an existing incident.
+func Load(client *cachet.Client, id int) (*Incident, error) {
+	i, _, err := client.Incidents.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if i == nil {
+		return nil, fmt.Errorf("Empty response from the Cachet API")
+	}
+
+	// The latest status reflects the updates of the incident.
+	if i.LatestStatus > 0 {
+		i.Status = i.LatestStatus
+	}
+	return &Incident{client: client, incident: *i}, nil
+}
+
+// ID returns the ID of the incident.
+func (i *Incident) ID() int {
+	return i.incident.ID
+}
+
+// Status returns the current status of the incident.
+func (i *Incident) Status() int {
+	return i.incident.Status
+}
+
+// Incident returns a copy of the incident.
+func (i *Incident) Incident() cachet.Incident {
+	return i.incident
+}
+
+// Identify moves the incident to identified.
+func (i *Incident) Identify(message string, componentStatus int) error {
+	return i.Transition(cachet.IncidentStatusIdentified, message, componentStatus)
+}
+
+// Watch moves the incident to watching.
+func (i *Incident) Watch(message string, componentStatus int) error {
+	return i.Transition(cachet.IncidentStatusWatching, message, componentStatus)
+}
+
+// Fix moves the incident to fixed and the affected component back to operational.
+func (i *Incident) Fix(message string) error {
+	return i.Transition(cachet.IncidentStatusFixed, message, cachet.ComponentStatusOperational)
+}
+
+// Update posts an update without changing the status of the incident.
+func (i *Incident) Update(message string, componentStatus int) error {
+	return i.Transition(i.incident.Status, message, componentStatus)
+}
+
+// Reopen moves a fixed incident back to investigating.
+func (i *Incident) Reopen(message string, componentStatus int) error {
+	if i.incident.Status != cachet.IncidentStatusFixed {
+		return &TransitionError{IncidentID: i.incident.ID, From: i.incident.Status, To: cachet.IncidentStatusInvestigating}
+	}
+	return i.step(cachet.IncidentStatusInvestigating, message, componentStatus)
+}
+
+// Transition moves the incident to status.
+// It posts an incident update with the message and sets componentStatus on the affected component.
+// A componentStatus of 0 leaves the component unchanged.
+func (i *Incident) Transition(status int, message string, componentStatus int) error {
+	if !CanTransition(i.incident.Status, status) {
+		return &TransitionError{IncidentID: i.incident.ID, From: i.incident.Status, To: status}
+	}
+	return i.step(status, message, componentStatus)
+}
+
+// step moves the incident to status without validating the transition.
+// The status of the incident is changed before the update is posted.
+// If posting the update fails, i keeps the previous status and the step can be retried.
+func (i *Incident) step(status int, message string, componentStatus int) error {
+	if status != i.incident.Status {
+		if _, _, err := i.client.Incidents.Update(i.incident.ID, &cachet.Incident{Status: status}); err != nil {
+			return fmt.Errorf("Failed to update status of incident %d: %v", i.incident.ID, err)
+		}
+	}
+
+	update := &cachet.IncidentUpdate{Status: status, Message: message}
+	if i.incident.ComponentID > 0 && componentStatus > 0 {
+		update.ComponentID = i.incident.ComponentID
+		update.ComponentStatus = componentStatus
+	}
+	if _, _, err := i.client.IncidentUpdates.Create(i.incident.ID, update); err != nil {
+		return fmt.Errorf("Failed to post update of incident %d (its status is already %s): %v", i.incident.ID, statusName(status), err)
+	}
+	i.incident.Status = status
+
+	if update.ComponentID > 0 {
+		if _, _, err := i.client.Components.Update(update.ComponentID, &cachet.Component{Status: componentStatus}); err != nil {
+			return fmt.Errorf("Failed to update status of component %d: %v", update.ComponentID, err)
+		}
+		i.incident.ComponentStatus = componentStatus
+	}
+	return nil
+}
+
+// statusName returns the readable name of an incident status.
+func statusName(status int) string {
+	if name, ok := statusNames[status]; ok {
+		return name
+	}
+	return fmt.Sprintf("status %d", status)
+}
@@ -0,0 +1,224 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func testMethod(t *testing.T, r *http.Request, want string) {
+	if got := r.Method; got != want {
+		t.Errorf("Request method: %v, want %v", got, want)
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	mockData := []struct {
+		from, to int
+		expected bool
+	}{
+		{cachet.IncidentStatusInvestigating, cachet.IncidentStatusIdentified, true},
+		{cachet.IncidentStatusInvestigating, cachet.IncidentStatusInvestigating, true},
+		{cachet.IncidentStatusInvestigating, cachet.IncidentStatusFixed, false},
+		{cachet.IncidentStatusIdentified, cachet.IncidentStatusWatching, true},
+		{cachet.IncidentStatusIdentified, cachet.IncidentStatusFixed, false},
+		{cachet.IncidentStatusWatching, cachet.IncidentStatusIdentified, true},
+		{cachet.IncidentStatusWatching, cachet.IncidentStatusFixed, true},
+		{cachet.IncidentStatusFixed, cachet.IncidentStatusInvestigating, false},
+		{cachet.IncidentStatusFixed, cachet.IncidentStatusFixed, false},
+		{cachet.IncidentStatusScheduled, cachet.IncidentStatusInvestigating, false},
+	}
+
+	for _, data := range mockData {
+		if got := CanTransition(data.from, data.to); got != data.expected {
+			t.Errorf("CanTransition(%d, %d) returned %v, want %v", data.from, data.to, got, data.expected)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := &cachet.Incident{}
+		json.NewDecoder(r.Body).Decode(body)
+		expected := &cachet.Incident{Name: "Outage", Status: cachet.IncidentStatusInvestigating, ComponentID: 2, ComponentStatus: cachet.ComponentStatusMajorOutage}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":1,"name":"Outage","status":1,"component_id":2}}`)
+	})
+
+	i, err := Open(testClient, &cachet.Incident{Name: "Outage", ComponentID: 2}, cachet.ComponentStatusMajorOutage)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	if i.ID() != 1 || i.Status() != cachet.IncidentStatusInvestigating {
+		t.Errorf("Open returned incident %d with status %d, want incident 1 investigating", i.ID(), i.Status())
+	}
+
+	for _, status := range []int{cachet.IncidentStatusIdentified, cachet.IncidentStatusWatching, cachet.IncidentStatusFixed} {
+		if _, err := Open(testClient, &cachet.Incident{Name: "Outage", Status: status}, 0); err == nil {
+			t.Errorf("Open returned no error for an incident with status %d. Expected one.", status)
+		}
+	}
+}
+
+func TestIncident_Lifecycle(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var requests []string
+
+	testMux.HandleFunc("/api/v1/incidents/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":{"id":1,"name":"Outage","status":1,"latest_status":2,"component_id":2}}`)
+			return
+		}
+		testMethod(t, r, "PUT")
+		body := &cachet.Incident{}
+		json.NewDecoder(r.Body).Decode(body)
+		requests = append(requests, fmt.Sprintf("incident %d", body.Status))
+		fmt.Fprint(w, `{"data":{"id":1}}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents/1/updates", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := &cachet.IncidentUpdate{}
+		json.NewDecoder(r.Body).Decode(body)
+		requests = append(requests, fmt.Sprintf("update %d %s (component %d: %d)", body.Status, body.Message, body.ComponentID, body.ComponentStatus))
+		fmt.Fprint(w, `{"data":{"id":1}}`)
+	})
+	testMux.HandleFunc("/api/v1/components/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		body := &cachet.Component{}
+		json.NewDecoder(r.Body).Decode(body)
+		requests = append(requests, fmt.Sprintf("component %d", body.Status))
+		fmt.Fprint(w, `{"data":{"id":2}}`)
+	})
+
+	i, err := Load(testClient, 1)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if i.Status() != cachet.IncidentStatusIdentified {
+		t.Errorf("Load returned status %d, want the latest status %d", i.Status(), cachet.IncidentStatusIdentified)
+	}
+
+	if err := i.Fix("Skipped"); err == nil {
+		t.Error("Fix returned no error for an identified incident. Expected one.")
+	}
+	if err := i.Watch("Deployed", cachet.ComponentStatusPerformanceIssues); err != nil {
+		t.Fatalf("Watch returned error: %v", err)
+	}
+	if err := i.Update("Still watching", 0); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if err := i.Fix("Resolved"); err != nil {
+		t.Fatalf("Fix returned error: %v", err)
+	}
+	if err := i.Update("Too late", 0); err == nil {
+		t.Error("Update returned no error for a fixed incident. Expected one.")
+	}
+	if err := i.Reopen("It is back", cachet.ComponentStatusMajorOutage); err != nil {
+		t.Fatalf("Reopen returned error: %v", err)
+	}
+	if err := i.Reopen("Again", 0); err == nil {
+		t.Error("Reopen returned no error for an open incident. Expected one.")
+	}
+
+	expected := []string{
+		"incident 3",
+		"update 3 Deployed (component 2: 2)",
+		"component 2",
+		"update 3 Still watching (component 0: 0)",
+		"incident 4",
+		"update 4 Resolved (component 2: 1)",
+		"component 1",
+		"incident 1",
+		"update 1 It is back (component 2: 4)",
+		"component 4",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Lifecycle made the requests %v, want %v", requests, expected)
+	}
+}
+
+func TestTransitionError_Error(t *testing.T) {
+	err := &TransitionError{IncidentID: 3, From: cachet.IncidentStatusFixed, To: cachet.IncidentStatusIdentified}
+
+	expected := "Incident 3 can not move from fixed to identified"
+	if got := err.Error(); got != expected {
+		t.Errorf("TransitionError.Error returned %q, want %q", got, expected)
+	}
+}
+
+func TestIncident_FailedUpdate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":{"id":1,"name":"Outage","status":1}}`)
+			return
+		}
+		testMethod(t, r, "PUT")
+		fmt.Fprint(w, `{"data":{"id":1}}`)
+	})
+	fail := true
+	testMux.HandleFunc("/api/v1/incidents/1/updates", func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"id":1}}`)
+	})
+
+	i, err := Load(testClient, 1)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if err := i.Identify("Found it", 0); err == nil {
+		t.Fatal("Identify returned no error. Expected one.")
+	}
+	if i.Status() != cachet.IncidentStatusInvestigating {
+		t.Errorf("Identify changed the status to %d after a failed update, want %d", i.Status(), cachet.IncidentStatusInvestigating)
+	}
+
+	fail = false
+	if err := i.Identify("Found it", 0); err != nil {
+		t.Errorf("Identify returned error on retry: %v", err)
+	}
+	if i.Status() != cachet.IncidentStatusIdentified {
+		t.Errorf("Identify changed the status to %d, want %d", i.Status(), cachet.IncidentStatusIdentified)
+	}
+}
@@ -0,0 +1,355 @@
+/*
+Package mailgate turns emails into incidents.
+
+A Gateway is a minimal SMTP server accepting messages to addresses like
+api@status.local. The local part of the address selects the component,
+the subject and body of the message become an incident or an update of the open incident:
+
+	g, err := mailgate.NewGateway(client, &mailgate.Options{
+		Domain:     "status.local",
+		Components: map[string]int{"api": 3, "website": 4},
+		Allow:      []string{"noc@example.com", "@ops.example.com"},
+	})
+	err = g.ListenAndServe(":2525")
+
+Tags in the subject set the status, e.g. "[identified] [major] Database cluster down":
+
+	[investigating], [identified], [watching], [fixed] or [resolved] set the incident status.
+	[major], [partial], [degraded] or [operational] set the component status.
+
+A message to a component with an open incident adds an update to it.
+[resolved] fixes the open incident and sets the component to operational.
+
+Only the senders of the allowlist may report incidents. The sender is checked for
+the envelope and the From header. SMTP has no authentication, so run the gateway
+in a trusted network only.
+*/
+package mailgate
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/dedup"
+)
+
+// DefaultMaxSize is the maximum size of messages in bytes.
+const DefaultMaxSize = 1 << 20
+
+// incidentTags map subject tags to incident statuses.
+var incidentTags = map[string]int{
+	"investigating": cachet.IncidentStatusInvestigating,
+	"identified":    cachet.IncidentStatusIdentified,
+	"watching":      cachet.IncidentStatusWatching,
+	"fixed":         cachet.IncidentStatusFixed,
+	"resolved":      cachet.IncidentStatusFixed,
+}
+
+// componentTags map subject tags to component statuses.
+var componentTags = map[string]int{
+	"operational": cachet.ComponentStatusOperational,
+	"degraded":    cachet.ComponentStatusPerformanceIssues,
+	"partial":     cachet.ComponentStatusPartialOutage,
+	"major":       cachet.ComponentStatusMajorOutage,
+}
+
+// tag matches a tag in brackets.
+var tag = regexp.MustCompile(`\[([^\]]*)\]`)
+
+// replyPrefix matches reply and forward prefixes of subjects.
+var replyPrefix = regexp.MustCompile(`(?i)^((re|fw|fwd|aw|wg):\s*)+`)
+
+// Options configures the gateway.
+type Options struct {
+	// Domain is the domain of the recipient addresses. It is required.
+	Domain string
+	// Components maps local parts of recipient addresses to component IDs.
+	// Numeric local parts missing here are used as component ID.
+	Components map[string]int
+	// Allow lists the allowed senders. Entries starting with @ allow a whole domain.
+	Allow []string
+	// ComponentStatus is the component status of new incidents without tag.
+	// It defaults to cachet.ComponentStatusPartialOutage.
+	ComponentStatus int
+	// Visible is the visibility of new incidents. It defaults to cachet.IncidentVisibilityPublic.
+	Visible int
+	// MaxSize is the maximum size of messages in bytes. It defaults to DefaultMaxSize.
+	MaxSize int64
+	// Ensurer finds and creates the incidents of components. Share one Ensurer between all
+	// integrations of an instance, so their changes to the same component are serialized.
+	// It defaults to an Ensurer of the gateway only.
+	Ensurer *dedup.Ensurer
+}
+
+// Report is a parsed message.
+type Report struct {
+	Name    string
+	Message string
+	// Status is the incident status of the tags or 0.
+	Status int
+	// ComponentStatus is the component status of the tags or 0.
+	ComponentStatus int
+}
+
+// Gateway applies emails as incidents.
+type Gateway struct {
+	client  *cachet.Client
+	options Options
+	ensurer *dedup.Ensurer
+}
+
+// NewGateway returns a new gateway.
+func NewGateway(client *cachet.Client, o *Options) (*Gateway, error) {
+	if o == nil || len(o.Domain) == 0 {
+		return nil, fmt.Errorf("The mail gateway needs a domain")
+	}
+	if len(o.Allow) == 0 {
+		return nil, fmt.Errorf("The mail gateway needs an allowlist of senders")
+	}
+
+	g := &Gateway{client: client, options: *o, ensurer: o.Ensurer}
+	if g.ensurer == nil {
+		g.ensurer = dedup.NewEnsurer(client, nil)
+	}
+	if g.options.ComponentStatus == 0 {
+		g.options.ComponentStatus = cachet.ComponentStatusPartialOutage
+	}
+	if g.options.Visible == 0 {
+		g.options.Visible = cachet.IncidentVisibilityPublic
+	}
+	if g.options.MaxSize == 0 {
+		g.options.MaxSize = DefaultMaxSize
+	}
+	return g, nil
+}
+
+// Allowed reports whether the sender address is on the allowlist.
+func (g *Gateway) Allowed(sender string) bool {
+	addr, err := mail.ParseAddress(sender)
+	if err != nil {
+		return false
+	}
+	address := strings.ToLower(addr.Address)
+	for _, a := range g.options.Allow {
+		a = strings.ToLower(a)
+		if address == a || (strings.HasPrefix(a, "@") && strings.HasSuffix(address, a)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Component returns the component ID of a recipient address.
+func (g *Gateway) Component(recipient string) (int, bool) {
+	addr, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return 0, false
+	}
+	at := strings.LastIndex(addr.Address, "@")
+	if at < 0 || !strings.EqualFold(addr.Address[at+1:], g.options.Domain) {
+		return 0, false
+	}
+
+	local := strings.ToLower(addr.Address[:at])
+	if id, ok := g.options.Components[local]; ok {
+		return id, true
+	}
+	id, err := strconv.Atoi(local)
+	return id, err == nil && id > 0
+}
+
+// Deliver applies the message read from r to the components of the recipients.
+// The sender has to be allowed, recipients without component are ignored.
+func (g *Gateway) Deliver(from string, to []string, r io.Reader) error {
+	if !g.Allowed(from) {
+		return fmt.Errorf("Sender %q is not allowed", from)
+	}
+
+	msg, err := mail.ReadMessage(io.LimitReader(r, g.options.MaxSize))
+	if err != nil {
+		return fmt.Errorf("Invalid message: %v", err)
+	}
+	if !g.Allowed(msg.Header.Get("From")) {
+		return fmt.Errorf("Sender %q is not allowed", msg.Header.Get("From"))
+	}
+
+	report, err := Parse(msg)
+	if err != nil {
+		return err
+	}
+
+	applied := map[int]bool{}
+	for _, rcpt := range to {
+		id, ok := g.Component(rcpt)
+		if !ok || applied[id] {
+			continue
+		}
+		applied[id] = true
+		if err := g.Apply(id, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply applies the report to the component with the ID id.
+// Reports for the same component are applied one after another, so concurrent messages can not open duplicate incidents.
+func (g *Gateway) Apply(id int, r *Report) error {
+	return g.ensurer.WithOpenIncident(id, func(open *cachet.Incident) error {
+		return g.apply(id, r, open)
+	})
+}
+
+// apply applies the report to the component with the ID id and its open incident.
+func (g *Gateway) apply(id int, r *Report, open *cachet.Incident) error {
+	componentStatus := r.ComponentStatus
+	switch {
+	case r.Status == cachet.IncidentStatusFixed:
+		if open == nil {
+			return nil
+		}
+		if componentStatus == 0 {
+			componentStatus = cachet.ComponentStatusOperational
+		}
+		if err := g.update(open, cachet.IncidentStatusFixed, r.Message, componentStatus); err != nil {
+			return err
+		}
+
+	case open != nil:
+		status := r.Status
+		if status == 0 {
+			status = dedup.CurrentStatus(open)
+		}
+		if err := g.update(open, status, r.Message, componentStatus); err != nil {
+			return err
+		}
+
+	default:
+		i := &cachet.Incident{
+			Name:            r.Name,
+			Message:         r.Message,
+			Status:          r.Status,
+			ComponentID:     id,
+			ComponentStatus: componentStatus,
+			Visible:         g.options.Visible,
+		}
+		if i.Status == 0 {
+			i.Status = cachet.IncidentStatusInvestigating
+		}
+		if i.ComponentStatus == 0 {
+			i.ComponentStatus = g.options.ComponentStatus
+			componentStatus = i.ComponentStatus
+		}
+		if _, _, err := g.client.Incidents.Create(i); err != nil {
+			return fmt.Errorf("Failed to create incident: %v", err)
+		}
+	}
+
+	if componentStatus > 0 {
+		if _, _, err := g.client.Components.Update(id, &cachet.Component{Status: componentStatus}); err != nil {
+			return fmt.Errorf("Failed to update status of component %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// update adds an update to the incident i and moves the incident to status.
+func (g *Gateway) update(i *cachet.Incident, status int, message string, componentStatus int) error {
+	u := &cachet.IncidentUpdate{Status: status, Message: message, ComponentStatus: componentStatus}
+	if _, _, err := g.client.IncidentUpdates.Create(i.ID, u); err != nil {
+		return fmt.Errorf("Failed to update incident %d: %v", i.ID, err)
+	}
+	if status != dedup.CurrentStatus(i) {
+		if _, _, err := g.client.Incidents.Update(i.ID, &cachet.Incident{Status: status}); err != nil {
+			return fmt.Errorf("Failed to update status of incident %d: %v", i.ID, err)
+		}
+	}
+	return nil
+}
+
+// Parse parses the subject tags and the plain text body of a message.
+func Parse(msg *mail.Message) (*Report, error) {
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	r := &Report{}
+	for _, m := range tag.FindAllStringSubmatch(subject, -1) {
+		t := strings.ToLower(strings.TrimSpace(m[1]))
+		if s, ok := incidentTags[t]; ok {
+			r.Status = s
+		}
+		if s, ok := componentTags[t]; ok {
+			r.ComponentStatus = s
+		}
+	}
+	r.Name = strings.Join(strings.Fields(replyPrefix.ReplaceAllString(tag.ReplaceAllString(subject, ""), "")), " ")
+
+	body, err := plainText(msg.Header.Get("Content-Type"), decode(msg.Header.Get("Content-Transfer-Encoding"), msg.Body))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid message body: %v", err)
+	}
+	r.Message = strings.TrimSpace(body)
+
+	if len(r.Name) == 0 {
+		return nil, fmt.Errorf("The message has no subject")
+	}
+	if len(r.Message) == 0 {
+		r.Message = r.Name
+	}
+	return r, nil
+}
+
+// plainText returns the text/plain part of a body with the given content type.
+func plainText(contentType string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// Messages without content type are plain text.
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			// Quoted-printable parts are decoded by the multipart reader already.
+			if text, err := plainText(p.Header.Get("Content-Type"), decode(p.Header.Get("Content-Transfer-Encoding"), p)); err != nil || len(text) > 0 {
+				return text, err
+			}
+		}
+	}
+
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+	b, err := ioutil.ReadAll(body)
+	return string(b), err
+}
+
+// decode decodes a body with the given Content-Transfer-Encoding.
+func decode(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
@@ -0,0 +1,186 @@
+package mailgate
+
+import (
+	"net/mail"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/dedup"
+	"github.com/andygrunwald/cachet/internal/cachettest"
+)
+
+// newTestServer returns an instance with the component 3 and without incidents.
+func newTestServer() *cachettest.Server {
+	s := cachettest.NewServer()
+	s.Add("components", cachet.Component{ID: 3, Name: "API"})
+	return s
+}
+
+func newTestGateway(t *testing.T, client *cachet.Client) *Gateway {
+	g, err := NewGateway(client, &Options{
+		Domain:     "status.local",
+		Components: map[string]int{"api": 3},
+		Allow:      []string{"noc@example.com", "@ops.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("NewGateway returned error: %v", err)
+	}
+	return g
+}
+
+func TestGateway_Allowed(t *testing.T) {
+	g := newTestGateway(t, nil)
+
+	mockData := map[string]bool{
+		"noc@example.com":                 true,
+		"NOC <NOC@Example.com>":           true,
+		"jane@ops.example.com":            true,
+		"jane@example.com":                false,
+		"mallory@evil-ops.example.com.io": false,
+		"":                                false,
+	}
+	for sender, expected := range mockData {
+		if got := g.Allowed(sender); got != expected {
+			t.Errorf("Allowed(%q) returned %v, want %v", sender, got, expected)
+		}
+	}
+}
+
+func TestGateway_Component(t *testing.T) {
+	g := newTestGateway(t, nil)
+
+	mockData := []struct {
+		recipient string
+		id        int
+		ok        bool
+	}{
+		{"api@status.local", 3, true},
+		{"API <Api@STATUS.local>", 3, true},
+		{"7@status.local", 7, true},
+		{"db@status.local", 0, false},
+		{"api@example.com", 0, false},
+	}
+	for _, data := range mockData {
+		if id, ok := g.Component(data.recipient); id != data.id || ok != data.ok {
+			t.Errorf("Component(%q) returned %d, %v, want %d, %v", data.recipient, id, ok, data.id, data.ok)
+		}
+	}
+}
+
+func TestNewGateway_Ensurer(t *testing.T) {
+	e := dedup.NewEnsurer(nil, nil)
+	g, err := NewGateway(nil, &Options{Domain: "status.local", Allow: []string{"noc@example.com"}, Ensurer: e})
+	if err != nil {
+		t.Fatalf("NewGateway returned error: %v", err)
+	}
+	if g.ensurer != e {
+		t.Error("NewGateway did not use the shared Ensurer")
+	}
+}
+
+func TestParse(t *testing.T) {
+	mockData := []struct {
+		message  string
+		expected *Report
+	}{
+		{
+			"Subject: [identified] [MAJOR] Database down\r\n\r\nThe primary is gone.\r\n",
+			&Report{Name: "Database down", Message: "The primary is gone.", Status: cachet.IncidentStatusIdentified, ComponentStatus: cachet.ComponentStatusMajorOutage},
+		},
+		{
+			"Subject: Re: AW: [resolved] Database down\r\n\r\n",
+			&Report{Name: "Database down", Message: "Database down", Status: cachet.IncidentStatusFixed},
+		},
+		{
+			"Subject: =?UTF-8?Q?St=C3=B6rung?=\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\nRGllIEFQSSBpc3Qg\r\nbGFuZ3NhbS4=\r\n",
+			&Report{Name: "Störung", Message: "Die API ist langsam."},
+		},
+		{
+			"Subject: Slow API\r\nContent-Type: multipart/alternative; boundary=b\r\n\r\n--b\r\nContent-Type: text/html\r\n\r\n<p>HTML</p>\r\n--b\r\nContent-Type: text/plain\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\nResponse times =3D 5s\r\n--b--\r\n",
+			&Report{Name: "Slow API", Message: "Response times = 5s"},
+		},
+	}
+
+	for _, data := range mockData {
+		msg, err := mail.ReadMessage(strings.NewReader(data.message))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Parse(msg)
+		if err != nil {
+			t.Errorf("Parse returned error: %v", err)
+			continue
+		}
+		if !reflect.DeepEqual(got, data.expected) {
+			t.Errorf("Parse returned %+v, want %+v", got, data.expected)
+		}
+	}
+
+	msg, _ := mail.ReadMessage(strings.NewReader("Subject: [major]\r\n\r\nBody\r\n"))
+	if _, err := Parse(msg); err == nil {
+		t.Error("Parse returned no error for a message without subject")
+	}
+}
+
+func TestGateway_Deliver(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	g := newTestGateway(t, s.Client)
+
+	steps := []struct {
+		name     string
+		message  string
+		expected []cachettest.Write
+	}{
+		{
+			"new incident",
+			"From: noc@example.com\r\nSubject: Database down\r\n\r\nInvestigating.\r\n",
+			[]cachettest.Write{
+				{Method: "POST", Path: "/api/v1/incidents", Body: map[string]interface{}{
+					"name": "Database down", "message": "Investigating.", "status": float64(1), "visible": float64(1),
+					"component_id": float64(3), "component_status": float64(3),
+				}},
+				{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(3)}},
+			},
+		},
+		{
+			"update",
+			"From: noc@example.com\r\nSubject: Re: [identified] [major] Database down\r\n\r\nThe primary is gone.\r\n",
+			[]cachettest.Write{
+				{Method: "POST", Path: "/api/v1/incidents/1/updates", Body: map[string]interface{}{
+					"message": "The primary is gone.", "status": float64(2), "component_status": float64(4),
+				}},
+				{Method: "PUT", Path: "/api/v1/incidents/1", Body: map[string]interface{}{"status": float64(2)}},
+				{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(4)}},
+			},
+		},
+		{
+			"resolved",
+			"From: noc@example.com\r\nSubject: [resolved] Database down\r\n\r\nFailover done.\r\n",
+			[]cachettest.Write{
+				{Method: "POST", Path: "/api/v1/incidents/1/updates", Body: map[string]interface{}{
+					"message": "Failover done.", "status": float64(4), "component_status": float64(1),
+				}},
+				{Method: "PUT", Path: "/api/v1/incidents/1", Body: map[string]interface{}{"status": float64(4)}},
+				{Method: "PUT", Path: "/api/v1/components/3", Body: map[string]interface{}{"status": float64(1)}},
+			},
+		},
+		{"resolved without incident", "From: noc@example.com\r\nSubject: [resolved] Database down\r\n\r\n", nil},
+	}
+
+	for _, step := range steps {
+		if err := g.Deliver("noc@example.com", []string{"api@status.local", "db@status.local"}, strings.NewReader(step.message)); err != nil {
+			t.Errorf("Deliver returned error for %s: %v", step.name, err)
+		}
+		if got := s.Writes(); !reflect.DeepEqual(got, step.expected) {
+			t.Errorf("Deliver sent for %s\n%v\nwant\n%v", step.name, got, step.expected)
+		}
+	}
+
+	err := g.Deliver("noc@example.com", []string{"api@status.local"}, strings.NewReader("From: mallory@example.com\r\nSubject: Fake\r\n\r\n"))
+	if err == nil || len(s.Writes()) > 0 {
+		t.Errorf("Deliver returned %v for a forged From header, want an error", err)
+	}
+}
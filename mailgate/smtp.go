@@ -0,0 +1,179 @@
+package mailgate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// timeout is the maximum time of an SMTP command.
+const timeout = 5 * time.Minute
+
+// ListenAndServe listens on the TCP address addr and serves SMTP connections.
+func (g *Gateway) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	return g.Serve(l)
+}
+
+// Serve serves SMTP connections accepted by l until l is closed.
+func (g *Gateway) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go g.serveConn(conn)
+	}
+}
+
+// session is the state of an SMTP connection.
+type session struct {
+	g    *Gateway
+	conn net.Conn
+	tp   *textproto.Conn
+	from string
+	to   []string
+}
+
+// serveConn speaks SMTP on conn until the client quits.
+func (g *Gateway) serveConn(conn net.Conn) {
+	s := &session{g: g, conn: conn, tp: textproto.NewConn(conn)}
+	defer s.tp.Close()
+
+	s.reply(220, "%s Cachet mail gateway ready", g.options.Domain)
+	for {
+		conn.SetDeadline(time.Now().Add(timeout))
+		line, err := s.tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg := line, ""
+		if i := strings.Index(line, " "); i >= 0 {
+			verb, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			s.reset()
+			s.reply(250, "%s", g.options.Domain)
+		case "EHLO":
+			s.reset()
+			s.tp.PrintfLine("250-%s", g.options.Domain)
+			s.tp.PrintfLine("250-SIZE %d", g.options.MaxSize)
+			s.reply(250, "8BITMIME")
+		case "MAIL":
+			s.mail(arg)
+		case "RCPT":
+			s.rcpt(arg)
+		case "DATA":
+			s.data()
+		case "RSET":
+			s.reset()
+			s.reply(250, "2.0.0 OK")
+		case "NOOP":
+			s.reply(250, "2.0.0 OK")
+		case "VRFY":
+			s.reply(252, "2.1.5 Cannot verify the user")
+		case "QUIT":
+			s.reply(221, "2.0.0 Bye")
+			return
+		default:
+			s.reply(500, "5.5.2 Command not recognized")
+		}
+	}
+}
+
+func (s *session) reply(code int, format string, args ...interface{}) {
+	s.tp.PrintfLine("%d %s", code, fmt.Sprintf(format, args...))
+}
+
+func (s *session) reset() {
+	s.from = ""
+	s.to = nil
+}
+
+func (s *session) mail(arg string) {
+	addr, ok := parsePath(arg, "FROM:")
+	if !ok {
+		s.reply(501, "5.5.4 Syntax: MAIL FROM:<address>")
+		return
+	}
+	if !s.g.Allowed(addr) {
+		s.reply(550, "5.7.1 Sender not allowed")
+		return
+	}
+	s.reset()
+	s.from = addr
+	s.reply(250, "2.1.0 OK")
+}
+
+func (s *session) rcpt(arg string) {
+	if len(s.from) == 0 {
+		s.reply(503, "5.5.1 MAIL first")
+		return
+	}
+	addr, ok := parsePath(arg, "TO:")
+	if !ok {
+		s.reply(501, "5.5.4 Syntax: RCPT TO:<address>")
+		return
+	}
+	if _, ok := s.g.Component(addr); !ok {
+		s.reply(550, "5.1.1 No such component")
+		return
+	}
+	s.to = append(s.to, addr)
+	s.reply(250, "2.1.5 OK")
+}
+
+func (s *session) data() {
+	if len(s.to) == 0 {
+		s.reply(503, "5.5.1 RCPT first")
+		return
+	}
+	s.reply(354, "End data with <CR><LF>.<CR><LF>")
+
+	r := s.tp.DotReader()
+	b, err := ioutil.ReadAll(io.LimitReader(r, s.g.options.MaxSize+1))
+	io.Copy(ioutil.Discard, r)
+	from, to := s.from, s.to
+	s.reset()
+
+	switch {
+	case err != nil:
+		s.reply(451, "4.3.0 Failed to read the message")
+	case int64(len(b)) > s.g.options.MaxSize:
+		s.reply(552, "5.3.4 Message too big")
+	default:
+		if err := s.g.Deliver(from, to, bytes.NewReader(b)); err != nil {
+			s.reply(554, "5.6.0 %s", strings.Replace(err.Error(), "\n", " ", -1))
+			return
+		}
+		s.reply(250, "2.0.0 OK: applied")
+	}
+}
+
+// parsePath returns the address of a MAIL FROM or RCPT TO argument like "FROM:<a@example.com> SIZE=100".
+func parsePath(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(path, "<") {
+		return "", false
+	}
+	end := strings.Index(path, ">")
+	if end < 0 {
+		return "", false
+	}
+	return path[1:end], true
+}
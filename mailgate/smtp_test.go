@@ -0,0 +1,57 @@
+package mailgate
+
+import (
+	"net"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+func TestGateway_Serve(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	g := newTestGateway(t, s.Client)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go g.Serve(l)
+
+	msg := "From: noc@example.com\r\nTo: api@status.local\r\nSubject: [major] API down\r\n\r\nAll requests fail.\r\n.. with a dot\r\n"
+	if err := smtp.SendMail(l.Addr().String(), nil, "noc@example.com", []string{"api@status.local"}, []byte(msg)); err != nil {
+		t.Fatalf("SendMail returned error: %v", err)
+	}
+	got := s.Writes()
+	if len(got) != 2 || got[0].Body["message"] != "All requests fail.\n.. with a dot" || got[0].Body["component_status"] != float64(4) {
+		t.Errorf("Gateway sent %v", got)
+	}
+
+	err = smtp.SendMail(l.Addr().String(), nil, "mallory@example.com", []string{"api@status.local"}, []byte(msg))
+	if err == nil || !strings.Contains(err.Error(), "550") {
+		t.Errorf("SendMail returned %v for a forbidden sender, want 550", err)
+	}
+	err = smtp.SendMail(l.Addr().String(), nil, "noc@example.com", []string{"db@status.local"}, []byte(msg))
+	if err == nil || !strings.Contains(err.Error(), "No such component") {
+		t.Errorf("SendMail returned %v for an unknown component, want an error", err)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	mockData := []struct {
+		arg, prefix, addr string
+		ok                bool
+	}{
+		{"FROM:<noc@example.com> SIZE=100", "FROM:", "noc@example.com", true},
+		{"to: <api@status.local>", "TO:", "api@status.local", true},
+		{"FROM:noc@example.com", "FROM:", "", false},
+		{"TO:<api@status.local", "TO:", "", false},
+		{"FROM:<a@b>", "TO:", "", false},
+	}
+	for _, data := range mockData {
+		if addr, ok := parsePath(data.arg, data.prefix); addr != data.addr || ok != data.ok {
+			t.Errorf("parsePath(%q) returned %q, %v, want %q, %v", data.arg, addr, ok, data.addr, data.ok)
+		}
+	}
+}
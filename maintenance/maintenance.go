@@ -0,0 +1,284 @@
+/*
+Package maintenance runs maintenance windows based on schedules.
+
+A Runner creates the schedule of a maintenance window. Once the window starts,
+the schedule moves to in progress and the affected components to the maintenance status.
+Once it ends, the schedule is completed and the components are restored to their previous status:
+
+	runner, err := maintenance.NewRunner(client, "/var/lib/cachet/maintenance.json", &maintenance.Options{
+		Location: time.UTC,
+	})
+
+	schedule, err := runner.Schedule(&maintenance.Window{
+		Name:       "Database upgrade",
+		Start:      time.Date(2017, 9, 30, 22, 0, 0, 0, time.UTC),
+		End:        time.Date(2017, 9, 30, 23, 0, 0, 0, time.UTC),
+		Components: []int{1, 2},
+	})
+
+	err = runner.Run(ctx, time.Minute, func(err error) { log.Print(err) })
+
+The state of all windows is kept in a small state file.
+A restarted runner picks up where the previous one stopped.
+*/
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+)
+
+// Window is a maintenance window.
+type Window struct {
+	Name    string
+	Message string
+	Start   time.Time
+	End     time.Time
+
+	// Components contains the IDs of the affected components.
+	Components []int
+	// ComponentStatus is set on the affected components during the maintenance.
+	// It defaults to cachet.ComponentStatusPerformanceIssues.
+	ComponentStatus int
+}
+
+// Options configures the runner.
+type Options struct {
+	// Location is the time zone of the Cachet instance. It defaults to UTC.
+	Location *time.Location
+}
+
+// Runner runs maintenance windows.
+type Runner struct {
+	client   *cachet.Client
+	path     string
+	location *time.Location
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+
+	mu    sync.Mutex
+	state *State
+}
+
+// NewRunner returns a new runner that keeps its state in the file at path. o can be nil.
+func NewRunner(client *cachet.Client, path string, o *Options) (*Runner, error) {
+	s, err := readState(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read state file %s: %v", path, err)
+	}
+	r := &Runner{client: client, path: path, location: time.UTC, now: time.Now, state: s}
+	if o != nil && o.Location != nil {
+		r.location = o.Location
+	}
+	return r, nil
+}
+
+// Location returns the time zone of the Cachet instance.
+func (r *Runner) Location() *time.Location {
+	return r.location
+}
+
+// Windows returns the maintenance windows that are not complete yet.
+func (r *Runner) Windows() []WindowState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	windows := make([]WindowState, 0, len(r.state.Windows))
+	for _, w := range r.state.Windows {
+		windows = append(windows, *w)
+	}
+	return windows
+}
+
+// Schedule creates the schedule of a maintenance window and adds it to the runner.
+func (r *Runner) Schedule(w *Window) (*cachet.Schedule, error) {
+	if len(w.Name) == 0 {
+		return nil, fmt.Errorf("A maintenance window needs a name")
+	}
+	if !w.End.After(w.Start) {
+		return nil, fmt.Errorf("Maintenance window %q ends before it starts", w.Name)
+	}
+
+	body := &api.Schedule{
+		Name:        w.Name,
+		Message:     w.Message,
+		Status:      cachet.ScheduleUpcoming,
+		ScheduledAt: w.Start.In(r.location).Format(api.TimestampLayout),
+	}
+	for _, id := range w.Components {
+		body.Components = append(body.Components, cachet.Component{ID: id})
+	}
+
+	created, err := api.CreateSchedule(r.client, body)
+	if err != nil {
+		return nil, err
+	}
+
+	status := w.ComponentStatus
+	if status == 0 {
+		status = cachet.ComponentStatusPerformanceIssues
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.state.Windows = append(r.state.Windows, &WindowState{
+		ScheduleID:      created.ID,
+		Name:            w.Name,
+		Start:           w.Start,
+		End:             w.End,
+		Components:      w.Components,
+		ComponentStatus: status,
+		Phase:           PhasePending,
+	})
+	return created, r.save()
+}
+
+// Tick starts and completes all maintenance windows that are due.
+// Windows that failed are retried with the next tick.
+func (r *Runner) Tick() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	var errs []error
+	var windows []*WindowState
+	for _, w := range r.state.Windows {
+		if err := r.advance(w, now); err != nil {
+			errs = append(errs, fmt.Errorf("Maintenance window %q: %v", w.Name, err))
+		}
+		if w.Phase != "" {
+			windows = append(windows, w)
+		}
+	}
+	r.state.Windows = windows
+
+	if err := r.save(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Run calls Tick every interval until the context is done.
+// Errors of single ticks are passed to the optional onError.
+func (r *Runner) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Tick(); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// advance moves the window w to the phase due at now.
+// Completed windows get an empty phase.
+func (r *Runner) advance(w *WindowState, now time.Time) error {
+	switch {
+	case w.Phase == PhasePending && !now.Before(w.End):
+		// The runner was not running during the whole window.
+		// Changing the components would only flap them.
+		if err := r.complete(w); err != nil {
+			return err
+		}
+	case w.Phase == PhasePending && !now.Before(w.Start):
+		if err := r.start(w); err != nil {
+			return err
+		}
+		if !now.Before(w.End) {
+			return r.advance(w, now)
+		}
+	case w.Phase == PhaseInProgress && !now.Before(w.End):
+		if err := r.restore(w); err != nil {
+			return err
+		}
+		if err := r.complete(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// start moves the schedule and the components of w into maintenance.
+func (r *Runner) start(w *WindowState) error {
+	// The previous status is only recorded once, a retry must not record the maintenance status.
+	if w.Previous == nil {
+		w.Previous = map[int]int{}
+		for _, id := range w.Components {
+			c, _, err := r.client.Components.Get(id)
+			if err == nil && c == nil {
+				err = fmt.Errorf("Empty response from the Cachet API")
+			}
+			if err != nil {
+				w.Previous = nil
+				return fmt.Errorf("Failed to get component %d: %v", id, err)
+			}
+			w.Previous[id] = c.Status
+		}
+		if err := r.save(); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range w.Components {
+		if _, _, err := r.client.Components.Update(id, &cachet.Component{Status: w.ComponentStatus}); err != nil {
+			return fmt.Errorf("Failed to update component %d: %v", id, err)
+		}
+	}
+	if _, _, err := r.client.Schedules.Update(w.ScheduleID, &cachet.Schedule{Status: cachet.ScheduleInProgress}); err != nil {
+		return fmt.Errorf("Failed to update schedule %d: %v", w.ScheduleID, err)
+	}
+
+	w.Phase = PhaseInProgress
+	return nil
+}
+
+// restore sets the components of w back to their previous status.
+// Unknown can not be sent with cachet.Component, such components are restored as operational.
+func (r *Runner) restore(w *WindowState) error {
+	for _, id := range w.Components {
+		status, ok := w.Previous[id]
+		if !ok || status == cachet.ComponentStatusUnknown {
+			status = cachet.ComponentStatusOperational
+		}
+		if _, _, err := r.client.Components.Update(id, &cachet.Component{Status: status}); err != nil {
+			return fmt.Errorf("Failed to restore component %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// complete marks the schedule of w as complete.
+func (r *Runner) complete(w *WindowState) error {
+	s := &cachet.Schedule{Status: cachet.ScheduleComplete, CompletedAt: w.End.In(r.location).Format(api.TimestampLayout)}
+	if _, _, err := r.client.Schedules.Update(w.ScheduleID, s); err != nil {
+		return fmt.Errorf("Failed to complete schedule %d: %v", w.ScheduleID, err)
+	}
+
+	w.Phase = ""
+	return nil
+}
+
+// save writes the state file. The caller has to hold r.mu.
+func (r *Runner) save() error {
+	if err := writeState(r.path, r.state); err != nil {
+		return fmt.Errorf("Failed to write state file %s: %v", r.path, err)
+	}
+	return nil
+}
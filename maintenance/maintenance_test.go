@@ -0,0 +1,287 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+
+	// testStateFile is the path of the state file used in tests.
+	testStateFile string
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+
+	dir, _ := ioutil.TempDir("", "maintenance")
+	testStateFile = filepath.Join(dir, "state.json")
+}
+
+// teardown closes the test HTTP server and removes the state file.
+func teardown() {
+	testServer.Close()
+	os.RemoveAll(filepath.Dir(testStateFile))
+}
+
+func testMethod(t *testing.T, r *http.Request, want string) {
+	if got := r.Method; got != want {
+		t.Errorf("Request method: %v, want %v", got, want)
+	}
+}
+
+func TestRunner_Schedule(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		expected := map[string]interface{}{
+			"name": "Upgrade", "message": "", "status": float64(0), "scheduled_at": "2017-09-30 22:00:00",
+			"components": []interface{}{map[string]interface{}{"id": float64(2)}},
+		}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":1,"name":"Upgrade"}}`)
+	})
+
+	r, err := NewRunner(testClient, testStateFile, nil)
+	if err != nil {
+		t.Fatalf("NewRunner returned error: %v", err)
+	}
+
+	start := time.Date(2017, 9, 30, 22, 0, 0, 0, time.UTC)
+	if _, err := r.Schedule(&Window{Name: "Upgrade", Start: start, End: start}); err == nil {
+		t.Error("Schedule returned no error for an empty window. Expected one.")
+	}
+
+	s, err := r.Schedule(&Window{Name: "Upgrade", Start: start, End: start.Add(time.Hour), Components: []int{2}})
+	if err != nil {
+		t.Fatalf("Schedule returned error: %v", err)
+	}
+	if s.ID != 1 {
+		t.Errorf("Schedule returned schedule %d, want 1", s.ID)
+	}
+
+	// A new runner reads the window from the state file.
+	r, err = NewRunner(testClient, testStateFile, nil)
+	if err != nil {
+		t.Fatalf("NewRunner returned error: %v", err)
+	}
+	windows := r.Windows()
+	if len(windows) != 1 || windows[0].Phase != PhasePending || windows[0].ComponentStatus != cachet.ComponentStatusPerformanceIssues {
+		t.Errorf("Windows returned %+v, want one pending window", windows)
+	}
+}
+
+func TestRunner_Schedule_Location(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		body := &cachet.Schedule{}
+		json.NewDecoder(r.Body).Decode(body)
+		if expected := "2017-10-01 00:00:00"; body.ScheduledAt != expected {
+			t.Errorf("Schedule sent scheduled_at %q, want %q", body.ScheduledAt, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":1,"name":"Upgrade"}}`)
+	})
+
+	r, err := NewRunner(testClient, testStateFile, &Options{Location: time.FixedZone("CEST", 2*60*60)})
+	if err != nil {
+		t.Fatalf("NewRunner returned error: %v", err)
+	}
+	start := time.Date(2017, 9, 30, 22, 0, 0, 0, time.UTC)
+	if _, err := r.Schedule(&Window{Name: "Upgrade", Start: start, End: start.Add(time.Hour)}); err != nil {
+		t.Fatalf("Schedule returned error: %v", err)
+	}
+}
+
+func TestRunner_Tick(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var requests []string
+	testMux.HandleFunc("/api/v1/components/2", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":{"id":2,"status":3}}`)
+			return
+		}
+		testMethod(t, r, "PUT")
+		body := &cachet.Component{}
+		json.NewDecoder(r.Body).Decode(body)
+		requests = append(requests, fmt.Sprintf("component %d", body.Status))
+		fmt.Fprint(w, `{"data":{"id":2}}`)
+	})
+	testMux.HandleFunc("/api/v1/schedules/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		body := &cachet.Schedule{}
+		json.NewDecoder(r.Body).Decode(body)
+		requests = append(requests, fmt.Sprintf("schedule %d %s", body.Status, body.CompletedAt))
+		fmt.Fprint(w, `{"data":{"id":1}}`)
+	})
+
+	start := time.Date(2017, 9, 30, 22, 0, 0, 0, time.UTC)
+	writeState(testStateFile, &State{Windows: []*WindowState{{
+		ScheduleID:      1,
+		Name:            "Upgrade",
+		Start:           start,
+		End:             start.Add(time.Hour),
+		Components:      []int{2},
+		ComponentStatus: cachet.ComponentStatusMajorOutage,
+		Phase:           PhasePending,
+	}}})
+
+	tick := func(now time.Time) {
+		// Every tick uses a new runner to simulate restarts.
+		r, err := NewRunner(testClient, testStateFile, nil)
+		if err != nil {
+			t.Fatalf("NewRunner returned error: %v", err)
+		}
+		r.now = func() time.Time { return now }
+		if err := r.Tick(); err != nil {
+			t.Fatalf("Tick returned error: %v", err)
+		}
+	}
+
+	tick(start.Add(-time.Minute))
+	tick(start)
+	tick(start.Add(time.Minute))
+	tick(start.Add(time.Hour))
+	tick(start.Add(2 * time.Hour))
+
+	expected := []string{
+		"component 4",
+		"schedule 1 ",
+		"component 3",
+		"schedule 2 2017-09-30 23:00:00",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Tick made the requests %v, want %v", requests, expected)
+	}
+
+	s, _ := readState(testStateFile)
+	if len(s.Windows) != 0 {
+		t.Errorf("State contains %+v after the window, want no windows", s.Windows)
+	}
+}
+
+func TestRunner_Tick_Missed(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/components/2", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Tick changed component 2 of a missed window")
+	})
+	completed := false
+	testMux.HandleFunc("/api/v1/schedules/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		completed = true
+		fmt.Fprint(w, `{"data":{"id":1}}`)
+	})
+
+	start := time.Date(2017, 9, 30, 22, 0, 0, 0, time.UTC)
+	writeState(testStateFile, &State{Windows: []*WindowState{{
+		ScheduleID: 1, Name: "Upgrade", Start: start, End: start.Add(time.Hour), Components: []int{2}, Phase: PhasePending,
+	}}})
+
+	r, _ := NewRunner(testClient, testStateFile, nil)
+	r.now = func() time.Time { return start.Add(2 * time.Hour) }
+	if err := r.Tick(); err != nil {
+		t.Fatalf("Tick returned error: %v", err)
+	}
+	if !completed {
+		t.Error("Tick did not complete the schedule of the missed window")
+	}
+}
+
+func TestRunner_Tick_Retry(t *testing.T) {
+	setup()
+	defer teardown()
+
+	fail := true
+	testMux.HandleFunc("/api/v1/components/2", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":{"id":2,"status":1}}`)
+			return
+		}
+		if fail {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"id":2}}`)
+	})
+	testMux.HandleFunc("/api/v1/schedules/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":1}}`)
+	})
+
+	start := time.Date(2017, 9, 30, 22, 0, 0, 0, time.UTC)
+	writeState(testStateFile, &State{Windows: []*WindowState{{
+		ScheduleID: 1, Name: "Upgrade", Start: start, End: start.Add(time.Hour), Components: []int{2}, ComponentStatus: 2, Phase: PhasePending,
+	}}})
+
+	r, _ := NewRunner(testClient, testStateFile, nil)
+	r.now = func() time.Time { return start }
+	if err := r.Tick(); err == nil {
+		t.Fatal("Tick returned no error. Expected one.")
+	}
+	if w := r.Windows(); len(w) != 1 || w[0].Phase != PhasePending || w[0].Previous[2] != 1 {
+		t.Errorf("Windows returned %+v after a failed start, want a pending window with the previous status", w)
+	}
+
+	fail = false
+	if err := r.Tick(); err != nil {
+		t.Fatalf("Tick returned error: %v", err)
+	}
+	if w := r.Windows(); len(w) != 1 || w[0].Phase != PhaseInProgress {
+		t.Errorf("Windows returned %+v, want a window in progress", w)
+	}
+}
+
+func TestRunner_Tick_EmptyComponent(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/components/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{}`)
+	})
+
+	start := time.Date(2017, 9, 30, 22, 0, 0, 0, time.UTC)
+	writeState(testStateFile, &State{Windows: []*WindowState{{
+		ScheduleID: 1, Name: "Upgrade", Start: start, End: start.Add(time.Hour), Components: []int{2}, ComponentStatus: 2, Phase: PhasePending,
+	}}})
+
+	r, _ := NewRunner(testClient, testStateFile, nil)
+	r.now = func() time.Time { return start }
+	if err := r.Tick(); err == nil {
+		t.Fatal("Tick returned no error for an empty response. Expected one.")
+	}
+	if w := r.Windows(); len(w) != 1 || w[0].Phase != PhasePending || w[0].Previous != nil {
+		t.Errorf("Windows returned %+v, want a pending window without previous status", w)
+	}
+}
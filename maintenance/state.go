@@ -0,0 +1,79 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// PhasePending means the maintenance window did not start yet.
+	PhasePending = "pending"
+	// PhaseInProgress means the components are in maintenance.
+	PhaseInProgress = "in_progress"
+)
+
+// State contains the maintenance windows that are not complete yet.
+// It is written to the state file after every change.
+type State struct {
+	Windows []*WindowState `json:"windows"`
+}
+
+// WindowState is the state of a single maintenance window.
+type WindowState struct {
+	ScheduleID      int       `json:"schedule_id"`
+	Name            string    `json:"name"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Components      []int     `json:"components"`
+	ComponentStatus int       `json:"component_status"`
+	Phase           string    `json:"phase"`
+
+	// Previous contains the status of the components before the maintenance started.
+	Previous map[int]int `json:"previous,omitempty"`
+}
+
+// readState reads the state file.
+// A missing file results in an empty state.
+func readState(path string) (*State, error) {
+	s := &State{}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// writeState writes the state file.
+// The state is written to a temporary file first to not lose it if the process dies while writing.
+func writeState(path string, s *State) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
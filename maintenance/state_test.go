@@ -0,0 +1,54 @@
+package maintenance
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestState_WriteRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintenance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	got, err := readState(path)
+	if err != nil {
+		t.Fatalf("readState returned error for a missing file: %v", err)
+	}
+	if len(got.Windows) != 0 {
+		t.Errorf("readState returned %+v for a missing file, want an empty state", got)
+	}
+
+	s := &State{Windows: []*WindowState{{
+		ScheduleID:      1,
+		Name:            "Upgrade",
+		Start:           time.Date(2017, 9, 30, 22, 0, 0, 0, time.UTC),
+		End:             time.Date(2017, 9, 30, 23, 0, 0, 0, time.UTC),
+		Components:      []int{2},
+		ComponentStatus: 2,
+		Phase:           PhaseInProgress,
+		Previous:        map[int]int{2: 1},
+	}}}
+	if err := writeState(path, s); err != nil {
+		t.Fatalf("writeState returned error: %v", err)
+	}
+
+	got, err = readState(path)
+	if err != nil {
+		t.Fatalf("readState returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("readState returned %+v, want %+v", got, s)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("writeState left %d files, want only the state file", len(files))
+	}
+}
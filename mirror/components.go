@@ -0,0 +1,102 @@
+package mirror
+
+import (
+	"github.com/andygrunwald/cachet"
+)
+
+// ComponentsService mirrors writes of components.
+type ComponentsService struct {
+	client *Client
+}
+
+// GetAll returns all components of the primary instance.
+func (s *ComponentsService) GetAll(filter *cachet.ComponentsQueryParams) (*cachet.ComponentResponse, *cachet.Response, error) {
+	return s.client.primary.Client.Components.GetAll(filter)
+}
+
+// Get returns a single component of the primary instance.
+func (s *ComponentsService) Get(id int) (*cachet.Component, *cachet.Response, error) {
+	return s.client.primary.Client.Components.Get(id)
+}
+
+// Create creates a component on all instances.
+// The group of the component is looked up by name on the mirrors.
+func (s *ComponentsService) Create(c *cachet.Component) (*cachet.Component, *cachet.Response, error) {
+	created, resp, err := s.client.primary.Client.Components.Create(c)
+	if err != nil || created == nil {
+		return created, resp, err
+	}
+
+	err = s.client.fanOut(func(m *instance) error {
+		mirrored, err := s.mirrored(m, c)
+		if err != nil {
+			return err
+		}
+		v, _, err := m.Client.Components.Create(mirrored)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			m.setMapping(kindComponent, created.ID, v.ID)
+		}
+		return nil
+	})
+	return created, resp, err
+}
+
+// Update updates a component on all instances.
+func (s *ComponentsService) Update(id int, c *cachet.Component) (*cachet.Component, *cachet.Response, error) {
+	// IDs are resolved before the update, because the update may rename the component.
+	ids, resolveErr := s.client.resolveAll(kindComponent, id)
+
+	updated, resp, err := s.client.primary.Client.Components.Update(id, c)
+	if err != nil {
+		return updated, resp, err
+	}
+
+	err = s.client.fanOut(func(m *instance) error {
+		if err := mirrorError(resolveErr, m); err != nil {
+			return err
+		}
+		mirrored, err := s.mirrored(m, c)
+		if err != nil {
+			return err
+		}
+		_, _, err = m.Client.Components.Update(ids[m], mirrored)
+		return err
+	})
+	return updated, resp, err
+}
+
+// Delete deletes a component on all instances.
+func (s *ComponentsService) Delete(id int) (*cachet.Response, error) {
+	ids, resolveErr := s.client.resolveAll(kindComponent, id)
+
+	resp, err := s.client.primary.Client.Components.Delete(id)
+	if err != nil {
+		return resp, err
+	}
+
+	err = s.client.fanOut(func(m *instance) error {
+		if err := mirrorError(resolveErr, m); err != nil {
+			return err
+		}
+		if _, err := m.Client.Components.Delete(ids[m]); err != nil {
+			return err
+		}
+		m.deleteMapping(kindComponent, id)
+		return nil
+	})
+	return resp, err
+}
+
+// mirrored returns a copy of c with the IDs of the mirror m.
+func (s *ComponentsService) mirrored(m *instance, c *cachet.Component) (*cachet.Component, error) {
+	mirrored := *c
+	groupID, err := s.client.resolve(m, kindGroup, c.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	mirrored.GroupID = groupID
+	return &mirrored, nil
+}
@@ -0,0 +1,136 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestComponentsService_Create(t *testing.T) {
+	setup()
+	defer teardown()
+
+	primaryMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"data":{"id":1,"name":"API","group_id":2}}`)
+	})
+	primaryMux.HandleFunc("/api/v1/components/groups/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"data":{"id":2,"name":"Websites"}}`)
+	})
+	mirrorMux.HandleFunc("/api/v1/components/groups", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"data":[{"id":7,"name":"Websites internal"},{"id":8,"name":"Websites"}]}`)
+	})
+	mirrorMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := &cachet.Component{}
+		json.NewDecoder(r.Body).Decode(body)
+		if body.GroupID != 8 {
+			t.Errorf("Mirrored component has group %d, want 8", body.GroupID)
+		}
+		fmt.Fprint(w, `{"data":{"id":5,"name":"API","group_id":8}}`)
+	})
+
+	got, _, err := testClient.Components.Create(&cachet.Component{Name: "API", GroupID: 2})
+	if err != nil {
+		t.Fatalf("Components.Create returned error: %v", err)
+	}
+
+	expected := &cachet.Component{ID: 1, Name: "API", GroupID: 2}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Components.Create returned %+v, want %+v", got, expected)
+	}
+	if id, _ := testClient.mirrors[0].mapping(kindComponent, 1); id != 5 {
+		t.Errorf("Component 1 is mapped to %d on the mirror, want 5", id)
+	}
+}
+
+func TestComponentsService_Update_Rename(t *testing.T) {
+	setup()
+	defer teardown()
+
+	name := "API"
+	primaryMux.HandleFunc("/api/v1/components/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			name = "Public API"
+		}
+		fmt.Fprintf(w, `{"data":{"id":1,"name":%q}}`, name)
+	})
+	mirrorMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"data":[{"id":5,"name":"API"}]}`)
+	})
+	updated := false
+	mirrorMux.HandleFunc("/api/v1/components/5", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		updated = true
+		fmt.Fprint(w, `{"data":{"id":5,"name":"Public API"}}`)
+	})
+
+	got, _, err := testClient.Components.Update(1, &cachet.Component{Name: "Public API"})
+	if err != nil {
+		t.Fatalf("Components.Update returned error: %v", err)
+	}
+	if got.Name != "Public API" {
+		t.Errorf("Components.Update returned %+v, want the renamed component", got)
+	}
+	if !updated {
+		t.Error("Components.Update did not update the component on the mirror")
+	}
+}
+
+func TestComponentsService_Update_PartialError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	primaryMux.HandleFunc("/api/v1/components/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":1,"name":"API"}}`)
+	})
+	mirrorMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[]}`)
+	})
+
+	got, _, err := testClient.Components.Update(1, &cachet.Component{Status: cachet.ComponentStatusMajorOutage})
+	if got == nil || got.ID != 1 {
+		t.Errorf("Components.Update returned %+v, want the component of the primary instance", got)
+	}
+	e, ok := err.(*PartialError)
+	if !ok {
+		t.Fatalf("Components.Update returned error %v, want a *PartialError", err)
+	}
+	if _, ok := e.Errors["mirror"]; !ok || len(e.Errors) != 1 {
+		t.Errorf("PartialError contains %v, want an error of the mirror", e.Errors)
+	}
+}
+
+func TestComponentsService_Delete_PrimaryError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	primaryMux.HandleFunc("/api/v1/components/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"id":1,"name":"API"}}`)
+	})
+	mirrorMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":5,"name":"API"}]}`)
+	})
+	mirrorMux.HandleFunc("/api/v1/components/5", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Components.Delete deleted the component on the mirror although the primary instance failed")
+	})
+
+	_, err := testClient.Components.Delete(1)
+	if err == nil {
+		t.Fatal("Components.Delete returned no error. Expected one.")
+	}
+	if _, ok := err.(*PartialError); ok {
+		t.Errorf("Components.Delete returned a *PartialError, want the error of the primary instance")
+	}
+}
@@ -0,0 +1,143 @@
+package mirror
+
+import (
+	"github.com/andygrunwald/cachet"
+)
+
+// IncidentsService mirrors writes of incidents.
+type IncidentsService struct {
+	client *Client
+}
+
+// GetAll returns all incidents of the primary instance.
+func (s *IncidentsService) GetAll(filter *cachet.IncidentsQueryParams) (*cachet.IncidentResponse, *cachet.Response, error) {
+	return s.client.primary.Client.Incidents.GetAll(filter)
+}
+
+// Get returns a single incident of the primary instance.
+func (s *IncidentsService) Get(id int) (*cachet.Incident, *cachet.Response, error) {
+	return s.client.primary.Client.Incidents.Get(id)
+}
+
+// Create creates an incident on all instances.
+// The affected component is looked up by name on the mirrors.
+func (s *IncidentsService) Create(i *cachet.Incident) (*cachet.Incident, *cachet.Response, error) {
+	created, resp, err := s.client.primary.Client.Incidents.Create(i)
+	if err != nil || created == nil {
+		return created, resp, err
+	}
+
+	err = s.client.fanOut(func(m *instance) error {
+		mirrored, err := s.mirrored(m, i)
+		if err != nil {
+			return err
+		}
+		v, _, err := m.Client.Incidents.Create(mirrored)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			m.setMapping(kindIncident, created.ID, v.ID)
+		}
+		return nil
+	})
+	return created, resp, err
+}
+
+// Update updates an incident on all instances.
+func (s *IncidentsService) Update(id int, i *cachet.Incident) (*cachet.Incident, *cachet.Response, error) {
+	// IDs are resolved before the update, because the update may rename the incident.
+	ids, resolveErr := s.client.resolveAll(kindIncident, id)
+
+	updated, resp, err := s.client.primary.Client.Incidents.Update(id, i)
+	if err != nil {
+		return updated, resp, err
+	}
+
+	err = s.client.fanOut(func(m *instance) error {
+		if err := mirrorError(resolveErr, m); err != nil {
+			return err
+		}
+		mirrored, err := s.mirrored(m, i)
+		if err != nil {
+			return err
+		}
+		_, _, err = m.Client.Incidents.Update(ids[m], mirrored)
+		return err
+	})
+	return updated, resp, err
+}
+
+// Delete deletes an incident on all instances.
+func (s *IncidentsService) Delete(id int) (*cachet.Response, error) {
+	ids, resolveErr := s.client.resolveAll(kindIncident, id)
+
+	resp, err := s.client.primary.Client.Incidents.Delete(id)
+	if err != nil {
+		return resp, err
+	}
+
+	err = s.client.fanOut(func(m *instance) error {
+		if err := mirrorError(resolveErr, m); err != nil {
+			return err
+		}
+		if _, err := m.Client.Incidents.Delete(ids[m]); err != nil {
+			return err
+		}
+		m.deleteMapping(kindIncident, id)
+		return nil
+	})
+	return resp, err
+}
+
+// mirrored returns a copy of i with the IDs of the mirror m.
+func (s *IncidentsService) mirrored(m *instance, i *cachet.Incident) (*cachet.Incident, error) {
+	mirrored := *i
+	componentID, err := s.client.resolve(m, kindComponent, i.ComponentID)
+	if err != nil {
+		return nil, err
+	}
+	mirrored.ComponentID = componentID
+	return &mirrored, nil
+}
+
+// IncidentUpdatesService mirrors new updates of incidents.
+// Updates have no name to look them up by, so only the creation of updates is mirrored.
+type IncidentUpdatesService struct {
+	client *Client
+}
+
+// GetAll returns all updates of an incident of the primary instance.
+func (s *IncidentUpdatesService) GetAll(incidentID int) (*cachet.IncidentUpdateResponse, *cachet.Response, error) {
+	return s.client.primary.Client.IncidentUpdates.GetAll(incidentID)
+}
+
+// Get returns a single update of an incident of the primary instance.
+func (s *IncidentUpdatesService) Get(incidentID int, updateID int) (*cachet.IncidentUpdate, *cachet.Response, error) {
+	return s.client.primary.Client.IncidentUpdates.Get(incidentID, updateID)
+}
+
+// Create adds an update to an incident on all instances.
+func (s *IncidentUpdatesService) Create(incidentID int, u *cachet.IncidentUpdate) (*cachet.IncidentUpdate, *cachet.Response, error) {
+	ids, resolveErr := s.client.resolveAll(kindIncident, incidentID)
+
+	created, resp, err := s.client.primary.Client.IncidentUpdates.Create(incidentID, u)
+	if err != nil {
+		return created, resp, err
+	}
+
+	err = s.client.fanOut(func(m *instance) error {
+		if err := mirrorError(resolveErr, m); err != nil {
+			return err
+		}
+		mirrored := *u
+		componentID, err := s.client.resolve(m, kindComponent, u.ComponentID)
+		if err != nil {
+			return err
+		}
+		mirrored.ComponentID = componentID
+		_, _, err = m.Client.IncidentUpdates.Create(ids[m], &mirrored)
+		return err
+	})
+	return created, resp, err
+}
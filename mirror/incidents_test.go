@@ -0,0 +1,84 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestIncidentsService_Create(t *testing.T) {
+	setup()
+	defer teardown()
+
+	primaryMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"data":{"id":3,"name":"Outage","component_id":1}}`)
+	})
+	primaryMux.HandleFunc("/api/v1/components/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"data":{"id":1,"name":"API"}}`)
+	})
+	mirrorMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"data":[{"id":5,"name":"API"}]}`)
+	})
+	mirrorMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		body := &cachet.Incident{}
+		json.NewDecoder(r.Body).Decode(body)
+		if body.ComponentID != 5 {
+			t.Errorf("Mirrored incident affects component %d, want 5", body.ComponentID)
+		}
+		fmt.Fprint(w, `{"data":{"id":9,"name":"Outage","component_id":5}}`)
+	})
+
+	got, _, err := testClient.Incidents.Create(&cachet.Incident{Name: "Outage", ComponentID: 1})
+	if err != nil {
+		t.Fatalf("Incidents.Create returned error: %v", err)
+	}
+	if got.ID != 3 {
+		t.Errorf("Incidents.Create returned incident %d, want 3", got.ID)
+	}
+	if id, _ := testClient.mirrors[0].mapping(kindIncident, 3); id != 9 {
+		t.Errorf("Incident 3 is mapped to %d on the mirror, want 9", id)
+	}
+}
+
+func TestIncidentUpdatesService_Create(t *testing.T) {
+	setup()
+	defer teardown()
+
+	primaryMux.HandleFunc("/api/v1/incidents/3", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"data":{"id":3,"name":"Outage"}}`)
+	})
+	primaryMux.HandleFunc("/api/v1/incidents/3/updates", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"data":{"id":1,"incident_id":3,"status":4}}`)
+	})
+	// The most recent incident with the name is used.
+	mirrorMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"data":[{"id":8,"name":"Outage"},{"id":9,"name":"Outage"},{"id":10,"name":"Other"}]}`)
+	})
+	updated := false
+	mirrorMux.HandleFunc("/api/v1/incidents/9/updates", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		updated = true
+		fmt.Fprint(w, `{"data":{"id":2,"incident_id":9,"status":4}}`)
+	})
+
+	got, _, err := testClient.IncidentUpdates.Create(3, &cachet.IncidentUpdate{Status: cachet.IncidentStatusFixed, Message: "Fixed"})
+	if err != nil {
+		t.Fatalf("IncidentUpdates.Create returned error: %v", err)
+	}
+	if got.ID != 1 {
+		t.Errorf("IncidentUpdates.Create returned update %d, want 1", got.ID)
+	}
+	if !updated {
+		t.Error("IncidentUpdates.Create did not create the update on the mirror")
+	}
+}
@@ -0,0 +1,297 @@
+/*
+Package mirror mirrors writes to several Cachet instances.
+
+A mirror.Client wraps one primary and any number of mirror instances.
+It offers the same API as the ComponentsService, IncidentsService and
+IncidentUpdatesService of the cachet package. Reads are served by the primary instance,
+writes are applied to the primary instance first and afterwards to all mirrors in parallel:
+
+	public, _ := cachet.NewClient("https://status.example.com/", nil)
+	internal, _ := cachet.NewClient("https://status.example.internal/", nil)
+
+	client, err := mirror.NewClient(
+		mirror.Instance{Name: "public", Client: public},
+		mirror.Instance{Name: "internal", Client: internal},
+	)
+
+	component, resp, err := client.Components.Update(3, &cachet.Component{Status: cachet.ComponentStatusMajorOutage})
+
+All IDs passed to and returned by the mirror.Client are IDs of the primary instance.
+The IDs of the same entities on the mirrors are looked up by name (component groups,
+components and incidents) and cached.
+If a write fails on the primary instance, the mirrors are not touched.
+If a write fails on some mirrors only, a *PartialError is returned.
+*/
+package mirror
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+const (
+	kindGroup     = "component group"
+	kindComponent = "component"
+	kindIncident  = "incident"
+)
+
+// Instance is a named Cachet instance.
+type Instance struct {
+	Name   string
+	Client *cachet.Client
+}
+
+// Client mirrors writes from a primary instance to a set of mirror instances.
+type Client struct {
+	primary *instance
+	mirrors []*instance
+
+	// Services used to talk to the instances.
+	Components      *ComponentsService
+	Incidents       *IncidentsService
+	IncidentUpdates *IncidentUpdatesService
+}
+
+// instance is an Instance along with the IDs mapped so far.
+type instance struct {
+	Instance
+
+	mu sync.Mutex
+	// ids maps a kind and a primary ID to the ID on this instance.
+	ids map[string]map[int]int
+}
+
+// PartialError is returned if a write succeeded on the primary instance, but failed on some mirrors.
+type PartialError struct {
+	// Errors maps the names of the failed mirrors to their errors.
+	Errors map[string]error
+}
+
+func (e *PartialError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", name, e.Errors[name]))
+	}
+	return fmt.Sprintf("Write failed on %d mirror(s): %s", len(names), strings.Join(msgs, "; "))
+}
+
+// NewClient returns a new client that mirrors all writes to primary to mirrors.
+func NewClient(primary Instance, mirrors ...Instance) (*Client, error) {
+	names := map[string]bool{}
+	for _, i := range append([]Instance{primary}, mirrors...) {
+		if i.Client == nil {
+			return nil, fmt.Errorf("No Cachet client given for instance %q", i.Name)
+		}
+		if names[i.Name] {
+			return nil, fmt.Errorf("Instance name %q is used twice", i.Name)
+		}
+		names[i.Name] = true
+	}
+
+	c := &Client{primary: newInstance(primary)}
+	for _, m := range mirrors {
+		c.mirrors = append(c.mirrors, newInstance(m))
+	}
+	c.Components = &ComponentsService{client: c}
+	c.Incidents = &IncidentsService{client: c}
+	c.IncidentUpdates = &IncidentUpdatesService{client: c}
+
+	return c, nil
+}
+
+func newInstance(i Instance) *instance {
+	return &instance{Instance: i, ids: map[string]map[int]int{}}
+}
+
+// mapping returns the cached ID on the instance of the entity with the primary ID id.
+func (i *instance) mapping(kind string, id int) (int, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	local, ok := i.ids[kind][id]
+	return local, ok
+}
+
+// setMapping caches the ID on the instance of the entity with the primary ID id.
+func (i *instance) setMapping(kind string, id, local int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.ids[kind] == nil {
+		i.ids[kind] = map[int]int{}
+	}
+	i.ids[kind][id] = local
+}
+
+// deleteMapping removes the cached ID of the entity with the primary ID id.
+func (i *instance) deleteMapping(kind string, id int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.ids[kind], id)
+}
+
+// fanOut calls f for all mirrors in parallel and collects the errors in a *PartialError.
+func (c *Client) fanOut(f func(m *instance) error) error {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errors = map[string]error{}
+	)
+
+	for _, m := range c.mirrors {
+		wg.Add(1)
+		go func(m *instance) {
+			defer wg.Done()
+			if err := f(m); err != nil {
+				mu.Lock()
+				errors[m.Name] = err
+				mu.Unlock()
+			}
+		}(m)
+	}
+	wg.Wait()
+
+	if len(errors) > 0 {
+		return &PartialError{Errors: errors}
+	}
+	return nil
+}
+
+// resolveAll looks up the IDs of the entity with the primary ID id on all mirrors.
+// Mirrors the entity could not be found on are returned as *PartialError.
+func (c *Client) resolveAll(kind string, id int) (map[*instance]int, error) {
+	var mu sync.Mutex
+	ids := map[*instance]int{}
+	err := c.fanOut(func(m *instance) error {
+		local, err := c.resolve(m, kind, id)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		ids[m] = local
+		mu.Unlock()
+		return nil
+	})
+	return ids, err
+}
+
+// resolve returns the ID on the mirror m of the entity with the primary ID id.
+// An ID of 0 (= no entity) is returned unchanged.
+func (c *Client) resolve(m *instance, kind string, id int) (int, error) {
+	if id == 0 {
+		return 0, nil
+	}
+	if local, ok := m.mapping(kind, id); ok {
+		return local, nil
+	}
+
+	name, err := c.primaryName(kind, id)
+	if err != nil {
+		return 0, err
+	}
+
+	local, err := findByName(m.Client, kind, name)
+	if err != nil {
+		return 0, err
+	}
+	m.setMapping(kind, id, local)
+	return local, nil
+}
+
+// primaryName returns the name of the entity with the ID id on the primary instance.
+func (c *Client) primaryName(kind string, id int) (string, error) {
+	var name string
+	var err error
+
+	switch kind {
+	case kindGroup:
+		var g *cachet.ComponentGroup
+		if g, _, err = c.primary.Client.ComponentGroups.Get(id); err == nil && g != nil {
+			name = g.Name
+		}
+	case kindComponent:
+		var co *cachet.Component
+		if co, _, err = c.primary.Client.Components.Get(id); err == nil && co != nil {
+			name = co.Name
+		}
+	case kindIncident:
+		var i *cachet.Incident
+		if i, _, err = c.primary.Client.Incidents.Get(id); err == nil && i != nil {
+			name = i.Name
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+	if len(name) == 0 {
+		return "", fmt.Errorf("Could not find %s %d on the primary instance %q", kind, id, c.primary.Name)
+	}
+	return name, nil
+}
+
+// findByName returns the ID of the entity of the given kind with the given name.
+// If several incidents share a name, the most recent one is returned.
+func findByName(client *cachet.Client, kind, name string) (int, error) {
+	id := 0
+
+	switch kind {
+	case kindGroup:
+		groups, err := fetch.ComponentGroups(client, &cachet.ComponentGroupsQueryParams{Name: name})
+		if err != nil {
+			return 0, err
+		}
+		for _, g := range groups {
+			if g.Name == name {
+				id = g.ID
+				break
+			}
+		}
+	case kindComponent:
+		components, err := fetch.Components(client, &cachet.ComponentsQueryParams{Name: name})
+		if err != nil {
+			return 0, err
+		}
+		for _, co := range components {
+			if co.Name == name {
+				id = co.ID
+				break
+			}
+		}
+	case kindIncident:
+		incidents, err := fetch.Incidents(client, &cachet.IncidentsQueryParams{Name: name})
+		if err != nil {
+			return 0, err
+		}
+		for _, i := range incidents {
+			if i.Name == name && i.ID > id {
+				id = i.ID
+			}
+		}
+	}
+
+	if id == 0 {
+		return 0, fmt.Errorf("No %s named %q found", kind, name)
+	}
+	return id, nil
+}
+
+// mirrorError returns the error of the mirror m if err is a *PartialError.
+func mirrorError(err error, m *instance) error {
+	if e, ok := err.(*PartialError); ok {
+		return e.Errors[m.Name]
+	}
+	return nil
+}
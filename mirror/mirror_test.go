@@ -0,0 +1,78 @@
+package mirror
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// primaryMux and mirrorMux are the HTTP request multiplexers of the test servers.
+	primaryMux *http.ServeMux
+	mirrorMux  *http.ServeMux
+
+	// testClient is the mirror client being tested.
+	testClient *Client
+
+	// primaryServer and mirrorServer are test HTTP servers used to provide mock API responses.
+	primaryServer *httptest.Server
+	mirrorServer  *httptest.Server
+)
+
+// setup sets up a primary and a mirror test HTTP server along with a Client that is configured to talk to them.
+func setup() {
+	primaryMux = http.NewServeMux()
+	primaryServer = httptest.NewServer(primaryMux)
+	mirrorMux = http.NewServeMux()
+	mirrorServer = httptest.NewServer(mirrorMux)
+
+	primary, _ := cachet.NewClient(primaryServer.URL, nil)
+	mirror, _ := cachet.NewClient(mirrorServer.URL, nil)
+	testClient, _ = NewClient(Instance{Name: "primary", Client: primary}, Instance{Name: "mirror", Client: mirror})
+}
+
+// teardown closes the test HTTP servers.
+func teardown() {
+	primaryServer.Close()
+	mirrorServer.Close()
+}
+
+func testMethod(t *testing.T, r *http.Request, want string) {
+	if got := r.Method; got != want {
+		t.Errorf("Request method: %v, want %v", got, want)
+	}
+}
+
+func TestNewClient_Invalid(t *testing.T) {
+	c, _ := cachet.NewClient("https://status.example.com/", nil)
+
+	mockData := []struct {
+		primary Instance
+		mirrors []Instance
+	}{
+		{Instance{Name: "primary"}, nil},
+		{Instance{Name: "primary", Client: c}, []Instance{{Name: "mirror"}}},
+		{Instance{Name: "primary", Client: c}, []Instance{{Name: "primary", Client: c}}},
+	}
+
+	for _, data := range mockData {
+		if _, err := NewClient(data.primary, data.mirrors...); err == nil {
+			t.Errorf("NewClient(%+v, %+v) returned no error. Expected one.", data.primary, data.mirrors)
+		}
+	}
+}
+
+func TestPartialError_Error(t *testing.T) {
+	err := &PartialError{Errors: map[string]error{
+		"staging": errors.New("Unauthorized"),
+		"backup":  errors.New("Timeout"),
+	}}
+
+	expected := "Write failed on 2 mirror(s): backup: Timeout; staging: Unauthorized"
+	if got := err.Error(); got != expected {
+		t.Errorf("PartialError.Error returned %q, want %q", got, expected)
+	}
+}
@@ -0,0 +1,97 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+)
+
+// Conflict is an existing schedule that overlaps with a checked schedule.
+type Conflict struct {
+	// Schedule is the existing schedule.
+	Schedule cachet.Schedule
+	// Components contains the IDs of the components affected by both schedules.
+	Components []int
+	// Start and End are the time span both schedules overlap.
+	Start, End time.Time
+}
+
+func (c Conflict) String() string {
+	ids := make([]string, 0, len(c.Components))
+	for _, id := range c.Components {
+		ids = append(ids, fmt.Sprint(id))
+	}
+	return fmt.Sprintf("schedule %d %q overlaps from %s to %s on components %s",
+		c.Schedule.ID, c.Schedule.Name, c.Start.Format(api.TimestampLayout), c.End.Format(api.TimestampLayout), strings.Join(ids, ", "))
+}
+
+// ConflictError is returned if a schedule was rejected because of conflicts.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	msgs := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		msgs = append(msgs, c.String())
+	}
+	return fmt.Sprintf("Schedule conflicts with %d existing schedule(s): %s", len(e.Conflicts), strings.Join(msgs, "; "))
+}
+
+// window is the time span and the components of a schedule.
+type window struct {
+	start, end time.Time
+	components map[int]bool
+}
+
+// newWindow returns the window of s.
+// Schedules without CompletedAt last for defaultDuration.
+func newWindow(s *cachet.Schedule, loc *time.Location, defaultDuration time.Duration) (*window, error) {
+	start, err := time.ParseInLocation(api.TimestampLayout, s.ScheduledAt, loc)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid scheduled_at %q of schedule %q", s.ScheduledAt, s.Name)
+	}
+	end := start.Add(defaultDuration)
+	if len(s.CompletedAt) > 0 {
+		if end, err = time.ParseInLocation(api.TimestampLayout, s.CompletedAt, loc); err != nil {
+			return nil, fmt.Errorf("Invalid completed_at %q of schedule %q", s.CompletedAt, s.Name)
+		}
+	}
+
+	w := &window{start: start, end: end, components: map[int]bool{}}
+	for _, c := range s.Components {
+		w.components[c.ID] = true
+	}
+	return w, nil
+}
+
+// conflict returns the conflict of w with the existing schedule s or nil if they do not conflict.
+func (w *window) conflict(s cachet.Schedule, other *window) *Conflict {
+	if !w.start.Before(other.end) || !other.start.Before(w.end) {
+		return nil
+	}
+
+	var shared []int
+	for id := range w.components {
+		if other.components[id] {
+			shared = append(shared, id)
+		}
+	}
+	if len(shared) == 0 {
+		return nil
+	}
+	sort.Ints(shared)
+
+	c := &Conflict{Schedule: s, Components: shared, Start: w.start, End: w.end}
+	if other.start.After(c.Start) {
+		c.Start = other.start
+	}
+	if other.end.Before(c.End) {
+		c.End = other.end
+	}
+	return c
+}
@@ -0,0 +1,73 @@
+package planner
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+)
+
+func TestWindow_Conflict(t *testing.T) {
+	schedule := func(start, end string, components ...int) *cachet.Schedule {
+		s := &cachet.Schedule{ID: 9, Name: "Existing", ScheduledAt: "2017-10-10 " + start, CompletedAt: end}
+		if len(end) > 0 {
+			s.CompletedAt = "2017-10-10 " + end
+		}
+		for _, id := range components {
+			s.Components = append(s.Components, cachet.Component{ID: id})
+		}
+		return s
+	}
+	at := func(clock string) time.Time {
+		t, _ := time.Parse(api.TimestampLayout, "2017-10-10 "+clock)
+		return t
+	}
+
+	mockData := []struct {
+		checked, existing *cachet.Schedule
+		expected          *Conflict
+	}{
+		// Overlapping time span and shared component.
+		{
+			schedule("10:00:00", "12:00:00", 1, 2), schedule("11:00:00", "13:00:00", 2, 3),
+			&Conflict{Schedule: *schedule("11:00:00", "13:00:00", 2, 3), Components: []int{2}, Start: at("11:00:00"), End: at("12:00:00")},
+		},
+		// No shared component.
+		{schedule("10:00:00", "12:00:00", 1), schedule("11:00:00", "13:00:00", 2), nil},
+		// Adjacent time spans.
+		{schedule("10:00:00", "11:00:00", 1), schedule("11:00:00", "13:00:00", 1), nil},
+		// Without an end the default duration is used.
+		{
+			schedule("10:30:00", "", 1), schedule("10:00:00", "", 1),
+			&Conflict{Schedule: *schedule("10:00:00", "", 1), Components: []int{1}, Start: at("10:30:00"), End: at("11:00:00")},
+		},
+	}
+
+	for _, data := range mockData {
+		w, err := newWindow(data.checked, time.UTC, DefaultDuration)
+		if err != nil {
+			t.Fatalf("newWindow returned error: %v", err)
+		}
+		other, _ := newWindow(data.existing, time.UTC, DefaultDuration)
+		got := w.conflict(*data.existing, other)
+		if !reflect.DeepEqual(got, data.expected) {
+			t.Errorf("conflict of %+v and %+v returned %+v, want %+v", data.checked, data.existing, got, data.expected)
+		}
+	}
+}
+
+func TestConflictError_Error(t *testing.T) {
+	err := &ConflictError{Conflicts: []Conflict{{
+		Schedule:   cachet.Schedule{ID: 3, Name: "Upgrade"},
+		Components: []int{1, 2},
+		Start:      time.Date(2017, 10, 10, 10, 0, 0, 0, time.UTC),
+		End:        time.Date(2017, 10, 10, 11, 0, 0, 0, time.UTC),
+	}}}
+
+	expected := `Schedule conflicts with 1 existing schedule(s): schedule 3 "Upgrade" overlaps from 2017-10-10 10:00:00 to 2017-10-10 11:00:00 on components 1, 2`
+	if got := err.Error(); got != expected {
+		t.Errorf("ConflictError.Error returned %q, want %q", got, expected)
+	}
+}
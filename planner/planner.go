@@ -0,0 +1,266 @@
+/*
+Package planner checks schedules for conflicts before creating or updating them.
+
+Two schedules conflict if their time spans overlap and they share at least one component.
+Only upcoming and in progress schedules are taken into account.
+What happens with a conflicting schedule depends on the policy of the planner:
+
+	p := planner.NewPlanner(client, planner.Reject)
+
+	schedule, conflicts, err := p.Create(&cachet.Schedule{
+		Name:        "Database upgrade",
+		ScheduledAt: "2017-10-10 22:00:00",
+		CompletedAt: "2017-10-10 23:00:00",
+		Components:  []cachet.Component{{ID: 1}},
+	})
+
+Reject returns a *ConflictError, Warn writes the schedule anyway and returns the conflicts,
+Merge merges the schedule into the conflicting one.
+*/
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// DefaultDuration is the duration of schedules without CompletedAt.
+const DefaultDuration = time.Hour
+
+// Policy decides what happens with conflicting schedules.
+type Policy int
+
+const (
+	// Reject refuses to write conflicting schedules.
+	Reject Policy = iota
+	// Warn writes conflicting schedules and returns the conflicts.
+	Warn
+	// Merge combines the time span and the components of a conflicting schedule and the existing one.
+	// Created schedules are merged into the existing one,
+	// the existing one is merged into updated schedules and deleted afterwards.
+	// Schedules that conflict with more than one schedule are rejected.
+	Merge
+)
+
+// Planner creates and updates schedules after checking them for conflicts.
+type Planner struct {
+	client *cachet.Client
+
+	Policy Policy
+	// Location is the time zone of the Cachet instance. It defaults to UTC.
+	Location *time.Location
+	// DefaultDuration is used for schedules without CompletedAt. It defaults to DefaultDuration.
+	DefaultDuration time.Duration
+}
+
+// NewPlanner returns a new planner with the given policy.
+func NewPlanner(client *cachet.Client, policy Policy) *Planner {
+	return &Planner{client: client, Policy: policy}
+}
+
+func (p *Planner) location() *time.Location {
+	if p.Location == nil {
+		return time.UTC
+	}
+	return p.Location
+}
+
+func (p *Planner) defaultDuration() time.Duration {
+	if p.DefaultDuration == 0 {
+		return DefaultDuration
+	}
+	return p.DefaultDuration
+}
+
+// Check returns the upcoming and in progress schedules that conflict with s.
+// The schedule with the ID ignoreID is skipped, e.g. the schedule being updated.
+func (p *Planner) Check(s *cachet.Schedule, ignoreID int) ([]Conflict, error) {
+	w, err := newWindow(s, p.location(), p.defaultDuration())
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, err := fetch.Schedules(p.client, nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+
+	var conflicts []Conflict
+	for _, existing := range schedules {
+		if existing.ID == ignoreID || existing.Status == cachet.ScheduleComplete {
+			continue
+		}
+		other, err := newWindow(&existing, p.location(), p.defaultDuration())
+		if err != nil {
+			// Schedules the API returns without a valid time can not conflict.
+			continue
+		}
+		if c := w.conflict(existing, other); c != nil {
+			conflicts = append(conflicts, *c)
+		}
+	}
+	return conflicts, nil
+}
+
+// Create creates a schedule after checking it for conflicts.
+// With the policy Merge, the returned schedule is the existing schedule s was merged into.
+func (p *Planner) Create(s *cachet.Schedule) (*cachet.Schedule, []Conflict, error) {
+	conflicts, err := p.Check(s, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(conflicts) > 0 {
+		switch p.Policy {
+		case Reject:
+			return nil, conflicts, &ConflictError{Conflicts: conflicts}
+		case Merge:
+			merged, err := p.merge(s, conflicts)
+			return merged, conflicts, err
+		}
+	}
+
+	body := &api.Schedule{
+		Name:        s.Name,
+		Message:     s.Message,
+		Status:      s.Status,
+		ScheduledAt: s.ScheduledAt,
+		CompletedAt: s.CompletedAt,
+		Components:  s.Components,
+	}
+	created, err := api.CreateSchedule(p.client, body)
+	if err != nil {
+		return nil, conflicts, err
+	}
+	return created, conflicts, nil
+}
+
+// Update updates a schedule after checking it for conflicts.
+// Empty fields of s keep the current value of the schedule.
+// With the policy Merge, the conflicting schedule is merged into the updated one and deleted afterwards.
+func (p *Planner) Update(id int, s *cachet.Schedule) (*cachet.Schedule, []Conflict, error) {
+	current, _, err := p.client.Schedules.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if current == nil {
+		return nil, nil, fmt.Errorf("Empty response from the Cachet API")
+	}
+
+	updated := *current
+	if len(s.Name) > 0 {
+		updated.Name = s.Name
+	}
+	if len(s.Message) > 0 {
+		updated.Message = s.Message
+	}
+	if s.Status > 0 {
+		updated.Status = s.Status
+	}
+	if len(s.ScheduledAt) > 0 {
+		updated.ScheduledAt = s.ScheduledAt
+	}
+	if len(s.CompletedAt) > 0 {
+		updated.CompletedAt = s.CompletedAt
+	}
+	if s.Components != nil {
+		updated.Components = s.Components
+	}
+
+	conflicts, err := p.Check(&updated, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(conflicts) > 0 {
+		switch p.Policy {
+		case Reject:
+			return nil, conflicts, &ConflictError{Conflicts: conflicts}
+		case Merge:
+			if len(conflicts) > 1 {
+				return nil, conflicts, &ConflictError{Conflicts: conflicts}
+			}
+			other := conflicts[0].Schedule
+			merged, err := p.combine(&updated, &other)
+			if err != nil {
+				return nil, conflicts, err
+			}
+			merged.Name, merged.Status = s.Name, s.Status
+
+			v, _, err := p.client.Schedules.Update(id, merged)
+			if err != nil {
+				return nil, conflicts, fmt.Errorf("Failed to merge schedule %d into schedule %d: %v", other.ID, id, err)
+			}
+			if _, err := p.client.Schedules.Delete(other.ID); err != nil {
+				return v, conflicts, fmt.Errorf("Failed to delete schedule %d after merging it into schedule %d: %v", other.ID, id, err)
+			}
+			return v, conflicts, nil
+		}
+	}
+
+	v, _, err := p.client.Schedules.Update(id, s)
+	return v, conflicts, err
+}
+
+// merge merges s into the only conflicting schedule.
+func (p *Planner) merge(s *cachet.Schedule, conflicts []Conflict) (*cachet.Schedule, error) {
+	if len(conflicts) > 1 {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+	existing := conflicts[0].Schedule
+
+	merged, err := p.combine(&existing, s)
+	if err != nil {
+		return nil, err
+	}
+	v, _, err := p.client.Schedules.Update(existing.ID, merged)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to merge into schedule %d: %v", existing.ID, err)
+	}
+	return v, nil
+}
+
+// combine returns the changes that merge the schedule b into a:
+// The time span covers both schedules, the components of both are combined
+// and the message of b is appended to the message of a.
+func (p *Planner) combine(a, b *cachet.Schedule) (*cachet.Schedule, error) {
+	loc, d := p.location(), p.defaultDuration()
+	wa, err := newWindow(a, loc, d)
+	if err != nil {
+		return nil, err
+	}
+	wb, err := newWindow(b, loc, d)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &cachet.Schedule{
+		Message:     a.Message,
+		ScheduledAt: a.ScheduledAt,
+		CompletedAt: a.CompletedAt,
+	}
+	if wb.start.Before(wa.start) {
+		merged.ScheduledAt = b.ScheduledAt
+	}
+	if wb.end.After(wa.end) {
+		merged.CompletedAt = wb.end.In(loc).Format(api.TimestampLayout)
+	}
+	if len(b.Message) > 0 && b.Message != a.Message {
+		merged.Message = a.Message + "\n\n" + b.Message
+	}
+
+	ids := map[int]bool{}
+	for _, c := range append(append([]cachet.Component(nil), a.Components...), b.Components...) {
+		if !ids[c.ID] {
+			ids[c.ID] = true
+			merged.Components = append(merged.Components, cachet.Component{ID: c.ID})
+		}
+	}
+	return merged, nil
+}
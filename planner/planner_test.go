@@ -0,0 +1,217 @@
+package planner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func testMethod(t *testing.T, r *http.Request, want string) {
+	if got := r.Method; got != want {
+		t.Errorf("Request method: %v, want %v", got, want)
+	}
+}
+
+// handleSchedules serves the existing schedules and records created schedules.
+func handleSchedules(t *testing.T, created *[]map[string]interface{}) {
+	testMux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":[
+				{"id":1,"name":"Upgrade","message":"Upgrade","status":0,"scheduled_at":"2017-10-10 10:00:00","completed_at":"2017-10-10 12:00:00","components":[{"id":1}]},
+				{"id":2,"name":"Done","status":2,"scheduled_at":"2017-10-10 11:00:00","completed_at":"2017-10-10 12:00:00","components":[{"id":1}]}
+			]}`)
+			return
+		}
+		testMethod(t, r, "POST")
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		*created = append(*created, body)
+		fmt.Fprint(w, `{"data":{"id":3}}`)
+	})
+}
+
+var testSchedule = &cachet.Schedule{
+	Name:        "Patching",
+	Message:     "Patching",
+	ScheduledAt: "2017-10-10 11:00:00",
+	CompletedAt: "2017-10-10 13:00:00",
+	Components:  []cachet.Component{{ID: 1}, {ID: 2}},
+}
+
+func TestPlanner_Create_Reject(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var created []map[string]interface{}
+	handleSchedules(t, &created)
+
+	_, conflicts, err := NewPlanner(testClient, Reject).Create(testSchedule)
+	if _, ok := err.(*ConflictError); !ok {
+		t.Fatalf("Create returned error %v, want a *ConflictError", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Schedule.ID != 1 {
+		t.Errorf("Create returned conflicts %+v, want a conflict with schedule 1", conflicts)
+	}
+	if len(created) > 0 {
+		t.Errorf("Create created %+v, want no schedule", created)
+	}
+}
+
+func TestPlanner_Create_Warn(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var created []map[string]interface{}
+	handleSchedules(t, &created)
+
+	got, conflicts, err := NewPlanner(testClient, Warn).Create(testSchedule)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if got.ID != 3 || len(conflicts) != 1 {
+		t.Errorf("Create returned schedule %+v with conflicts %+v, want schedule 3 with one conflict", got, conflicts)
+	}
+	if len(created) != 1 || created[0]["status"] != float64(0) {
+		t.Errorf("Create created %+v, want one upcoming schedule", created)
+	}
+}
+
+func TestPlanner_Create_Merge(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var created []map[string]interface{}
+	handleSchedules(t, &created)
+	testMux.HandleFunc("/api/v1/schedules/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		body := &cachet.Schedule{}
+		json.NewDecoder(r.Body).Decode(body)
+		expected := &cachet.Schedule{
+			Message:     "Upgrade\n\nPatching",
+			ScheduledAt: "2017-10-10 10:00:00",
+			CompletedAt: "2017-10-10 13:00:00",
+			Components:  []cachet.Component{{ID: 1}, {ID: 2}},
+		}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":1}}`)
+	})
+
+	got, _, err := NewPlanner(testClient, Merge).Create(testSchedule)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if got.ID != 1 {
+		t.Errorf("Create returned schedule %d, want the merged schedule 1", got.ID)
+	}
+	if len(created) > 0 {
+		t.Errorf("Create created %+v, want no schedule", created)
+	}
+}
+
+func TestPlanner_Update(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var created []map[string]interface{}
+	handleSchedules(t, &created)
+	testMux.HandleFunc("/api/v1/schedules/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":{"id":1,"name":"Upgrade","status":0,"scheduled_at":"2017-10-10 10:00:00","completed_at":"2017-10-10 12:00:00","components":[{"id":1}]}}`)
+			return
+		}
+		testMethod(t, r, "PUT")
+		fmt.Fprint(w, `{"data":{"id":1,"name":"Upgrade"}}`)
+	})
+
+	// The schedule does not conflict with itself or the complete schedule.
+	got, conflicts, err := NewPlanner(testClient, Reject).Update(1, &cachet.Schedule{CompletedAt: "2017-10-10 14:00:00"})
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if got.ID != 1 || len(conflicts) > 0 {
+		t.Errorf("Update returned schedule %+v with conflicts %+v, want schedule 1 without conflicts", got, conflicts)
+	}
+}
+
+func TestPlanner_Update_Merge(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var created []map[string]interface{}
+	handleSchedules(t, &created)
+
+	var requests []string
+	testMux.HandleFunc("/api/v1/schedules/5", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":{"id":5,"name":"Patching","message":"Patching","status":0,"scheduled_at":"2017-10-10 07:00:00","completed_at":"2017-10-10 08:00:00","components":[{"id":2}]}}`)
+			return
+		}
+		testMethod(t, r, "PUT")
+		body := &cachet.Schedule{}
+		json.NewDecoder(r.Body).Decode(body)
+		expected := &cachet.Schedule{
+			Name:        "Patching v2",
+			Message:     "Patching\n\nUpgrade",
+			ScheduledAt: "2017-10-10 10:00:00",
+			CompletedAt: "2017-10-10 13:00:00",
+			Components:  []cachet.Component{{ID: 2}, {ID: 1}},
+		}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		requests = append(requests, "update 5")
+		fmt.Fprint(w, `{"data":{"id":5}}`)
+	})
+	testMux.HandleFunc("/api/v1/schedules/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		requests = append(requests, "delete 1")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// Moving schedule 5 to 11:00 makes it overlap with schedule 1, which is merged into it.
+	got, conflicts, err := NewPlanner(testClient, Merge).Update(5, &cachet.Schedule{
+		Name:        "Patching v2",
+		ScheduledAt: "2017-10-10 11:00:00",
+		CompletedAt: "2017-10-10 13:00:00",
+		Components:  []cachet.Component{{ID: 2}, {ID: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if got.ID != 5 || len(conflicts) != 1 || conflicts[0].Schedule.ID != 1 {
+		t.Errorf("Update returned schedule %+v with conflicts %+v, want schedule 5 with a conflict with schedule 1", got, conflicts)
+	}
+	if expected := []string{"update 5", "delete 1"}; !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Update made the requests %v, want %v", requests, expected)
+	}
+}
@@ -0,0 +1,262 @@
+/*
+Package postmortem generates postmortem documents from the timeline of an incident.
+
+The incident and all its updates are fetched and rendered as Markdown
+with a Go text/template. The default template contains a summary, the timeline
+with timestamps, statuses, component status changes and messages as well as
+placeholders for the root cause and action items:
+
+	d, err := postmortem.Fetch(client, 42, nil)
+	d.ActionItems = []string{"Add an alert for the queue length"}
+	err = postmortem.Render(os.Stdout, d, nil)
+
+A custom template gets the *Document as data.
+*/
+package postmortem
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+	"github.com/andygrunwald/cachet/status"
+)
+
+// DefaultTemplate is the Markdown template of postmortem documents.
+const DefaultTemplate = `# Postmortem: {{.Incident.Name}}
+
+## Summary
+
+| | |
+|---|---|
+| Incident | #{{.Incident.ID}} |
+| Component | {{if .Component}}{{.Component.Name}}{{else}}-{{end}} |
+| Started | {{time .Started}} |
+| Resolved | {{if .Resolved.IsZero}}unresolved{{else}}{{time .Resolved}}{{end}} |
+| Duration | {{duration .Duration}} |
+
+{{.Incident.Message}}
+
+## Timeline
+
+| Time | Since start | Status | Component status | Message |
+|---|---|---|---|---|
+{{range .Timeline}}| {{time .Time}} | {{duration .Since}} | {{.HumanStatus}} | {{if .ComponentStatusChanged}}**{{componentStatus .ComponentStatus}}**{{else}}{{componentStatus .ComponentStatus}}{{end}} | {{cell .Message}} |
+{{end}}
+## Root cause
+
+{{if .RootCause}}{{.RootCause}}{{else}}_TODO: Describe the root cause._{{end}}
+
+## Action items
+
+{{range .ActionItems}}- [ ] {{.}}
+{{else}}- [ ] _TODO: Add action items._
+{{end}}`
+
+// Options configures the generator.
+type Options struct {
+	// Template overrides DefaultTemplate.
+	Template string
+	// Location is the time zone of the Cachet instance and of the document. It defaults to UTC.
+	Location *time.Location
+	// Now is the end of unresolved incidents. It defaults to the current time.
+	Now time.Time
+}
+
+// Document is the data of a postmortem.
+type Document struct {
+	Incident cachet.Incident
+	// Component is the affected component or nil.
+	Component *cachet.Component
+	Started   time.Time
+	// Resolved is zero for unresolved incidents.
+	Resolved time.Time
+	// Duration is the time until resolved or, for unresolved incidents, until now.
+	Duration time.Duration
+	Timeline []Event
+
+	// RootCause and ActionItems fill the placeholders of the template.
+	RootCause   string
+	ActionItems []string
+}
+
+// Event is an entry of the timeline: the creation of the incident or an update.
+type Event struct {
+	Time time.Time
+	// Since is the time since the incident started.
+	Since       time.Duration
+	Status      int
+	HumanStatus string
+	// ComponentStatus is the status of the component after the event.
+	ComponentStatus int
+	// ComponentStatusChanged reports whether the event changed the component status.
+	ComponentStatusChanged bool
+	Message                string
+}
+
+// Fetch fetches the incident with the ID id, its updates and its component and builds the document.
+func Fetch(client *cachet.Client, id int, o *Options) (*Document, error) {
+	i, _, err := client.Incidents.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if i == nil {
+		return nil, fmt.Errorf("Empty response from the Cachet API")
+	}
+
+	updates, err := fetch.IncidentUpdates(client, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var component *cachet.Component
+	if i.ComponentID > 0 {
+		if component, _, err = client.Components.Get(i.ComponentID); err != nil {
+			return nil, err
+		}
+	}
+
+	return Build(*i, updates, component, o), nil
+}
+
+// Build builds the document of an incident from its updates.
+// A reopened incident is resolved by the last fixed update.
+func Build(i cachet.Incident, updates []cachet.IncidentUpdate, component *cachet.Component, o *Options) *Document {
+	if o == nil {
+		o = &Options{}
+	}
+	loc := api.Location(o.Location)
+
+	updates = append([]cachet.IncidentUpdate(nil), updates...)
+	sort.SliceStable(updates, func(a, b int) bool {
+		return api.ParseTime(loc, updates[a].CreatedAt).Before(api.ParseTime(loc, updates[b].CreatedAt))
+	})
+
+	d := &Document{Incident: i, Component: component, Started: api.ParseTime(loc, i.OccurredAt, i.CreatedAt)}
+
+	// The status of the incident itself is its latest status, the timeline starts with the first one.
+	first := i.Status
+	if len(updates) > 0 {
+		first = cachet.IncidentStatusInvestigating
+	}
+	componentStatus := i.ComponentStatus
+	d.Timeline = append(d.Timeline, Event{
+		Time:                   d.Started,
+		Status:                 first,
+		HumanStatus:            humanStatus(first, ""),
+		ComponentStatus:        componentStatus,
+		ComponentStatusChanged: componentStatus > 0,
+		Message:                i.Message,
+	})
+
+	for _, u := range updates {
+		e := Event{
+			Time:            api.ParseTime(loc, u.CreatedAt),
+			Status:          u.Status,
+			HumanStatus:     humanStatus(u.Status, u.HumanStatus),
+			ComponentStatus: componentStatus,
+			Message:         u.Message,
+		}
+		if u.ComponentStatus > 0 && u.ComponentStatus != componentStatus {
+			componentStatus = u.ComponentStatus
+			e.ComponentStatus = componentStatus
+			e.ComponentStatusChanged = true
+		}
+		e.Since = api.Since(d.Started, e.Time)
+		d.Timeline = append(d.Timeline, e)
+
+		if u.Status == cachet.IncidentStatusFixed {
+			d.Resolved = e.Time
+		} else {
+			d.Resolved = time.Time{}
+		}
+	}
+	if d.Resolved.IsZero() && (i.IsResolved || i.Status == cachet.IncidentStatusFixed) {
+		d.Resolved = api.ParseTime(loc, i.UpdatedAt, i.CreatedAt)
+	}
+
+	if d.Resolved.IsZero() {
+		d.Duration = api.Since(d.Started, api.Now(o.Now))
+	} else {
+		d.Duration = api.Since(d.Started, d.Resolved)
+	}
+	return d
+}
+
+// Render writes the document rendered with the template of o to w.
+func Render(w io.Writer, d *Document, o *Options) error {
+	if o == nil {
+		o = &Options{}
+	}
+	text := DefaultTemplate
+	if len(o.Template) > 0 {
+		text = o.Template
+	}
+
+	loc := api.Location(o.Location)
+	t, err := template.New("postmortem").Funcs(template.FuncMap{
+		"time":            func(t time.Time) string { return t.In(loc).Format("2006-01-02 15:04 MST") },
+		"duration":        formatDuration,
+		"componentStatus": status.HumanStatus,
+		"cell":            cell,
+	}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("Failed to parse the postmortem template: %v", err)
+	}
+	return t.Execute(w, d)
+}
+
+// Generate fetches the incident with the ID id and writes its postmortem to w.
+func Generate(client *cachet.Client, id int, w io.Writer, o *Options) error {
+	d, err := Fetch(client, id, o)
+	if err != nil {
+		return err
+	}
+	return Render(w, d, o)
+}
+
+// humanStatus returns the human status of the Cachet API or the name of the incident status.
+func humanStatus(s int, human string) string {
+	if len(human) > 0 {
+		return human
+	}
+	return status.HumanIncidentStatus(s)
+}
+
+// formatDuration formats d rounded to minutes, e.g. 1h30m.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "0m"
+	}
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	}
+	return fmt.Sprintf("%dh%dm", h, m)
+}
+
+// cell makes s usable in a cell of a Markdown table.
+func cell(s string) string {
+	var buf []rune
+	for _, r := range s {
+		switch r {
+		case '|':
+			buf = append(buf, '\\', '|')
+		case '\r':
+		case '\n':
+			buf = append(buf, ' ')
+		default:
+			buf = append(buf, r)
+		}
+	}
+	return string(buf)
+}
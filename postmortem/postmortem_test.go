@@ -0,0 +1,158 @@
+package postmortem
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+// handleIncident serves a fixed incident with two pages of updates on the component API.
+func handleIncident() {
+	testMux.HandleFunc("/api/v1/incidents/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":1,"name":"API down","message":"Requests fail","status":4,"component_id":2,"component_status":4,
+			"occurred_at":"2017-10-10 10:00:00","updated_at":"2017-10-10 12:30:00"}}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents/1/updates", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":1,"total_pages":2}},"data":[
+				{"id":3,"status":4,"human_status":"Fixed","component_status":1,"message":"Back to normal","created_at":"2017-10-10 12:30:00"}
+			]}`)
+		case "2":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":2,"total_pages":2}},"data":[
+				{"id":2,"status":2,"human_status":"Identified","component_status":3,"message":"Database | overloaded","created_at":"2017-10-10 10:45:00"}
+			]}`)
+		}
+	})
+	testMux.HandleFunc("/api/v1/components/2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":2,"name":"API"}}`)
+	})
+}
+
+func TestFetch(t *testing.T) {
+	setup()
+	defer teardown()
+	handleIncident()
+
+	d, err := Fetch(testClient, 1, nil)
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+
+	if d.Component == nil || d.Component.Name != "API" {
+		t.Errorf("Fetch returned component %+v, want API", d.Component)
+	}
+	if d.Duration != 150*time.Minute {
+		t.Errorf("Fetch returned duration %v, want %v", d.Duration, 150*time.Minute)
+	}
+	if len(d.Timeline) != 3 {
+		t.Fatalf("Fetch returned %d events, want 3", len(d.Timeline))
+	}
+	if e := d.Timeline[0]; e.HumanStatus != "Investigating" || e.ComponentStatus != cachet.ComponentStatusMajorOutage || !e.ComponentStatusChanged {
+		t.Errorf("Fetch returned first event %+v", e)
+	}
+	if e := d.Timeline[1]; e.Since != 45*time.Minute || e.ComponentStatus != cachet.ComponentStatusPartialOutage {
+		t.Errorf("Fetch returned second event %+v", e)
+	}
+}
+
+func TestBuild_Unresolved(t *testing.T) {
+	i := cachet.Incident{ID: 1, Status: cachet.IncidentStatusIdentified, OccurredAt: "2017-10-10 10:00:00"}
+	now := time.Date(2017, 10, 10, 11, 0, 0, 0, time.UTC)
+
+	d := Build(i, nil, nil, &Options{Now: now})
+	if !d.Resolved.IsZero() || d.Duration != time.Hour {
+		t.Errorf("Build returned resolved %v and duration %v, want unresolved and %v", d.Resolved, d.Duration, time.Hour)
+	}
+	if e := d.Timeline[0]; e.HumanStatus != "Identified" || e.ComponentStatusChanged {
+		t.Errorf("Build returned first event %+v", e)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	setup()
+	defer teardown()
+	handleIncident()
+
+	var buf bytes.Buffer
+	if err := Generate(testClient, 1, &buf, nil); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	got := buf.String()
+
+	expected := []string{
+		"# Postmortem: API down",
+		"| Component | API |",
+		"| Duration | 2h30m |",
+		"| 2017-10-10 10:00 UTC | 0m | Investigating | **Major Outage** | Requests fail |",
+		"| 2017-10-10 10:45 UTC | 45m | Identified | **Partial Outage** | Database \\| overloaded |",
+		"| 2017-10-10 12:30 UTC | 2h30m | Fixed | **Operational** | Back to normal |",
+		"_TODO: Describe the root cause._",
+		"- [ ] _TODO: Add action items._",
+	}
+	for _, e := range expected {
+		if !strings.Contains(got, e) {
+			t.Errorf("Generate returned\n%s\nwithout %q", got, e)
+		}
+	}
+}
+
+func TestRender_Custom(t *testing.T) {
+	d := &Document{
+		Incident:    cachet.Incident{Name: "API down"},
+		RootCause:   "A full disk",
+		ActionItems: []string{"Monitor disks", "Rotate logs"},
+		Duration:    90 * time.Minute,
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, d, nil); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	for _, e := range []string{"A full disk", "- [ ] Monitor disks\n- [ ] Rotate logs\n", "| Resolved | unresolved |", "| Duration | 1h30m |"} {
+		if !strings.Contains(buf.String(), e) {
+			t.Errorf("Render returned\n%s\nwithout %q", buf.String(), e)
+		}
+	}
+
+	buf.Reset()
+	if err := Render(&buf, d, &Options{Template: "{{.Incident.Name}} took {{duration .Duration}}"}); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if got, want := buf.String(), "API down took 1h30m"; got != want {
+		t.Errorf("Render returned %q, want %q", got, want)
+	}
+
+	if err := Render(&buf, d, &Options{Template: "{{.Incident"}); err == nil {
+		t.Error("Render returned no error for an invalid template")
+	}
+}
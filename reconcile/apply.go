@@ -0,0 +1,153 @@
+package reconcile
+
+import (
+	"fmt"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+)
+
+// Apply executes the changes of the plan in order.
+// It stops at the first failing change. Changes applied before remain in place.
+func (p *Plan) Apply(client *cachet.Client) error {
+	groupIDs := map[string]int{}
+	for name, id := range p.groupIDs {
+		groupIDs[name] = id
+	}
+
+	for i := range p.Changes {
+		c := &p.Changes[i]
+		if err := c.apply(client, groupIDs); err != nil {
+			return fmt.Errorf("Failed to %s %s %q: %v", c.Action, c.Kind, c.Name, err)
+		}
+	}
+	return nil
+}
+
+// apply executes a single change.
+// groupIDs maps the names of component groups to their IDs and is extended by created groups.
+func (c *Change) apply(client *cachet.Client, groupIDs map[string]int) error {
+	switch c.Kind {
+	case KindGroup:
+		return c.applyGroup(client, groupIDs)
+	case KindComponent:
+		return c.applyComponent(client, groupIDs)
+	case KindMetric:
+		return c.applyMetric(client)
+	}
+	return fmt.Errorf("Unknown kind %q", c.Kind)
+}
+
+func (c *Change) applyGroup(client *cachet.Client, groupIDs map[string]int) error {
+	if c.Action == ActionDelete {
+		_, err := client.ComponentGroups.Delete(c.ID)
+		return err
+	}
+
+	body := &api.ComponentGroup{
+		Name:      c.group.Name,
+		Order:     c.group.Order,
+		Collapsed: c.group.Collapsed,
+		Visible:   c.group.visible(),
+	}
+	v := &struct {
+		Data *cachet.ComponentGroup `json:"data"`
+	}{}
+
+	if c.Action == ActionUpdate {
+		_, err := client.Call("PUT", fmt.Sprintf("api/v1/components/groups/%d", c.ID), body, v)
+		return err
+	}
+
+	if _, err := client.Call("POST", "api/v1/components/groups", body, v); err != nil {
+		return err
+	}
+	if v.Data != nil {
+		c.ID = v.Data.ID
+		groupIDs[c.Name] = v.Data.ID
+	}
+	return nil
+}
+
+func (c *Change) applyComponent(client *cachet.Client, groupIDs map[string]int) error {
+	if c.Action == ActionDelete {
+		_, err := client.Components.Delete(c.ID)
+		return err
+	}
+
+	body := &api.Component{
+		Name:        c.component.Name,
+		Description: c.component.Description,
+		Link:        c.component.Link,
+		Order:       c.component.Order,
+		Enabled:     c.component.enabled(),
+	}
+	if c.component.Status > 0 {
+		status := c.component.Status
+		body.Status = &status
+	}
+	if len(c.component.Group) > 0 {
+		id, ok := groupIDs[c.component.Group]
+		if !ok {
+			return fmt.Errorf("Unknown group %q", c.component.Group)
+		}
+		body.GroupID = id
+	}
+	v := &struct {
+		Data *cachet.Component `json:"data"`
+	}{}
+
+	if c.Action == ActionUpdate {
+		_, err := client.Call("PUT", fmt.Sprintf("api/v1/components/%d", c.ID), body, v)
+		return err
+	}
+
+	if body.Status == nil {
+		status := cachet.ComponentStatusOperational
+		body.Status = &status
+	}
+	if _, err := client.Call("POST", "api/v1/components", body, v); err != nil {
+		return err
+	}
+	if v.Data != nil {
+		c.ID = v.Data.ID
+	}
+	return nil
+}
+
+func (c *Change) applyMetric(client *cachet.Client) error {
+	if c.Action == ActionDelete {
+		_, err := client.Metrics.Delete(c.ID)
+		return err
+	}
+
+	body := &api.Metric{
+		Name:         c.metric.Name,
+		Suffix:       c.metric.Suffix,
+		Description:  c.metric.Description,
+		DefaultValue: c.metric.DefaultValue,
+		CalcType:     c.metric.CalcType,
+		DisplayChart: c.metric.DisplayChart,
+		Places:       c.metric.Places,
+		DefaultView:  c.metric.DefaultView,
+		Threshold:    c.metric.Threshold,
+		Order:        c.metric.Order,
+		Visible:      c.metric.visible(),
+	}
+	v := &struct {
+		Data *cachet.Metric `json:"data"`
+	}{}
+
+	if c.Action == ActionUpdate {
+		_, err := client.Call("PUT", fmt.Sprintf("api/v1/metrics/%d", c.ID), body, v)
+		return err
+	}
+
+	if _, err := client.Call("POST", "api/v1/metrics", body, v); err != nil {
+		return err
+	}
+	if v.Data != nil {
+		c.ID = v.Data.ID
+	}
+	return nil
+}
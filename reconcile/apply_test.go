@@ -0,0 +1,130 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func testMethod(t *testing.T, r *http.Request, want string) {
+	if got := r.Method; got != want {
+		t.Errorf("Request method: %v, want %v", got, want)
+	}
+}
+
+func testBody(t *testing.T, r *http.Request, want map[string]interface{}) {
+	got := map[string]interface{}{}
+	json.NewDecoder(r.Body).Decode(&got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Request body is %+v, want %+v", got, want)
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var calls []string
+	testMux.HandleFunc("/api/v1/components/groups", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":[{"id":1,"name":"Legacy","visible":1}]}`)
+			return
+		}
+		testMethod(t, r, "POST")
+		testBody(t, r, map[string]interface{}{"name": "Backends", "order": float64(1), "collapsed": float64(0), "visible": float64(1)})
+		calls = append(calls, "create group")
+		fmt.Fprint(w, `{"data":{"id":7,"name":"Backends"}}`)
+	})
+	testMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":[{"id":3,"name":"API","group_id":1,"enabled":true,"status":1}]}`)
+			return
+		}
+		t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+	})
+	testMux.HandleFunc("/api/v1/components/3", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		testBody(t, r, map[string]interface{}{"name": "API", "description": "", "link": "", "order": float64(0), "group_id": float64(7), "enabled": false})
+		calls = append(calls, "update component")
+		fmt.Fprint(w, `{"data":{"id":3,"name":"API"}}`)
+	})
+	testMux.HandleFunc("/api/v1/components/groups/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		calls = append(calls, "delete group")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	testMux.HandleFunc("/api/v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":[]}`)
+			return
+		}
+		testMethod(t, r, "POST")
+		calls = append(calls, "create metric")
+		fmt.Fprint(w, `{"data":{"id":2,"name":"Response time"}}`)
+	})
+
+	disabled := false
+	spec := &Spec{
+		Groups:     []GroupSpec{{Name: "Backends", Order: 1}},
+		Components: []ComponentSpec{{Name: "API", Group: "Backends", Enabled: &disabled}},
+		Metrics:    []MetricSpec{{Name: "Response time"}},
+	}
+
+	p, err := NewPlan(testClient, spec, &Options{Prune: true})
+	if err != nil {
+		t.Fatalf("NewPlan returned error: %v", err)
+	}
+	if err := p.Apply(testClient); err != nil {
+		t.Fatalf("Plan.Apply returned error: %v", err)
+	}
+
+	expected := []string{"create group", "update component", "create metric", "delete group"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Plan.Apply made the calls %v, want %v", calls, expected)
+	}
+	if p.Changes[0].ID != 7 {
+		t.Errorf("Plan.Apply set the ID of the created group to %d, want 7", p.Changes[0].ID)
+	}
+}
+
+func TestApply_Error(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/components/1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	})
+
+	p := &Plan{Changes: []Change{{Action: ActionDelete, Kind: KindComponent, Name: "API", ID: 1}}}
+	if err := p.Apply(testClient); err == nil {
+		t.Error("Plan.Apply returned no error. Expected one.")
+	}
+}
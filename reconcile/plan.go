@@ -0,0 +1,287 @@
+package reconcile
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+const (
+	// ActionCreate means "The entity is part of the spec but does not exist."
+	ActionCreate = "create"
+	// ActionUpdate means "The entity exists but differs from the spec."
+	ActionUpdate = "update"
+	// ActionDelete means "The entity exists but is not part of the spec (only with pruning)."
+	ActionDelete = "delete"
+
+	// KindGroup is the kind of component group changes
+	KindGroup = "group"
+	// KindComponent is the kind of component changes
+	KindComponent = "component"
+	// KindMetric is the kind of metric changes
+	KindMetric = "metric"
+)
+
+// Options configures how a plan is built.
+type Options struct {
+	// Prune deletes entities that are not part of the spec.
+	Prune bool
+}
+
+// Plan contains the changes to bring a Cachet instance in the state of a spec.
+// The changes are ordered by their dependencies and are applied in this order.
+type Plan struct {
+	Changes []Change
+
+	// groupIDs maps the names of existing component groups to their IDs.
+	groupIDs map[string]int
+}
+
+// Change is a single create, update or delete of an entity.
+type Change struct {
+	// Action is one of ActionCreate, ActionUpdate or ActionDelete.
+	Action string
+	// Kind is one of KindGroup, KindComponent or KindMetric.
+	Kind string
+	// Name of the entity.
+	Name string
+	// ID of the existing entity. It is set after the entity was created by Plan.Apply.
+	ID int
+	// Diffs contains the changed fields of an update.
+	Diffs []Diff
+
+	group     *GroupSpec
+	component *ComponentSpec
+	metric    *MetricSpec
+}
+
+// Diff is a single changed field of an entity.
+type Diff struct {
+	Field string
+	From  interface{}
+	To    interface{}
+}
+
+// NewPlan compares spec with the live instance of client and returns the necessary changes.
+func NewPlan(client *cachet.Client, spec *Spec, o *Options) (*Plan, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	groups, err := fetch.ComponentGroups(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	components, err := fetch.Components(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := fetch.Metrics(client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPlan(spec, groups, components, metrics, o)
+}
+
+// newPlan compares spec with the given live entities and returns the necessary changes.
+func newPlan(spec *Spec, groups []cachet.ComponentGroup, components []cachet.Component, metrics []cachet.Metric, o *Options) (*Plan, error) {
+	if o == nil {
+		o = &Options{}
+	}
+
+	p := &Plan{groupIDs: map[string]int{}}
+	var deletes []Change
+
+	// Component groups
+	groupNames := map[int]string{}
+	liveGroups := map[string]cachet.ComponentGroup{}
+	for _, g := range groups {
+		groupNames[g.ID] = g.Name
+		if _, ok := liveGroups[g.Name]; !ok {
+			liveGroups[g.Name] = g
+			p.groupIDs[g.Name] = g.ID
+		}
+	}
+
+	specGroups := map[string]bool{}
+	matched := map[int]bool{}
+	for i := range spec.Groups {
+		g := &spec.Groups[i]
+		specGroups[g.Name] = true
+		live, ok := liveGroups[g.Name]
+		if !ok {
+			p.Changes = append(p.Changes, Change{Action: ActionCreate, Kind: KindGroup, Name: g.Name, group: g})
+			continue
+		}
+
+		matched[live.ID] = true
+		var diffs []Diff
+		diffs = appendDiff(diffs, "order", live.Order, g.Order)
+		diffs = appendDiff(diffs, "collapsed", live.Collapsed, g.Collapsed)
+		diffs = appendDiff(diffs, "visible", live.Visible, g.visible())
+		if len(diffs) > 0 {
+			p.Changes = append(p.Changes, Change{Action: ActionUpdate, Kind: KindGroup, Name: g.Name, ID: live.ID, Diffs: diffs, group: g})
+		}
+	}
+	if o.Prune {
+		for _, g := range groups {
+			if !matched[g.ID] {
+				deletes = append(deletes, Change{Action: ActionDelete, Kind: KindGroup, Name: g.Name, ID: g.ID})
+			}
+		}
+	}
+
+	// Components
+	liveComponents := map[string]cachet.Component{}
+	for _, c := range components {
+		if _, ok := liveComponents[c.Name]; !ok {
+			liveComponents[c.Name] = c
+		}
+	}
+
+	matched = map[int]bool{}
+	var componentDeletes []Change
+	for i := range spec.Components {
+		c := &spec.Components[i]
+		if len(c.Group) > 0 && !specGroups[c.Group] {
+			if _, ok := liveGroups[c.Group]; !ok {
+				return nil, fmt.Errorf("Component %q references the unknown group %q", c.Name, c.Group)
+			}
+			if o.Prune {
+				return nil, fmt.Errorf("Component %q references the group %q which is not part of the spec and would be pruned", c.Name, c.Group)
+			}
+		}
+
+		live, ok := liveComponents[c.Name]
+		if !ok {
+			p.Changes = append(p.Changes, Change{Action: ActionCreate, Kind: KindComponent, Name: c.Name, component: c})
+			continue
+		}
+
+		matched[live.ID] = true
+		var diffs []Diff
+		diffs = appendDiff(diffs, "description", live.Description, c.Description)
+		diffs = appendDiff(diffs, "link", live.Link, c.Link)
+		diffs = appendDiff(diffs, "order", live.Order, c.Order)
+		diffs = appendDiff(diffs, "group", groupNames[live.GroupID], c.Group)
+		diffs = appendDiff(diffs, "enabled", live.Enabled, c.enabled())
+		if c.Status != 0 {
+			diffs = appendDiff(diffs, "status", live.Status, c.Status)
+		}
+		if len(diffs) > 0 {
+			p.Changes = append(p.Changes, Change{Action: ActionUpdate, Kind: KindComponent, Name: c.Name, ID: live.ID, Diffs: diffs, component: c})
+		}
+	}
+	if o.Prune {
+		for _, c := range components {
+			if !matched[c.ID] {
+				componentDeletes = append(componentDeletes, Change{Action: ActionDelete, Kind: KindComponent, Name: c.Name, ID: c.ID})
+			}
+		}
+	}
+	// Components are deleted before their groups.
+	deletes = append(componentDeletes, deletes...)
+
+	// Metrics
+	liveMetrics := map[string]cachet.Metric{}
+	for _, m := range metrics {
+		if _, ok := liveMetrics[m.Name]; !ok {
+			liveMetrics[m.Name] = m
+		}
+	}
+
+	matched = map[int]bool{}
+	for i := range spec.Metrics {
+		m := &spec.Metrics[i]
+		live, ok := liveMetrics[m.Name]
+		if !ok {
+			p.Changes = append(p.Changes, Change{Action: ActionCreate, Kind: KindMetric, Name: m.Name, metric: m})
+			continue
+		}
+
+		matched[live.ID] = true
+		var diffs []Diff
+		diffs = appendDiff(diffs, "suffix", live.Suffix, m.Suffix)
+		diffs = appendDiff(diffs, "description", live.Description, m.Description)
+		diffs = appendDiff(diffs, "default_value", live.DefaultValue, m.DefaultValue)
+		diffs = appendDiff(diffs, "calc_type", live.CalcType, m.CalcType)
+		diffs = appendDiff(diffs, "display_chart", live.DisplayChart, m.DisplayChart)
+		diffs = appendDiff(diffs, "places", live.Places, m.Places)
+		diffs = appendDiff(diffs, "default_view", live.DefaultView, m.DefaultView)
+		diffs = appendDiff(diffs, "threshold", live.Threshold, m.Threshold)
+		diffs = appendDiff(diffs, "order", live.Order, m.Order)
+		diffs = appendDiff(diffs, "visible", live.Visible, m.visible())
+		if len(diffs) > 0 {
+			p.Changes = append(p.Changes, Change{Action: ActionUpdate, Kind: KindMetric, Name: m.Name, ID: live.ID, Diffs: diffs, metric: m})
+		}
+	}
+	if o.Prune {
+		for _, m := range metrics {
+			if !matched[m.ID] {
+				deletes = append(deletes, Change{Action: ActionDelete, Kind: KindMetric, Name: m.Name, ID: m.ID})
+			}
+		}
+	}
+
+	p.Changes = append(p.Changes, deletes...)
+	return p, nil
+}
+
+// appendDiff appends a Diff for field to diffs if from and to differ.
+func appendDiff(diffs []Diff, field string, from, to interface{}) []Diff {
+	if from == to {
+		return diffs
+	}
+	return append(diffs, Diff{Field: field, From: from, To: to})
+}
+
+// Empty returns true if the instance already matches the spec.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Write prints the plan in a human readable form to w.
+func (p *Plan) Write(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintln(w, "No changes. The instance matches the spec.")
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, c := range p.Changes {
+		counts[c.Action]++
+		if _, err := fmt.Fprintln(w, c.String()); err != nil {
+			return err
+		}
+		for _, d := range c.Diffs {
+			if _, err := fmt.Fprintf(w, "    %s: %s => %s\n", d.Field, formatValue(d.From), formatValue(d.To)); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete.\n", counts[ActionCreate], counts[ActionUpdate], counts[ActionDelete])
+	return err
+}
+
+// String returns a one line summary of the change.
+func (c Change) String() string {
+	switch c.Action {
+	case ActionCreate:
+		return fmt.Sprintf("+ %s %q", c.Kind, c.Name)
+	case ActionUpdate:
+		return fmt.Sprintf("~ %s %q (ID %d)", c.Kind, c.Name, c.ID)
+	}
+	return fmt.Sprintf("- %s %q (ID %d)", c.Kind, c.Name, c.ID)
+}
+
+// formatValue formats a value of a Diff. Strings are quoted to make empty values visible.
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,152 @@
+package reconcile
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	testGroups = []cachet.ComponentGroup{
+		{ID: 1, Name: "Websites", Order: 1, Visible: cachet.ComponentGroupVisibilityPublic},
+		{ID: 2, Name: "Legacy", Visible: cachet.ComponentGroupVisibilityPublic},
+	}
+	testComponents = []cachet.Component{
+		{ID: 1, Name: "Website", GroupID: 1, Enabled: true, Status: cachet.ComponentStatusOperational},
+		{ID: 2, Name: "API", Order: 2, Enabled: true, Status: cachet.ComponentStatusMajorOutage},
+		{ID: 3, Name: "FTP", GroupID: 2, Enabled: true},
+	}
+	testMetrics = []cachet.Metric{
+		{ID: 1, Name: "Response time", Suffix: "ms", Visible: cachet.MetricsVisibilityPublic},
+	}
+)
+
+func TestNewPlan(t *testing.T) {
+	spec := &Spec{
+		Groups: []GroupSpec{{Name: "Websites", Order: 1}, {Name: "Backends", Order: 2}},
+		Components: []ComponentSpec{
+			{Name: "Website", Group: "Websites"},
+			{Name: "API", Group: "Backends", Order: 2},
+			{Name: "Database", Group: "Backends"},
+		},
+		Metrics: []MetricSpec{{Name: "Response time", Suffix: "s"}},
+	}
+
+	got, err := newPlan(spec, testGroups, testComponents, testMetrics, nil)
+	if err != nil {
+		t.Fatalf("newPlan returned error: %v", err)
+	}
+
+	expected := []Change{
+		{Action: ActionCreate, Kind: KindGroup, Name: "Backends"},
+		{Action: ActionUpdate, Kind: KindComponent, Name: "API", ID: 2, Diffs: []Diff{{"group", "", "Backends"}}},
+		{Action: ActionCreate, Kind: KindComponent, Name: "Database"},
+		{Action: ActionUpdate, Kind: KindMetric, Name: "Response time", ID: 1, Diffs: []Diff{{"suffix", "ms", "s"}}},
+	}
+	if !reflect.DeepEqual(withoutSpecs(got.Changes), expected) {
+		t.Errorf("newPlan returned %+v, want %+v", withoutSpecs(got.Changes), expected)
+	}
+}
+
+func TestNewPlan_Prune(t *testing.T) {
+	spec := &Spec{
+		Groups:     []GroupSpec{{Name: "Websites", Order: 1}},
+		Components: []ComponentSpec{{Name: "Website", Group: "Websites"}},
+	}
+
+	got, err := newPlan(spec, testGroups, testComponents, testMetrics, &Options{Prune: true})
+	if err != nil {
+		t.Fatalf("newPlan returned error: %v", err)
+	}
+
+	// Components have to be deleted before their groups.
+	expected := []Change{
+		{Action: ActionDelete, Kind: KindComponent, Name: "API", ID: 2},
+		{Action: ActionDelete, Kind: KindComponent, Name: "FTP", ID: 3},
+		{Action: ActionDelete, Kind: KindGroup, Name: "Legacy", ID: 2},
+		{Action: ActionDelete, Kind: KindMetric, Name: "Response time", ID: 1},
+	}
+	if !reflect.DeepEqual(withoutSpecs(got.Changes), expected) {
+		t.Errorf("newPlan returned %+v, want %+v", withoutSpecs(got.Changes), expected)
+	}
+}
+
+func TestNewPlan_Status(t *testing.T) {
+	spec := &Spec{
+		Components: []ComponentSpec{
+			{Name: "Website", Group: "Websites"},
+			{Name: "API", Order: 2, Status: cachet.ComponentStatusOperational},
+		},
+	}
+
+	got, err := newPlan(spec, testGroups, testComponents, testMetrics, nil)
+	if err != nil {
+		t.Fatalf("newPlan returned error: %v", err)
+	}
+
+	expected := []Change{
+		{Action: ActionUpdate, Kind: KindComponent, Name: "API", ID: 2, Diffs: []Diff{{"status", cachet.ComponentStatusMajorOutage, cachet.ComponentStatusOperational}}},
+	}
+	if !reflect.DeepEqual(withoutSpecs(got.Changes), expected) {
+		t.Errorf("newPlan returned %+v, want %+v", withoutSpecs(got.Changes), expected)
+	}
+}
+
+func TestNewPlan_UnknownGroup(t *testing.T) {
+	mockData := []struct {
+		Spec    *Spec
+		Options *Options
+	}{
+		{&Spec{Components: []ComponentSpec{{Name: "API", Group: "Unknown"}}}, nil},
+		{&Spec{Components: []ComponentSpec{{Name: "API", Group: "Legacy"}}}, &Options{Prune: true}},
+	}
+
+	for _, mock := range mockData {
+		if _, err := newPlan(mock.Spec, testGroups, testComponents, testMetrics, mock.Options); err == nil {
+			t.Errorf("newPlan(%+v) returned no error. Expected one.", mock.Spec)
+		}
+	}
+}
+
+func TestPlan_Write(t *testing.T) {
+	p := &Plan{Changes: []Change{
+		{Action: ActionCreate, Kind: KindGroup, Name: "Backends"},
+		{Action: ActionUpdate, Kind: KindComponent, Name: "API", ID: 2, Diffs: []Diff{{"group", "", "Backends"}, {"order", 1, 2}}},
+		{Action: ActionDelete, Kind: KindMetric, Name: "Response time", ID: 1},
+	}}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Errorf("Plan.Write returned error: %v", err)
+	}
+
+	expected := `+ group "Backends"
+~ component "API" (ID 2)
+    group: "" => "Backends"
+    order: 1 => 2
+- metric "Response time" (ID 1)
+
+Plan: 1 to create, 1 to update, 1 to delete.
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("Plan.Write returned %q, want %q", got, expected)
+	}
+
+	buf.Reset()
+	(&Plan{}).Write(&buf)
+	if expected := "No changes. The instance matches the spec.\n"; buf.String() != expected {
+		t.Errorf("Plan.Write returned %q, want %q", buf.String(), expected)
+	}
+}
+
+// withoutSpecs returns the changes without the unexported references to the spec.
+func withoutSpecs(changes []Change) []Change {
+	l := make([]Change, 0, len(changes))
+	for _, c := range changes {
+		c.group, c.component, c.metric = nil, nil, nil
+		l = append(l, c)
+	}
+	return l
+}
@@ -0,0 +1,198 @@
+/*
+Package reconcile syncs the layout of a Cachet status page with a declarative spec.
+
+The spec describes the desired component groups, components and metrics.
+It is compared with the live instance (entities are matched by name) and
+results in a plan of creates, updates and deletes that can be reviewed and applied:
+
+	spec, err := reconcile.LoadSpecFile("status-page.yaml")
+
+	plan, err := reconcile.NewPlan(client, spec, &reconcile.Options{Prune: true})
+	plan.Write(os.Stdout)
+
+	err = plan.Apply(client)
+
+A spec looks like:
+
+	groups:
+	  - name: Websites
+	    order: 1
+	components:
+	  - name: Website
+	    description: Our public website
+	    link: https://www.example.com/
+	    group: Websites
+	    order: 1
+	  - name: API
+	    enabled: false
+	metrics:
+	  - name: Response time
+	    suffix: ms
+	    calc_type: 1
+	    display_chart: true
+
+Only entities listed in the spec are touched, unless pruning is enabled.
+With pruning, entities of the live instance that are not part of the spec are deleted.
+The status of components is only managed if it is part of the spec.
+*/
+package reconcile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/andygrunwald/cachet"
+	"gopkg.in/yaml.v2"
+)
+
+// Spec describes the desired state of a Cachet instance.
+type Spec struct {
+	Groups     []GroupSpec     `yaml:"groups" json:"groups"`
+	Components []ComponentSpec `yaml:"components" json:"components"`
+	Metrics    []MetricSpec    `yaml:"metrics" json:"metrics"`
+}
+
+// GroupSpec describes a desired component group.
+type GroupSpec struct {
+	Name      string `yaml:"name" json:"name"`
+	Order     int    `yaml:"order" json:"order"`
+	Collapsed int    `yaml:"collapsed" json:"collapsed"`
+	// Visible defaults to cachet.ComponentGroupVisibilityPublic.
+	Visible *int `yaml:"visible" json:"visible"`
+}
+
+// ComponentSpec describes a desired component.
+type ComponentSpec struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	Link        string `yaml:"link" json:"link"`
+	Order       int    `yaml:"order" json:"order"`
+	// Group is the name of the component group.
+	// It has to be part of the spec or exist on the instance.
+	Group string `yaml:"group" json:"group"`
+	// Enabled defaults to true.
+	Enabled *bool `yaml:"enabled" json:"enabled"`
+	// Status is only managed if it is set.
+	// New components are created with cachet.ComponentStatusOperational otherwise.
+	Status int `yaml:"status" json:"status"`
+}
+
+// MetricSpec describes a desired metric.
+type MetricSpec struct {
+	Name         string `yaml:"name" json:"name"`
+	Suffix       string `yaml:"suffix" json:"suffix"`
+	Description  string `yaml:"description" json:"description"`
+	DefaultValue int    `yaml:"default_value" json:"default_value"`
+	CalcType     int    `yaml:"calc_type" json:"calc_type"`
+	DisplayChart bool   `yaml:"display_chart" json:"display_chart"`
+	Places       int    `yaml:"places" json:"places"`
+	DefaultView  int    `yaml:"default_view" json:"default_view"`
+	Threshold    int    `yaml:"threshold" json:"threshold"`
+	Order        int    `yaml:"order" json:"order"`
+	// Visible defaults to cachet.MetricsVisibilityPublic.
+	Visible *int `yaml:"visible" json:"visible"`
+}
+
+// LoadSpec reads a YAML or JSON spec from r.
+// Unknown fields are reported as error to catch typos early.
+func LoadSpec(r io.Reader) (*Spec, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{}
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.DisallowUnknownFields()
+		err = d.Decode(spec)
+	} else {
+		err = yaml.UnmarshalStrict(b, spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// LoadSpecFile reads a YAML or JSON spec from the file path.
+func LoadSpecFile(path string) (*Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	spec, err := LoadSpec(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return spec, nil
+}
+
+// Validate checks that all entities have a unique name.
+func (s *Spec) Validate() error {
+	groups := map[string]bool{}
+	for _, g := range s.Groups {
+		if len(g.Name) == 0 {
+			return fmt.Errorf("Group without a name")
+		}
+		if groups[g.Name] {
+			return fmt.Errorf("Group %q is defined twice", g.Name)
+		}
+		groups[g.Name] = true
+	}
+
+	components := map[string]bool{}
+	for _, c := range s.Components {
+		if len(c.Name) == 0 {
+			return fmt.Errorf("Component without a name")
+		}
+		if components[c.Name] {
+			return fmt.Errorf("Component %q is defined twice", c.Name)
+		}
+		components[c.Name] = true
+	}
+
+	metrics := map[string]bool{}
+	for _, m := range s.Metrics {
+		if len(m.Name) == 0 {
+			return fmt.Errorf("Metric without a name")
+		}
+		if metrics[m.Name] {
+			return fmt.Errorf("Metric %q is defined twice", m.Name)
+		}
+		metrics[m.Name] = true
+	}
+
+	return nil
+}
+
+// visible returns the desired visibility of the group.
+func (g *GroupSpec) visible() int {
+	if g.Visible == nil {
+		return cachet.ComponentGroupVisibilityPublic
+	}
+	return *g.Visible
+}
+
+// enabled returns whether the component should be enabled.
+func (c *ComponentSpec) enabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// visible returns the desired visibility of the metric.
+func (m *MetricSpec) visible() int {
+	if m.Visible == nil {
+		return cachet.MetricsVisibilityPublic
+	}
+	return *m.Visible
+}
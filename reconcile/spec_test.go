@@ -0,0 +1,65 @@
+package reconcile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadSpec(t *testing.T) {
+	disabled := false
+	private := 0
+	expected := &Spec{
+		Groups:     []GroupSpec{{Name: "Websites", Order: 1, Visible: &private}},
+		Components: []ComponentSpec{{Name: "API", Group: "Websites", Enabled: &disabled}},
+		Metrics:    []MetricSpec{{Name: "Response time", Suffix: "ms", DisplayChart: true}},
+	}
+
+	mockData := []string{
+		`
+groups:
+  - name: Websites
+    order: 1
+    visible: 0
+components:
+  - name: API
+    group: Websites
+    enabled: false
+metrics:
+  - name: Response time
+    suffix: ms
+    display_chart: true
+`,
+		`{
+	"groups": [{"name": "Websites", "order": 1, "visible": 0}],
+	"components": [{"name": "API", "group": "Websites", "enabled": false}],
+	"metrics": [{"name": "Response time", "suffix": "ms", "display_chart": true}]
+}`,
+	}
+
+	for _, data := range mockData {
+		got, err := LoadSpec(strings.NewReader(data))
+		if err != nil {
+			t.Errorf("LoadSpec returned error: %v", err)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("LoadSpec returned %+v, want %+v", got, expected)
+		}
+	}
+}
+
+func TestLoadSpec_Errors(t *testing.T) {
+	mockData := []string{
+		"components:\n  - name: API\n    grup: Websites\n",
+		`{"components": [{"name": "API", "grup": "Websites"}]}`,
+		"components:\n  - name: API\n  - name: API\n",
+		"groups:\n  - order: 1\n",
+		"metrics:\n  - name: Foo\n  - name: Foo\n",
+	}
+
+	for _, data := range mockData {
+		if _, err := LoadSpec(strings.NewReader(data)); err == nil {
+			t.Errorf("LoadSpec(%q) returned no error. Expected one.", data)
+		}
+	}
+}
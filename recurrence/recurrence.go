@@ -0,0 +1,177 @@
+/*
+Package recurrence creates recurring maintenance schedules from iCalendar recurrence rules.
+
+A Series describes a recurring maintenance window. Sync creates a schedule for every
+occurrence within a rolling horizon and removes upcoming schedules that are no longer part of the rule:
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	series := &recurrence.Series{
+		Name:       "Database patching",
+		Message:    "The database is patched, expect short interruptions.",
+		Rule:       "FREQ=MONTHLY;BYDAY=2TU",
+		Start:      time.Date(2017, 10, 10, 22, 0, 0, 0, berlin),
+		Duration:   2 * time.Hour,
+		Components: []int{1, 2},
+	}
+
+	result, err := recurrence.Sync(client, series, nil)
+
+Schedules belong to a series by name. Two schedules of a series never share the same start.
+Sync can be run repeatedly, e.g. from cron.
+*/
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// DefaultHorizon is the time span schedules are created for by default.
+const DefaultHorizon = 30 * 24 * time.Hour
+
+// Series is a recurring maintenance window.
+type Series struct {
+	Name    string
+	Message string
+
+	// Rule is an RRULE like "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU".
+	Rule string
+	// Start is the first occurrence. Its location is used to expand the rule,
+	// so occurrences keep their local time across daylight saving time changes.
+	Start time.Time
+	// Duration of every occurrence. It sets the completed_at of the schedules.
+	Duration time.Duration
+
+	// Components contains the IDs of the affected components.
+	Components []int
+}
+
+// Options configures Sync.
+type Options struct {
+	// Horizon is the time span schedules are created for. It defaults to DefaultHorizon.
+	Horizon time.Duration
+	// Now is the start of the horizon. It defaults to the current time.
+	Now time.Time
+	// Location is the time zone of the Cachet instance. It defaults to UTC.
+	Location *time.Location
+}
+
+// Result contains the changes made by Sync.
+type Result struct {
+	// Created contains the schedules created for new occurrences.
+	Created []cachet.Schedule
+	// Deleted contains the upcoming schedules that are no longer part of the rule.
+	Deleted []cachet.Schedule
+	// Existing contains the schedules of occurrences that already existed.
+	Existing []cachet.Schedule
+}
+
+// Sync creates the schedules of all occurrences of the series within the horizon
+// and deletes upcoming schedules of the series that are no longer part of the rule.
+// Schedules that are in progress or complete are never deleted.
+// The returned Result contains the changes made so far, even if an error occurred.
+func Sync(client *cachet.Client, s *Series, o *Options) (*Result, error) {
+	result := &Result{}
+
+	if o == nil {
+		o = &Options{}
+	}
+	horizon := o.Horizon
+	if horizon == 0 {
+		horizon = DefaultHorizon
+	}
+	now := o.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	loc := o.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	if len(s.Name) == 0 {
+		return result, fmt.Errorf("A series needs a name")
+	}
+	rule, err := ParseRule(s.Rule)
+	if err != nil {
+		return result, err
+	}
+
+	schedules, err := fetch.Schedules(client, &cachet.SchedulesQueryParams{Name: s.Name})
+	if err != nil {
+		return result, err
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+
+	existing := map[string]bool{}
+	for _, schedule := range schedules {
+		if schedule.Name != s.Name {
+			continue
+		}
+		scheduledAt, err := time.ParseInLocation(api.TimestampLayout, schedule.ScheduledAt, loc)
+		if err != nil {
+			continue
+		}
+		key := scheduledAt.In(loc).Format(api.TimestampLayout)
+
+		switch {
+		case existing[key] && schedule.Status == cachet.ScheduleUpcoming:
+			// A duplicate of an occurrence.
+			if err := deleteSchedule(client, schedule, result); err != nil {
+				return result, err
+			}
+		case schedule.Status == cachet.ScheduleUpcoming && !scheduledAt.Before(now) && !rule.Includes(s.Start, scheduledAt):
+			if err := deleteSchedule(client, schedule, result); err != nil {
+				return result, err
+			}
+		default:
+			existing[key] = true
+			if !scheduledAt.Before(now) {
+				result.Existing = append(result.Existing, schedule)
+			}
+		}
+	}
+
+	for _, t := range rule.Occurrences(s.Start, now, now.Add(horizon)) {
+		key := t.In(loc).Format(api.TimestampLayout)
+		if existing[key] {
+			continue
+		}
+
+		body := &api.Schedule{
+			Name:        s.Name,
+			Message:     s.Message,
+			Status:      cachet.ScheduleUpcoming,
+			ScheduledAt: key,
+		}
+		if s.Duration > 0 {
+			body.CompletedAt = t.Add(s.Duration).In(loc).Format(api.TimestampLayout)
+		}
+		for _, id := range s.Components {
+			body.Components = append(body.Components, cachet.Component{ID: id})
+		}
+
+		created, err := api.CreateSchedule(client, body)
+		if err != nil {
+			return result, fmt.Errorf("Failed to create schedule %q at %s: %v", s.Name, key, err)
+		}
+		existing[key] = true
+		result.Created = append(result.Created, *created)
+	}
+
+	return result, nil
+}
+
+// deleteSchedule deletes a schedule and records it in result.
+func deleteSchedule(client *cachet.Client, schedule cachet.Schedule, result *Result) error {
+	if _, err := client.Schedules.Delete(schedule.ID); err != nil {
+		return fmt.Errorf("Failed to delete schedule %d: %v", schedule.ID, err)
+	}
+	result.Deleted = append(result.Deleted, schedule)
+	return nil
+}
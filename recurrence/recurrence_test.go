@@ -0,0 +1,113 @@
+package recurrence
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func TestSync(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var created []string
+	testMux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			if name := r.URL.Query().Get("name"); name != "Patching" {
+				t.Errorf("Schedules are filtered by name %q, want %q", name, "Patching")
+			}
+			fmt.Fprint(w, `{"data":[
+				{"id":1,"name":"Patching","status":2,"scheduled_at":"2017-09-26 22:00:00"},
+				{"id":2,"name":"Patching","status":0,"scheduled_at":"2017-10-10 22:00:00"},
+				{"id":3,"name":"Patching","status":0,"scheduled_at":"2017-10-10 22:00:00"},
+				{"id":4,"name":"Patching","status":0,"scheduled_at":"2017-10-11 22:00:00"},
+				{"id":5,"name":"Patching tomorrow","status":0,"scheduled_at":"2017-10-12 22:00:00"}
+			]}`)
+			return
+		}
+
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		expected := map[string]interface{}{
+			"name": "Patching", "message": "Expect interruptions", "status": float64(0),
+			"scheduled_at": "2017-10-24 22:00:00", "completed_at": "2017-10-25 00:00:00",
+			"components": []interface{}{map[string]interface{}{"id": float64(7)}},
+		}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Request body is %+v, want %+v", body, expected)
+		}
+		created = append(created, body["scheduled_at"].(string))
+		fmt.Fprint(w, `{"data":{"id":6,"name":"Patching"}}`)
+	})
+	var deleted []int
+	for _, id := range []int{3, 4} {
+		id := id
+		testMux.HandleFunc(fmt.Sprintf("/api/v1/schedules/%d", id), func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "DELETE" {
+				t.Errorf("Request method: %v, want DELETE", r.Method)
+			}
+			deleted = append(deleted, id)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	series := &Series{
+		Name:       "Patching",
+		Message:    "Expect interruptions",
+		Rule:       "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+		Start:      time.Date(2017, 9, 12, 22, 0, 0, 0, time.UTC),
+		Duration:   2 * time.Hour,
+		Components: []int{7},
+	}
+	o := &Options{Now: time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC), Horizon: 4 * 7 * 24 * time.Hour}
+
+	got, err := Sync(testClient, series, o)
+	if err != nil {
+		t.Fatalf("Sync returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(created, []string{"2017-10-24 22:00:00"}) {
+		t.Errorf("Sync created schedules at %v, want one at 2017-10-24 22:00:00", created)
+	}
+	if !reflect.DeepEqual(deleted, []int{3, 4}) {
+		t.Errorf("Sync deleted schedules %v, want the duplicate 3 and the removed occurrence 4", deleted)
+	}
+	if len(got.Created) != 1 || len(got.Deleted) != 2 || len(got.Existing) != 1 || got.Existing[0].ID != 2 {
+		t.Errorf("Sync returned %+v, want 1 created, 2 deleted and schedule 2 existing", got)
+	}
+}
+
+func TestSync_InvalidRule(t *testing.T) {
+	if _, err := Sync(nil, &Series{Name: "Patching", Rule: "FREQ=SECONDLY"}, nil); err == nil {
+		t.Error("Sync returned no error for an invalid rule. Expected one.")
+	}
+}
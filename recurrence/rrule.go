@@ -0,0 +1,397 @@
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies of a Rule.
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// maxPeriods limits the expansion of rules that never match.
+const maxPeriods = 100000
+
+// weekdays maps the weekdays of an RRULE to time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Rule is a recurrence rule as defined by RFC 5545 (iCalendar).
+//
+// The parts FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH and WKST are supported,
+// except BYMONTHDAY with FREQ=WEEKLY and numbered weekdays of the whole year.
+// Occurrences are at the time of day of the first occurrence.
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+// WeekdayNum is a weekday of BYDAY.
+// N selects the nth weekday within the month (e.g. 2 for the second, -1 for the last).
+// 0 selects every such weekday.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// ParseRule parses an RRULE like "FREQ=MONTHLY;BYDAY=2TU".
+// A leading "RRULE:" is ignored.
+// An UNTIL without time zone is interpreted as UTC.
+func ParseRule(s string) (*Rule, error) {
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	for _, part := range strings.Split(s, ";") {
+		if len(part) == 0 {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("Invalid RRULE part %q", part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = value
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err == nil && r.Interval < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err == nil && r.Count < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseInts(value, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(value, 12)
+			for _, m := range months {
+				if m < 0 {
+					err = fmt.Errorf("must be positive")
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			var ok bool
+			if r.WeekStart, ok = weekdays[value]; !ok {
+				err = fmt.Errorf("unknown weekday")
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported RRULE part %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid RRULE part %s=%s: %v", key, value, err)
+		}
+	}
+
+	switch r.Freq {
+	case Daily, Weekly:
+		for _, d := range r.ByDay {
+			if d.N != 0 {
+				return nil, fmt.Errorf("Numbered weekdays in BYDAY need FREQ=MONTHLY or FREQ=YEARLY")
+			}
+		}
+		if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+			return nil, fmt.Errorf("BYMONTHDAY must not be used with FREQ=WEEKLY")
+		}
+	case Monthly:
+	case Yearly:
+		// Numbered weekdays of the whole year are not supported.
+		for _, d := range r.ByDay {
+			if d.N != 0 && len(r.ByMonth) == 0 {
+				return nil, fmt.Errorf("Numbered weekdays in BYDAY need BYMONTH with FREQ=YEARLY")
+			}
+		}
+	case "":
+		return nil, fmt.Errorf("RRULE has no FREQ")
+	default:
+		return nil, fmt.Errorf("Unsupported RRULE frequency %s", r.Freq)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, fmt.Errorf("RRULE must not contain both COUNT and UNTIL")
+	}
+
+	return r, nil
+}
+
+// parseUntil parses the value of UNTIL.
+func parseUntil(s string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date")
+}
+
+// parseByDay parses the value of BYDAY, e.g. "MO,WE" or "2TU,-1FR".
+func parseByDay(s string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, v := range strings.Split(s, ",") {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("invalid weekday %q", v)
+		}
+		day, ok := weekdays[v[len(v)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", v)
+		}
+		n := 0
+		if len(v) > 2 {
+			var err error
+			if n, err = strconv.Atoi(v[:len(v)-2]); err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("invalid weekday %q", v)
+			}
+		}
+		days = append(days, WeekdayNum{N: n, Day: day})
+	}
+	return days, nil
+}
+
+// parseInts parses a comma separated list of integers between -max and max, excluding 0.
+func parseInts(s string, max int) ([]int, error) {
+	var ints []int
+	for _, v := range strings.Split(s, ",") {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 || i < -max || i > max {
+			return nil, fmt.Errorf("%d is out of range", i)
+		}
+		ints = append(ints, i)
+	}
+	return ints, nil
+}
+
+// Occurrences returns the occurrences of the rule between from (inclusive) and to (exclusive).
+// dtstart is the first occurrence. Its location is used to expand the rule.
+func (r *Rule) Occurrences(dtstart, from, to time.Time) []time.Time {
+	var occurrences []time.Time
+	n := 0
+	for period := 0; period < maxPeriods; period++ {
+		start, candidates := r.expand(dtstart, period)
+		if !start.Before(to) {
+			break
+		}
+		for _, t := range candidates {
+			if t.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return occurrences
+			}
+			if !t.Before(to) {
+				return occurrences
+			}
+			n++
+			if r.Count > 0 && n > r.Count {
+				return occurrences
+			}
+			if !t.Before(from) {
+				occurrences = append(occurrences, t)
+			}
+		}
+	}
+	return occurrences
+}
+
+// Includes reports whether t is an occurrence of the rule.
+func (r *Rule) Includes(dtstart, t time.Time) bool {
+	return len(r.Occurrences(dtstart, t, t.Add(time.Second))) > 0
+}
+
+// expand returns the start of the nth period after dtstart and the sorted candidates within that period.
+func (r *Rule) expand(dtstart time.Time, n int) (time.Time, []time.Time) {
+	h, min, sec := dtstart.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, h, min, sec, 0, dtstart.Location())
+	}
+
+	var start time.Time
+	var candidates []time.Time
+
+	switch r.Freq {
+	case Daily:
+		start = at(dtstart.Year(), dtstart.Month(), dtstart.Day()+n*r.Interval)
+		if r.matchMonth(start) && r.matchWeekday(start) && r.matchMonthDay(start) {
+			candidates = append(candidates, start)
+		}
+
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		start = at(dtstart.Year(), dtstart.Month(), dtstart.Day()-offset+7*n*r.Interval)
+		for i := 0; i < 7; i++ {
+			day := at(start.Year(), start.Month(), start.Day()+i)
+			if len(r.ByDay) == 0 && day.Weekday() != dtstart.Weekday() {
+				continue
+			}
+			if r.matchWeekday(day) && r.matchMonth(day) {
+				candidates = append(candidates, day)
+			}
+		}
+
+	case Monthly:
+		start = at(dtstart.Year(), dtstart.Month()+time.Month(n*r.Interval), 1)
+		if r.matchMonth(start) {
+			candidates = r.monthDays(start, dtstart, at)
+		}
+
+	case Yearly:
+		year := dtstart.Year() + n*r.Interval
+		start = at(year, time.January, 1)
+		months := r.ByMonth
+		switch {
+		case len(months) > 0:
+		case len(r.ByDay) > 0 || len(r.ByMonthDay) > 0:
+			// BYDAY and BYMONTHDAY without BYMONTH apply to every month of the year.
+			for m := time.January; m <= time.December; m++ {
+				months = append(months, m)
+			}
+		default:
+			months = []time.Month{dtstart.Month()}
+		}
+		months = append([]time.Month(nil), months...)
+		sort.Slice(months, func(i, j int) bool { return months[i] < months[j] })
+		for _, m := range months {
+			candidates = append(candidates, r.monthDays(at(year, m, 1), dtstart, at)...)
+		}
+	}
+
+	return start, candidates
+}
+
+// monthDays returns the candidates within the month starting at first.
+func (r *Rule) monthDays(first, dtstart time.Time, at func(int, time.Month, int) time.Time) []time.Time {
+	last := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	days := map[int]bool{}
+	for _, md := range r.ByMonthDay {
+		if md < 0 {
+			md = last + md + 1
+		}
+		if md >= 1 && md <= last {
+			days[md] = true
+		}
+	}
+
+	if len(r.ByDay) > 0 {
+		byDay := map[int]bool{}
+		for _, wd := range r.ByDay {
+			var matches []int
+			for d := 1; d <= last; d++ {
+				if time.Date(first.Year(), first.Month(), d, 0, 0, 0, 0, time.UTC).Weekday() == wd.Day {
+					matches = append(matches, d)
+				}
+			}
+			switch {
+			case wd.N == 0:
+				for _, d := range matches {
+					byDay[d] = true
+				}
+			case wd.N > 0 && wd.N <= len(matches):
+				byDay[matches[wd.N-1]] = true
+			case wd.N < 0 && -wd.N <= len(matches):
+				byDay[matches[len(matches)+wd.N]] = true
+			}
+		}
+
+		// BYMONTHDAY and BYDAY both have to match.
+		if len(r.ByMonthDay) > 0 {
+			for d := range days {
+				if !byDay[d] {
+					delete(days, d)
+				}
+			}
+		} else {
+			days = byDay
+		}
+	}
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 && dtstart.Day() <= last {
+		days[dtstart.Day()] = true
+	}
+
+	sorted := make([]int, 0, len(days))
+	for d := range days {
+		sorted = append(sorted, d)
+	}
+	sort.Ints(sorted)
+
+	candidates := make([]time.Time, 0, len(sorted))
+	for _, d := range sorted {
+		candidates = append(candidates, at(first.Year(), first.Month(), d))
+	}
+	return candidates
+}
+
+// matchMonth reports whether the month of t is part of BYMONTH.
+func (r *Rule) matchMonth(t time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if t.Month() == m {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWeekday reports whether the weekday of t is part of BYDAY.
+func (r *Rule) matchWeekday(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if t.Weekday() == wd.Day {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMonthDay reports whether the day of t is part of BYMONTHDAY.
+func (r *Rule) matchMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.ByMonthDay {
+		if md < 0 {
+			md = last + md + 1
+		}
+		if t.Day() == md {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package recurrence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet/internal/api"
+)
+
+func TestParseRule(t *testing.T) {
+	got, err := ParseRule("RRULE:FREQ=MONTHLY;INTERVAL=2;BYDAY=2TU,-1FR;BYMONTH=1,7;COUNT=4;WKST=SU")
+	if err != nil {
+		t.Fatalf("ParseRule returned error: %v", err)
+	}
+
+	expected := &Rule{
+		Freq:      Monthly,
+		Interval:  2,
+		Count:     4,
+		ByDay:     []WeekdayNum{{N: 2, Day: time.Tuesday}, {N: -1, Day: time.Friday}},
+		ByMonth:   []time.Month{time.January, time.July},
+		WeekStart: time.Sunday,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ParseRule returned %+v, want %+v", got, expected)
+	}
+}
+
+func TestParseRule_Invalid(t *testing.T) {
+	mockData := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20171231",
+		"FREQ=WEEKLY;BYDAY=2TU",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=YEARLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYSETPOS=1",
+		"FREQ",
+	}
+
+	for _, data := range mockData {
+		if _, err := ParseRule(data); err == nil {
+			t.Errorf("ParseRule(%q) returned no error. Expected one.", data)
+		}
+	}
+}
+
+func TestRule_Occurrences(t *testing.T) {
+	at := func(month time.Month, day int) time.Time {
+		return time.Date(2017, month, day, 22, 0, 0, 0, time.UTC)
+	}
+
+	mockData := []struct {
+		rule     string
+		dtstart  time.Time
+		from, to time.Time
+		expected []time.Time
+	}{
+		{
+			"FREQ=DAILY;INTERVAL=2", at(10, 1), at(10, 1), at(10, 8),
+			[]time.Time{at(10, 1), at(10, 3), at(10, 5), at(10, 7)},
+		},
+		{
+			"FREQ=DAILY;BYDAY=SA,SU", at(10, 1), at(10, 1), at(10, 10),
+			[]time.Time{at(10, 1), at(10, 7), at(10, 8)},
+		},
+		{
+			// Every second Tuesday.
+			"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", at(10, 3), at(10, 1), at(11, 1),
+			[]time.Time{at(10, 3), at(10, 17), at(10, 31)},
+		},
+		{
+			"FREQ=WEEKLY;BYDAY=MO,FR;COUNT=3", at(10, 2), at(10, 1), at(12, 1),
+			[]time.Time{at(10, 2), at(10, 6), at(10, 9)},
+		},
+		{
+			// The second Tuesday of the month.
+			"FREQ=MONTHLY;BYDAY=2TU", at(10, 10), at(10, 1), at(12, 31),
+			[]time.Time{at(10, 10), at(11, 14), at(12, 12)},
+		},
+		{
+			"FREQ=MONTHLY;BYDAY=-1FR", at(9, 29), at(10, 1), at(12, 1),
+			[]time.Time{at(10, 27), at(11, 24)},
+		},
+		{
+			// Months without a 31st are skipped.
+			"FREQ=MONTHLY", at(8, 31), at(8, 1), at(12, 1),
+			[]time.Time{at(8, 31), at(10, 31)},
+		},
+		{
+			"FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20171101T000000Z", at(10, 1), at(10, 1), at(12, 31),
+			[]time.Time{at(10, 1), at(10, 31)},
+		},
+		{
+			"FREQ=YEARLY;BYMONTH=3,9;BYMONTHDAY=15", at(3, 15), at(1, 1), time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			[]time.Time{at(3, 15), at(9, 15), time.Date(2018, 3, 15, 22, 0, 0, 0, time.UTC), time.Date(2018, 9, 15, 22, 0, 0, 0, time.UTC)},
+		},
+		{
+			// Every Monday of the year.
+			"FREQ=YEARLY;BYDAY=MO", at(10, 2), at(10, 1), at(10, 20),
+			[]time.Time{at(10, 2), at(10, 9), at(10, 16)},
+		},
+		{
+			"FREQ=YEARLY;BYMONTHDAY=1", at(10, 1), at(10, 1), at(12, 31),
+			[]time.Time{at(10, 1), at(11, 1), at(12, 1)},
+		},
+		{
+			"FREQ=YEARLY", at(3, 15), at(1, 1), time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			[]time.Time{at(3, 15), time.Date(2018, 3, 15, 22, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, data := range mockData {
+		r, err := ParseRule(data.rule)
+		if err != nil {
+			t.Fatalf("ParseRule(%q) returned error: %v", data.rule, err)
+		}
+		got := r.Occurrences(data.dtstart, data.from, data.to)
+		if !reflect.DeepEqual(got, data.expected) {
+			t.Errorf("Occurrences of %q returned %v, want %v", data.rule, got, data.expected)
+		}
+	}
+}
+
+func TestRule_Occurrences_Location(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("Time zone database not available")
+	}
+
+	r, _ := ParseRule("FREQ=WEEKLY")
+	got := r.Occurrences(time.Date(2017, 10, 24, 22, 0, 0, 0, berlin), time.Time{}, time.Date(2017, 11, 1, 0, 0, 0, 0, berlin))
+
+	// Daylight saving time ends on October 29th, the local time is kept.
+	expected := []string{"2017-10-24 20:00:00", "2017-10-31 21:00:00"}
+	if len(got) != len(expected) {
+		t.Fatalf("Occurrences returned %v, want %v", got, expected)
+	}
+	for i, o := range got {
+		if s := o.UTC().Format(api.TimestampLayout); s != expected[i] {
+			t.Errorf("Occurrence %d is %s UTC, want %s", i, s, expected[i])
+		}
+	}
+}
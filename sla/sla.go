@@ -0,0 +1,244 @@
+/*
+Package sla calculates the availability of components from the incident history of a Cachet instance.
+
+The outages of every component are reconstructed from the component statuses of incidents and their updates.
+Outages are weighted by their status, a partial outage counts half by default.
+Scheduled maintenance is excluded from the downtime and from the measured time:
+
+	reports, err := sla.Fetch(client, sla.Monthly(from, to), &sla.Options{
+		DefaultTarget: 99.9,
+		Targets:       map[int]float64{3: 99.5},
+	})
+
+	for _, r := range reports {
+		for _, c := range r.Components {
+			fmt.Printf("%s %s: %.3f%% (target met: %v)\n", r.Period.Start.Format("2006-01"), c.Name, c.Uptime, c.Met)
+		}
+	}
+*/
+package sla
+
+import (
+	"sort"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// DefaultWeights are the shares of an outage that count as downtime per component status.
+var DefaultWeights = map[int]float64{
+	cachet.ComponentStatusPerformanceIssues: 0,
+	cachet.ComponentStatusPartialOutage:     0.5,
+	cachet.ComponentStatusMajorOutage:       1,
+}
+
+// Options configures the calculation.
+type Options struct {
+	// Weights overrides DefaultWeights per component status.
+	Weights map[int]float64
+	// Targets are the SLO targets in percent per component ID.
+	Targets map[int]float64
+	// DefaultTarget is the SLO target in percent of components without a target in Targets.
+	DefaultTarget float64
+	// Location is the time zone of the Cachet instance. It defaults to UTC.
+	Location *time.Location
+	// Now is the end of unresolved outages. It defaults to the current time.
+	Now time.Time
+}
+
+func (o *Options) weight(status int) float64 {
+	if o != nil {
+		if w, ok := o.Weights[status]; ok {
+			return w
+		}
+	}
+	return DefaultWeights[status]
+}
+
+func (o *Options) target(id int) float64 {
+	if o == nil {
+		return 0
+	}
+	if t, ok := o.Targets[id]; ok {
+		return t
+	}
+	return o.DefaultTarget
+}
+
+// Period is a time range. Start is included, End is excluded.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Monthly returns the calendar months between from and to in the location of from.
+// The first and last month are cut to from and to.
+func Monthly(from, to time.Time) []Period {
+	var periods []Period
+	for start := from; start.Before(to); {
+		end := time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, from.Location())
+		if end.After(to) {
+			end = to
+		}
+		periods = append(periods, Period{Start: start, End: end})
+		start = end
+	}
+	return periods
+}
+
+// Report is the availability of all components in a period.
+type Report struct {
+	Period     Period
+	Components []ComponentReport
+}
+
+// ComponentReport is the availability of a component in a period.
+type ComponentReport struct {
+	ComponentID int
+	Name        string
+	// Downtime is the weighted duration of all outages outside of maintenance.
+	Downtime time.Duration
+	// Maintenance is the duration of scheduled maintenance.
+	Maintenance time.Duration
+	// Uptime is the availability in percent of the period without maintenance.
+	Uptime float64
+	// Target is the SLO target in percent. 0 means no target.
+	Target float64
+	// Met reports whether Uptime reaches Target.
+	Met bool
+	// Outages are the outages overlapping the period.
+	Outages []Outage
+}
+
+// Fetch fetches components, incidents, their updates and schedules and calculates a report per period.
+// Incidents that ended before the first period are skipped.
+func Fetch(client *cachet.Client, periods []Period, o *Options) ([]*Report, error) {
+	if len(periods) == 0 {
+		return nil, nil
+	}
+	if o == nil {
+		o = &Options{}
+	}
+	loc, now := api.Location(o.Location), api.Now(o.Now)
+	first := periods[0].Start
+	for _, p := range periods {
+		if p.Start.Before(first) {
+			first = p.Start
+		}
+	}
+
+	components, err := fetch.Components(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := fetch.Incidents(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := fetch.Schedules(client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var outages []Outage
+	for _, i := range incidents {
+		if i.ComponentID == 0 || i.Status == cachet.IncidentStatusScheduled {
+			continue
+		}
+		resolved := i.IsResolved || i.Status == cachet.IncidentStatusFixed
+		if resolved && api.ParseTime(loc, i.UpdatedAt).Before(first) {
+			continue
+		}
+
+		updates, err := fetch.IncidentUpdates(client, i.ID)
+		if err != nil {
+			return nil, err
+		}
+		outages = append(outages, Outages(i, updates, loc, now)...)
+	}
+
+	var maintenances []Maintenance
+	for _, s := range schedules {
+		if m, ok := MaintenanceWindow(s, loc, now); ok {
+			maintenances = append(maintenances, m)
+		}
+	}
+
+	reports := make([]*Report, 0, len(periods))
+	for _, p := range periods {
+		reports = append(reports, Compute(p, components, outages, maintenances, o))
+	}
+	return reports, nil
+}
+
+// Compute calculates the availability of components in the period p.
+// Overlapping outages of a component count once with the highest weight.
+func Compute(p Period, components []cachet.Component, outages []Outage, maintenances []Maintenance, o *Options) *Report {
+	r := &Report{Period: p, Components: []ComponentReport{}}
+
+	for _, c := range components {
+		cr := ComponentReport{ComponentID: c.ID, Name: c.Name, Target: o.target(c.ID), Outages: []Outage{}}
+
+		var own []Outage
+		var windows []Maintenance
+		bounds := []time.Time{p.Start, p.End}
+		for _, out := range outages {
+			if out.ComponentID == c.ID && out.Start.Before(p.End) && out.End.After(p.Start) {
+				own = append(own, out)
+				cr.Outages = append(cr.Outages, out)
+				bounds = append(bounds, out.Start, out.End)
+			}
+		}
+		for _, m := range maintenances {
+			if m.covers(c.ID) && m.Start.Before(p.End) && m.End.After(p.Start) {
+				windows = append(windows, m)
+				bounds = append(bounds, m.Start, m.End)
+			}
+		}
+		sort.Slice(bounds, func(a, b int) bool { return bounds[a].Before(bounds[b]) })
+
+		var downtime float64
+		for k := 0; k+1 < len(bounds); k++ {
+			start, end := bounds[k], bounds[k+1]
+			if start.Before(p.Start) || end.After(p.End) || !end.After(start) {
+				continue
+			}
+			segment := end.Sub(start)
+
+			if inMaintenance(windows, start) {
+				cr.Maintenance += segment
+				continue
+			}
+			weight := 0.0
+			for _, out := range own {
+				if !start.Before(out.Start) && start.Before(out.End) {
+					if w := o.weight(out.Status); w > weight {
+						weight = w
+					}
+				}
+			}
+			downtime += weight * float64(segment)
+		}
+		cr.Downtime = time.Duration(downtime)
+
+		cr.Uptime = 100
+		if measured := p.End.Sub(p.Start) - cr.Maintenance; measured > 0 {
+			cr.Uptime = 100 * (1 - downtime/float64(measured))
+		}
+		cr.Met = cr.Uptime >= cr.Target
+		r.Components = append(r.Components, cr)
+	}
+	return r
+}
+
+// inMaintenance reports whether t is within one of the maintenance windows.
+func inMaintenance(windows []Maintenance, t time.Time) bool {
+	for _, m := range windows {
+		if !t.Before(m.Start) && t.Before(m.End) {
+			return true
+		}
+	}
+	return false
+}
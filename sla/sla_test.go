@@ -0,0 +1,151 @@
+package sla
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func TestMonthly(t *testing.T) {
+	from := time.Date(2017, 9, 15, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2017, 11, 10, 0, 0, 0, 0, time.UTC)
+
+	got := Monthly(from, to)
+	expected := []Period{
+		{from, time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2017, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2017, 11, 1, 0, 0, 0, 0, time.UTC), to},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Monthly returned %+v, want %+v", got, expected)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	p := Period{date(0, 0), date(0, 0).Add(100 * time.Hour)}
+	components := []cachet.Component{{ID: 1, Name: "API"}, {ID: 2, Name: "Website"}}
+	outages := []Outage{
+		// 2 hours major outage, overlapping with a partial outage that counts only outside of it.
+		{ComponentID: 1, Status: cachet.ComponentStatusMajorOutage, Start: date(10, 0), End: date(12, 0)},
+		{ComponentID: 1, Status: cachet.ComponentStatusPartialOutage, Start: date(11, 0), End: date(13, 0)},
+		// Within maintenance.
+		{ComponentID: 1, Status: cachet.ComponentStatusMajorOutage, Start: date(20, 0), End: date(21, 0)},
+		// Started before the period.
+		{ComponentID: 2, Status: cachet.ComponentStatusMajorOutage, Start: date(0, 0).Add(-time.Hour), End: date(1, 0)},
+	}
+	maintenances := []Maintenance{{Components: []int{1}, Start: date(20, 0), End: date(24, 0)}}
+
+	r := Compute(p, components, outages, maintenances, &Options{DefaultTarget: 99, Targets: map[int]float64{2: 99.5}})
+
+	api, website := r.Components[0], r.Components[1]
+	if api.Downtime != 150*time.Minute {
+		t.Errorf("Compute returned downtime %v, want %v", api.Downtime, 150*time.Minute)
+	}
+	if api.Maintenance != 4*time.Hour {
+		t.Errorf("Compute returned maintenance %v, want %v", api.Maintenance, 4*time.Hour)
+	}
+	if want := 100 * (1 - 2.5/96); math.Abs(api.Uptime-want) > 1e-9 {
+		t.Errorf("Compute returned uptime %v, want %v", api.Uptime, want)
+	}
+	if api.Target != 99 || api.Met {
+		t.Errorf("Compute returned target %v met %v, want 99 not met", api.Target, api.Met)
+	}
+	if len(api.Outages) != 3 {
+		t.Errorf("Compute returned %d outages, want 3", len(api.Outages))
+	}
+
+	if website.Downtime != time.Hour || website.Uptime != 99 || website.Target != 99.5 || website.Met {
+		t.Errorf("Compute returned %+v for the website", website)
+	}
+}
+
+func TestCompute_Weights(t *testing.T) {
+	p := Period{date(0, 0), date(0, 0).Add(10 * time.Hour)}
+	outages := []Outage{{ComponentID: 1, Status: cachet.ComponentStatusPerformanceIssues, Start: date(1, 0), End: date(2, 0)}}
+
+	r := Compute(p, []cachet.Component{{ID: 1}}, outages, nil, nil)
+	if r.Components[0].Uptime != 100 || !r.Components[0].Met {
+		t.Errorf("Compute returned %+v, want full uptime for performance issues", r.Components[0])
+	}
+
+	r = Compute(p, []cachet.Component{{ID: 1}}, outages, nil, &Options{Weights: map[int]float64{cachet.ComponentStatusPerformanceIssues: 0.1}})
+	if r.Components[0].Downtime != 6*time.Minute {
+		t.Errorf("Compute returned downtime %v, want %v", r.Components[0].Downtime, 6*time.Minute)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":2,"name":"API"}]}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"id":1,"component_id":2,"component_status":4,"status":4,"is_resolved":true,"occurred_at":"2017-10-10 10:00:00","updated_at":"2017-10-10 12:00:00"},
+			{"id":5,"component_id":2,"component_status":4,"status":4,"is_resolved":true,"occurred_at":"2017-08-10 10:00:00","updated_at":"2017-08-10 12:00:00"}
+		]}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents/1/updates", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":1,"total_pages":2}},"data":[{"id":3,"incident_id":1,"status":4,"component_status":1,"created_at":"2017-10-10 11:00:00"}]}`)
+		case "2":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":2,"total_pages":2}},"data":[{"id":2,"incident_id":1,"status":2,"component_status":3,"created_at":"2017-10-10 10:30:00"}]}`)
+		}
+	})
+	testMux.HandleFunc("/api/v1/incidents/5/updates", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Updates of an incident before the first period were requested")
+	})
+	testMux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":4,"status":2,"scheduled_at":"2017-10-11 10:00:00","completed_at":"2017-10-11 12:00:00"}]}`)
+	})
+
+	from := time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC)
+	reports, err := Fetch(testClient, Monthly(from, to), &Options{Now: to})
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("Fetch returned %d reports, want 2", len(reports))
+	}
+
+	october := reports[0].Components[0]
+	if october.Downtime != 45*time.Minute || len(october.Outages) != 2 || october.Maintenance != 2*time.Hour {
+		t.Errorf("Fetch returned %+v for October", october)
+	}
+	if november := reports[1].Components[0]; november.Uptime != 100 {
+		t.Errorf("Fetch returned %+v for November", november)
+	}
+}
@@ -0,0 +1,137 @@
+package sla
+
+import (
+	"sort"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+)
+
+// Outage is an interval in which a component had a degraded status because of an incident.
+type Outage struct {
+	ComponentID int
+	IncidentID  int
+	// Status is the cachet.ComponentStatus* of the component during the outage.
+	Status int
+	Start  time.Time
+	End    time.Time
+}
+
+// Maintenance is an interval in which components were under scheduled maintenance.
+type Maintenance struct {
+	ScheduleID int
+	// Components are the IDs of the affected components. No components means all components.
+	Components []int
+	Start      time.Time
+	End        time.Time
+}
+
+// covers reports whether the maintenance affects the component with the ID id.
+func (m Maintenance) covers(id int) bool {
+	if len(m.Components) == 0 {
+		return true
+	}
+	for _, c := range m.Components {
+		if c == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Outages reconstructs the outages of components from an incident and its updates.
+//
+// An outage starts when the incident occurred with the component status of the incident.
+// Every update with a component status starts a new outage with that status.
+// An update with the status fixed ends the outage, a later update reopens it.
+// If the incident is resolved without a fixed update, the outage ends at the last update of the incident.
+// Outages of unresolved incidents end at now.
+// Operational and unknown component statuses are no outage.
+func Outages(i cachet.Incident, updates []cachet.IncidentUpdate, loc *time.Location, now time.Time) []Outage {
+	if i.ComponentID == 0 || i.Status == cachet.IncidentStatusScheduled {
+		return nil
+	}
+
+	updates = append([]cachet.IncidentUpdate(nil), updates...)
+	sort.SliceStable(updates, func(a, b int) bool {
+		return api.ParseTime(loc, updates[a].CreatedAt).Before(api.ParseTime(loc, updates[b].CreatedAt))
+	})
+
+	var outages []Outage
+	var current *Outage
+	componentStatus := i.ComponentStatus
+
+	// switchTo ends the current outage at t and starts a new one with status, if it is an outage.
+	switchTo := func(t time.Time, status int) {
+		if current != nil {
+			if current.Status == status {
+				return
+			}
+			current.End = t
+			if current.End.After(current.Start) {
+				outages = append(outages, *current)
+			}
+			current = nil
+		}
+		if status > cachet.ComponentStatusOperational {
+			current = &Outage{ComponentID: i.ComponentID, IncidentID: i.ID, Status: status, Start: t}
+		}
+	}
+
+	switchTo(api.ParseTime(loc, i.OccurredAt, i.CreatedAt), componentStatus)
+	fixed := false
+	for _, u := range updates {
+		t := api.ParseTime(loc, u.CreatedAt)
+		if u.ComponentStatus > 0 {
+			componentStatus = u.ComponentStatus
+		}
+		if u.Status == cachet.IncidentStatusFixed {
+			fixed = true
+			switchTo(t, cachet.ComponentStatusOperational)
+			continue
+		}
+		fixed = false
+		switchTo(t, componentStatus)
+	}
+
+	if current != nil {
+		end := now
+		if !fixed && (i.IsResolved || i.Status == cachet.IncidentStatusFixed) {
+			end = api.ParseTime(loc, i.UpdatedAt)
+		}
+		current.End = end
+		if current.End.After(current.Start) {
+			outages = append(outages, *current)
+		}
+	}
+	return outages
+}
+
+// MaintenanceWindow returns the maintenance interval of a schedule.
+// Upcoming schedules and completed schedules without completion time are no maintenance.
+// Schedules in progress last until now.
+func MaintenanceWindow(s cachet.Schedule, loc *time.Location, now time.Time) (Maintenance, bool) {
+	start := api.ParseTime(loc, s.ScheduledAt)
+	end := api.ParseTime(loc, s.CompletedAt)
+
+	switch {
+	case start.IsZero():
+		return Maintenance{}, false
+	case s.Status == cachet.ScheduleInProgress && end.IsZero():
+		end = now
+	case s.Status == cachet.ScheduleUpcoming && start.After(now):
+		return Maintenance{}, false
+	case end.IsZero():
+		return Maintenance{}, false
+	}
+	if !end.After(start) {
+		return Maintenance{}, false
+	}
+
+	m := Maintenance{ScheduleID: s.ID, Start: start, End: end}
+	for _, c := range s.Components {
+		m.Components = append(m.Components, c.ID)
+	}
+	return m, true
+}
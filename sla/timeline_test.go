@@ -0,0 +1,114 @@
+package sla
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func date(hour, min int) time.Time {
+	return time.Date(2017, 10, 10, hour, min, 0, 0, time.UTC)
+}
+
+func TestOutages(t *testing.T) {
+	now := date(18, 0)
+	incident := cachet.Incident{
+		ID:              1,
+		ComponentID:     2,
+		ComponentStatus: cachet.ComponentStatusMajorOutage,
+		Status:          cachet.IncidentStatusFixed,
+		IsResolved:      true,
+		OccurredAt:      "2017-10-10 10:00:00",
+		UpdatedAt:       "2017-10-10 13:00:00",
+	}
+
+	mockData := []struct {
+		name     string
+		incident cachet.Incident
+		updates  []cachet.IncidentUpdate
+		expected []Outage
+	}{
+		{
+			"status changes and fix",
+			incident,
+			[]cachet.IncidentUpdate{
+				{Status: cachet.IncidentStatusFixed, CreatedAt: "2017-10-10 12:00:00"},
+				{Status: cachet.IncidentStatusIdentified, ComponentStatus: cachet.ComponentStatusPartialOutage, CreatedAt: "2017-10-10 11:00:00"},
+			},
+			[]Outage{
+				{ComponentID: 2, IncidentID: 1, Status: cachet.ComponentStatusMajorOutage, Start: date(10, 0), End: date(11, 0)},
+				{ComponentID: 2, IncidentID: 1, Status: cachet.ComponentStatusPartialOutage, Start: date(11, 0), End: date(12, 0)},
+			},
+		},
+		{
+			"resolved without fixed update",
+			incident,
+			nil,
+			[]Outage{{ComponentID: 2, IncidentID: 1, Status: cachet.ComponentStatusMajorOutage, Start: date(10, 0), End: date(13, 0)}},
+		},
+		{
+			"reopened",
+			cachet.Incident{ID: 1, ComponentID: 2, ComponentStatus: cachet.ComponentStatusPartialOutage, Status: cachet.IncidentStatusWatching, OccurredAt: "2017-10-10 10:00:00"},
+			[]cachet.IncidentUpdate{
+				{Status: cachet.IncidentStatusFixed, CreatedAt: "2017-10-10 11:00:00"},
+				{Status: cachet.IncidentStatusWatching, CreatedAt: "2017-10-10 12:00:00"},
+				{Status: cachet.IncidentStatusWatching, ComponentStatus: cachet.ComponentStatusOperational, CreatedAt: "2017-10-10 12:30:00"},
+			},
+			[]Outage{
+				{ComponentID: 2, IncidentID: 1, Status: cachet.ComponentStatusPartialOutage, Start: date(10, 0), End: date(11, 0)},
+				{ComponentID: 2, IncidentID: 1, Status: cachet.ComponentStatusPartialOutage, Start: date(12, 0), End: date(12, 30)},
+			},
+		},
+		{
+			"unresolved",
+			cachet.Incident{ID: 1, ComponentID: 2, ComponentStatus: cachet.ComponentStatusMajorOutage, Status: cachet.IncidentStatusInvestigating, CreatedAt: "2017-10-10 17:00:00"},
+			nil,
+			[]Outage{{ComponentID: 2, IncidentID: 1, Status: cachet.ComponentStatusMajorOutage, Start: date(17, 0), End: now}},
+		},
+		{
+			"without component",
+			cachet.Incident{ID: 1, ComponentStatus: cachet.ComponentStatusMajorOutage, OccurredAt: "2017-10-10 10:00:00"},
+			nil,
+			nil,
+		},
+	}
+
+	for _, data := range mockData {
+		got := Outages(data.incident, data.updates, time.UTC, now)
+		if !reflect.DeepEqual(got, data.expected) {
+			t.Errorf("Outages for %s returned %+v, want %+v", data.name, got, data.expected)
+		}
+	}
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	now := date(18, 0)
+
+	mockData := []struct {
+		schedule cachet.Schedule
+		expected Maintenance
+		ok       bool
+	}{
+		{
+			cachet.Schedule{ID: 1, Status: cachet.ScheduleComplete, ScheduledAt: "2017-10-10 10:00:00", CompletedAt: "2017-10-10 12:00:00", Components: []cachet.Component{{ID: 2}}},
+			Maintenance{ScheduleID: 1, Components: []int{2}, Start: date(10, 0), End: date(12, 0)},
+			true,
+		},
+		{
+			cachet.Schedule{ID: 2, Status: cachet.ScheduleInProgress, ScheduledAt: "2017-10-10 17:00:00"},
+			Maintenance{ScheduleID: 2, Start: date(17, 0), End: now},
+			true,
+		},
+		{cachet.Schedule{ID: 3, Status: cachet.ScheduleUpcoming, ScheduledAt: "2017-10-11 10:00:00", CompletedAt: "2017-10-11 12:00:00"}, Maintenance{}, false},
+		{cachet.Schedule{ID: 4, Status: cachet.ScheduleComplete, ScheduledAt: "2017-10-10 10:00:00"}, Maintenance{}, false},
+	}
+
+	for _, data := range mockData {
+		got, ok := MaintenanceWindow(data.schedule, time.UTC, now)
+		if ok != data.ok || !reflect.DeepEqual(got, data.expected) {
+			t.Errorf("MaintenanceWindow of schedule %d returned %+v, %v, want %+v, %v", data.schedule.ID, got, ok, data.expected, data.ok)
+		}
+	}
+}
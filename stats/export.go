@@ -0,0 +1,194 @@
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/andygrunwald/cachet/internal/api"
+)
+
+// groupHeader is the CSV header of groups.
+var groupHeader = []string{"dimension", "key", "count", "acknowledged", "identified", "resolved", "total_duration", "mtta", "mtti", "mttr"}
+
+// incidentHeader is the CSV header of incidents.
+var incidentHeader = []string{"id", "name", "component", "occurred_at", "resolved_at", "time_to_acknowledge", "time_to_identify", "time_to_resolve", "duration"}
+
+// jsonGroup is the JSON form of a Group. Durations are seconds.
+type jsonGroup struct {
+	Key                   string  `json:"key"`
+	Count                 int     `json:"count"`
+	Acknowledged          int     `json:"acknowledged"`
+	Identified            int     `json:"identified"`
+	Resolved              int     `json:"resolved"`
+	TotalDuration         float64 `json:"total_duration"`
+	MeanTimeToAcknowledge float64 `json:"mtta"`
+	MeanTimeToIdentify    float64 `json:"mtti"`
+	MeanTimeToResolve     float64 `json:"mttr"`
+}
+
+// jsonIncident is the JSON form of an Incident. Durations are seconds.
+type jsonIncident struct {
+	ID                int     `json:"id"`
+	Name              string  `json:"name"`
+	ComponentID       int     `json:"component_id"`
+	Component         string  `json:"component"`
+	OccurredAt        string  `json:"occurred_at"`
+	Acknowledged      bool    `json:"acknowledged"`
+	Identified        bool    `json:"identified"`
+	Resolved          bool    `json:"resolved"`
+	ResolvedAt        string  `json:"resolved_at"`
+	TimeToAcknowledge float64 `json:"time_to_acknowledge"`
+	TimeToIdentify    float64 `json:"time_to_identify"`
+	TimeToResolve     float64 `json:"time_to_resolve"`
+	Duration          float64 `json:"duration"`
+}
+
+// WriteJSON writes the report as JSON to w. Durations are written in seconds.
+func WriteJSON(w io.Writer, r *Report) error {
+	v := struct {
+		Total       jsonGroup      `json:"total"`
+		ByComponent []jsonGroup    `json:"by_component"`
+		ByMonth     []jsonGroup    `json:"by_month"`
+		Incidents   []jsonIncident `json:"incidents"`
+	}{
+		Total:       toJSONGroup(r.Total),
+		ByComponent: []jsonGroup{},
+		ByMonth:     []jsonGroup{},
+		Incidents:   []jsonIncident{},
+	}
+	for _, g := range r.ByComponent {
+		v.ByComponent = append(v.ByComponent, toJSONGroup(g))
+	}
+	for _, g := range r.ByMonth {
+		v.ByMonth = append(v.ByMonth, toJSONGroup(g))
+	}
+	for _, i := range r.Incidents {
+		v.Incidents = append(v.Incidents, jsonIncident{
+			ID:                i.ID,
+			Name:              i.Name,
+			ComponentID:       i.ComponentID,
+			Component:         i.Component,
+			OccurredAt:        formatTime(i.OccurredAt),
+			Acknowledged:      i.Acknowledged,
+			Identified:        i.Identified,
+			Resolved:          i.Resolved,
+			ResolvedAt:        formatTime(i.ResolvedAt),
+			TimeToAcknowledge: i.TimeToAcknowledge.Seconds(),
+			TimeToIdentify:    i.TimeToIdentify.Seconds(),
+			TimeToResolve:     i.TimeToResolve.Seconds(),
+			Duration:          i.Duration.Seconds(),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// WriteCSV writes the aggregated groups of the report as CSV to w.
+// The dimension column is total, component or month. Durations are written in seconds.
+func WriteCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(groupHeader); err != nil {
+		return err
+	}
+
+	if err := cw.Write(groupRecord("total", r.Total)); err != nil {
+		return err
+	}
+	for _, g := range r.ByComponent {
+		if err := cw.Write(groupRecord("component", g)); err != nil {
+			return err
+		}
+	}
+	for _, g := range r.ByMonth {
+		if err := cw.Write(groupRecord("month", g)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteIncidentsCSV writes the statistics of the single incidents as CSV to w.
+// Durations are written in seconds, the durations of steps that did not happen are empty.
+func WriteIncidentsCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(incidentHeader); err != nil {
+		return err
+	}
+
+	for _, i := range r.Incidents {
+		record := []string{
+			strconv.Itoa(i.ID),
+			i.Name,
+			i.Component,
+			formatTime(i.OccurredAt),
+			formatTime(i.ResolvedAt),
+			step(i.Acknowledged, i.TimeToAcknowledge),
+			step(i.Identified, i.TimeToIdentify),
+			step(i.Resolved, i.TimeToResolve),
+			seconds(i.Duration),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func groupRecord(dimension string, g Group) []string {
+	return []string{
+		dimension,
+		g.Key,
+		strconv.Itoa(g.Count),
+		strconv.Itoa(g.Acknowledged),
+		strconv.Itoa(g.Identified),
+		strconv.Itoa(g.Resolved),
+		seconds(g.TotalDuration),
+		seconds(g.MeanTimeToAcknowledge),
+		seconds(g.MeanTimeToIdentify),
+		seconds(g.MeanTimeToResolve),
+	}
+}
+
+func toJSONGroup(g Group) jsonGroup {
+	return jsonGroup{
+		Key:                   g.Key,
+		Count:                 g.Count,
+		Acknowledged:          g.Acknowledged,
+		Identified:            g.Identified,
+		Resolved:              g.Resolved,
+		TotalDuration:         g.TotalDuration.Seconds(),
+		MeanTimeToAcknowledge: g.MeanTimeToAcknowledge.Seconds(),
+		MeanTimeToIdentify:    g.MeanTimeToIdentify.Seconds(),
+		MeanTimeToResolve:     g.MeanTimeToResolve.Seconds(),
+	}
+}
+
+// seconds formats d as seconds without trailing zeros.
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// step formats the duration of a step as seconds or empty if the step did not happen.
+func step(happened bool, d time.Duration) string {
+	if !happened {
+		return ""
+	}
+	return seconds(d)
+}
+
+// formatTime formats t in the timestamp layout of the Cachet API. Zero times are empty.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(api.TimestampLayout)
+}
@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testReport()); err != nil {
+		t.Fatalf("WriteCSV returned error: %v", err)
+	}
+
+	expected := `dimension,key,count,acknowledged,identified,resolved,total_duration,mtta,mtti,mttr
+total,total,3,2,2,2,32400,2100,3600,10800
+component,API,2,2,2,2,21600,2100,3600,10800
+component,none,1,0,0,0,10800,0,0,0
+month,2017-09,1,1,1,1,7200,600,3600,7200
+month,2017-10,2,1,1,1,25200,3600,3600,14400
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("WriteCSV returned\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestWriteIncidentsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteIncidentsCSV(&buf, testReport()); err != nil {
+		t.Fatalf("WriteIncidentsCSV returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("WriteIncidentsCSV returned %d lines, want 4", len(lines))
+	}
+	if expected := "1,Slow API,API,2017-09-30 10:00:00,2017-09-30 12:00:00,600,3600,7200,7200"; lines[1] != expected {
+		t.Errorf("WriteIncidentsCSV returned %q, want %q", lines[1], expected)
+	}
+	if expected := "3,Mails delayed,none,2017-10-11 10:00:00,,,,,10800"; lines[3] != expected {
+		t.Errorf("WriteIncidentsCSV returned %q, want %q", lines[3], expected)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, testReport()); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+
+	var got struct {
+		Total struct {
+			Count int     `json:"count"`
+			MTTR  float64 `json:"mttr"`
+		} `json:"total"`
+		ByComponent []map[string]interface{} `json:"by_component"`
+		ByMonth     []map[string]interface{} `json:"by_month"`
+		Incidents   []map[string]interface{} `json:"incidents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("WriteJSON returned invalid JSON: %v", err)
+	}
+	if got.Total.Count != 3 || got.Total.MTTR != 10800 || len(got.ByComponent) != 2 || len(got.ByMonth) != 2 || len(got.Incidents) != 3 {
+		t.Errorf("WriteJSON returned %s", buf.String())
+	}
+	if got.Incidents[0]["acknowledged"] != true || got.Incidents[2]["acknowledged"] != false {
+		t.Errorf("WriteJSON returned acknowledged %v and %v, want true and false", got.Incidents[0]["acknowledged"], got.Incidents[2]["acknowledged"])
+	}
+	if got.Incidents[2]["resolved_at"] != "" {
+		t.Errorf("WriteJSON returned resolved_at %v for an unresolved incident", got.Incidents[2]["resolved_at"])
+	}
+}
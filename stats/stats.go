@@ -0,0 +1,262 @@
+/*
+Package stats aggregates incident statistics for reliability reviews.
+
+For every incident the time to acknowledge (first update), the time to identify
+(first update with the status identified or later) and the time to resolve (fixed)
+are calculated. The statistics are aggregated in total, by component and by month:
+
+	r, err := stats.Fetch(client, &stats.Options{From: from, To: to})
+
+	fmt.Printf("MTTR: %v\n", r.Total.MeanTimeToResolve)
+	err = stats.WriteCSV(os.Stdout, r)
+*/
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// monthLayout is the key of the monthly groups.
+const monthLayout = "2006-01"
+
+// NoComponent is the key of incidents without component.
+const NoComponent = "none"
+
+// Options configures the report.
+type Options struct {
+	// From and To select the incidents that occurred in [From, To). Zero values are unbounded.
+	From time.Time
+	To   time.Time
+	// Location is the time zone of the Cachet instance and of the months. It defaults to UTC.
+	Location *time.Location
+	// Now is the end of unresolved incidents. It defaults to the current time.
+	Now time.Time
+}
+
+func (o *Options) includes(t time.Time) bool {
+	if o == nil {
+		return true
+	}
+	if !o.From.IsZero() && t.Before(o.From) {
+		return false
+	}
+	if !o.To.IsZero() && !t.Before(o.To) {
+		return false
+	}
+	return true
+}
+
+// Incident are the statistics of a single incident.
+// Durations of steps that did not happen yet are 0,
+// Acknowledged, Identified and Resolved report whether a step happened.
+type Incident struct {
+	ID           int
+	Name         string
+	ComponentID  int
+	Component    string
+	OccurredAt   time.Time
+	Acknowledged bool
+	Identified   bool
+	Resolved     bool
+	// ResolvedAt is zero for unresolved incidents.
+	ResolvedAt        time.Time
+	TimeToAcknowledge time.Duration
+	TimeToIdentify    time.Duration
+	TimeToResolve     time.Duration
+	// Duration is the time to resolve or, for unresolved incidents, the time until now.
+	Duration time.Duration
+}
+
+// Group are the aggregated statistics of a set of incidents.
+// Means are calculated over the incidents that reached the step.
+type Group struct {
+	Key   string
+	Count int
+	// Acknowledged, Identified and Resolved are the numbers of incidents that reached the step.
+	Acknowledged          int
+	Identified            int
+	Resolved              int
+	TotalDuration         time.Duration
+	MeanTimeToAcknowledge time.Duration
+	MeanTimeToIdentify    time.Duration
+	MeanTimeToResolve     time.Duration
+}
+
+// Report are the statistics of all incidents.
+type Report struct {
+	Incidents   []Incident
+	Total       Group
+	ByComponent []Group
+	ByMonth     []Group
+}
+
+// Fetch fetches the incidents, their updates and the components and computes the report.
+func Fetch(client *cachet.Client, o *Options) (*Report, error) {
+	if o == nil {
+		o = &Options{}
+	}
+	loc := api.Location(o.Location)
+
+	components, err := fetch.Components(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := map[int]string{}
+	for _, c := range components {
+		names[c.ID] = c.Name
+	}
+
+	incidents, err := fetch.Incidents(client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []cachet.Incident
+	updates := map[int][]cachet.IncidentUpdate{}
+	for _, i := range incidents {
+		if i.Status == cachet.IncidentStatusScheduled || !o.includes(api.ParseTime(loc, i.OccurredAt, i.CreatedAt)) {
+			continue
+		}
+		u, err := fetch.IncidentUpdates(client, i.ID)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, i)
+		updates[i.ID] = u
+	}
+
+	return Compute(selected, updates, names, o), nil
+}
+
+// Compute computes the report of incidents.
+// updates maps incident IDs to their updates, names maps component IDs to their names.
+// Scheduled incidents and incidents that did not occur between From and To are skipped.
+func Compute(incidents []cachet.Incident, updates map[int][]cachet.IncidentUpdate, names map[int]string, o *Options) *Report {
+	if o == nil {
+		o = &Options{}
+	}
+	loc, now := api.Location(o.Location), api.Now(o.Now)
+
+	r := &Report{Incidents: []Incident{}, ByComponent: []Group{}, ByMonth: []Group{}}
+	for _, i := range incidents {
+		s := incidentStats(i, updates[i.ID], loc, now)
+		if i.Status == cachet.IncidentStatusScheduled || !o.includes(s.OccurredAt) {
+			continue
+		}
+		s.Component = NoComponent
+		if i.ComponentID > 0 {
+			s.Component = names[i.ComponentID]
+			if len(s.Component) == 0 {
+				s.Component = fmt.Sprintf("#%d", i.ComponentID)
+			}
+		}
+		r.Incidents = append(r.Incidents, s)
+	}
+	sort.SliceStable(r.Incidents, func(a, b int) bool { return r.Incidents[a].OccurredAt.Before(r.Incidents[b].OccurredAt) })
+
+	r.Total = aggregate("total", r.Incidents)
+	r.ByComponent = groupBy(r.Incidents, func(s Incident) string { return s.Component })
+	r.ByMonth = groupBy(r.Incidents, func(s Incident) string { return s.OccurredAt.In(loc).Format(monthLayout) })
+	return r
+}
+
+// incidentStats calculates the statistics of an incident from its updates.
+// A reopened incident is resolved by the last fixed update.
+func incidentStats(i cachet.Incident, updates []cachet.IncidentUpdate, loc *time.Location, now time.Time) Incident {
+	updates = append([]cachet.IncidentUpdate(nil), updates...)
+	sort.SliceStable(updates, func(a, b int) bool {
+		return api.ParseTime(loc, updates[a].CreatedAt).Before(api.ParseTime(loc, updates[b].CreatedAt))
+	})
+
+	s := Incident{ID: i.ID, Name: i.Name, ComponentID: i.ComponentID, OccurredAt: api.ParseTime(loc, i.OccurredAt, i.CreatedAt)}
+
+	var resolved time.Time
+	for _, u := range updates {
+		t := api.ParseTime(loc, u.CreatedAt)
+		if !s.Acknowledged {
+			s.Acknowledged = true
+			s.TimeToAcknowledge = api.Since(s.OccurredAt, t)
+		}
+		if !s.Identified && u.Status >= cachet.IncidentStatusIdentified {
+			s.Identified = true
+			s.TimeToIdentify = api.Since(s.OccurredAt, t)
+		}
+		if u.Status == cachet.IncidentStatusFixed {
+			resolved = t
+		} else {
+			resolved = time.Time{}
+		}
+	}
+	if resolved.IsZero() && (i.IsResolved || i.Status == cachet.IncidentStatusFixed) {
+		resolved = api.ParseTime(loc, i.UpdatedAt)
+	}
+
+	s.Duration = api.Since(s.OccurredAt, now)
+	if !resolved.IsZero() {
+		s.Resolved = true
+		s.ResolvedAt = resolved
+		s.TimeToResolve = api.Since(s.OccurredAt, resolved)
+		s.Duration = s.TimeToResolve
+	}
+	return s
+}
+
+// groupBy aggregates incidents by the key returned by key. Groups are sorted by key.
+func groupBy(incidents []Incident, key func(Incident) string) []Group {
+	grouped := map[string][]Incident{}
+	var keys []string
+	for _, s := range incidents {
+		k := key(s)
+		if _, ok := grouped[k]; !ok {
+			keys = append(keys, k)
+		}
+		grouped[k] = append(grouped[k], s)
+	}
+	sort.Strings(keys)
+
+	groups := make([]Group, 0, len(keys))
+	for _, k := range keys {
+		groups = append(groups, aggregate(k, grouped[k]))
+	}
+	return groups
+}
+
+// aggregate calculates the statistics of a group of incidents.
+func aggregate(key string, incidents []Incident) Group {
+	g := Group{Key: key, Count: len(incidents)}
+
+	var toAcknowledge, toIdentify, toResolve time.Duration
+	for _, s := range incidents {
+		g.TotalDuration += s.Duration
+		if s.Acknowledged {
+			g.Acknowledged++
+			toAcknowledge += s.TimeToAcknowledge
+		}
+		if s.Identified {
+			g.Identified++
+			toIdentify += s.TimeToIdentify
+		}
+		if s.Resolved {
+			g.Resolved++
+			toResolve += s.TimeToResolve
+		}
+	}
+
+	g.MeanTimeToAcknowledge = mean(toAcknowledge, g.Acknowledged)
+	g.MeanTimeToIdentify = mean(toIdentify, g.Identified)
+	g.MeanTimeToResolve = mean(toResolve, g.Resolved)
+	return g
+}
+
+func mean(total time.Duration, n int) time.Duration {
+	if n == 0 {
+		return 0
+	}
+	return total / time.Duration(n)
+}
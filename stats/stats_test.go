@@ -0,0 +1,166 @@
+package stats
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+// testReport returns a report of two resolved incidents on the API and one unresolved incident without component.
+func testReport() *Report {
+	incidents := []cachet.Incident{
+		{ID: 1, Name: "Slow API", ComponentID: 2, Status: cachet.IncidentStatusFixed, OccurredAt: "2017-09-30 10:00:00"},
+		{ID: 2, Name: "API down", ComponentID: 2, Status: cachet.IncidentStatusFixed, IsResolved: true, OccurredAt: "2017-10-10 10:00:00", UpdatedAt: "2017-10-10 14:00:00"},
+		{ID: 3, Name: "Mails delayed", Status: cachet.IncidentStatusInvestigating, OccurredAt: "2017-10-11 10:00:00"},
+		{ID: 4, Name: "Maintenance", Status: cachet.IncidentStatusScheduled, OccurredAt: "2017-10-11 10:00:00"},
+	}
+	updates := map[int][]cachet.IncidentUpdate{
+		1: {
+			{Status: cachet.IncidentStatusFixed, CreatedAt: "2017-09-30 12:00:00"},
+			{Status: cachet.IncidentStatusIdentified, CreatedAt: "2017-09-30 11:00:00"},
+			{Status: cachet.IncidentStatusInvestigating, CreatedAt: "2017-09-30 10:10:00"},
+		},
+		2: {{Status: cachet.IncidentStatusWatching, CreatedAt: "2017-10-10 11:00:00"}},
+	}
+	now := time.Date(2017, 10, 11, 13, 0, 0, 0, time.UTC)
+	return Compute(incidents, updates, map[int]string{2: "API"}, &Options{Now: now})
+}
+
+func TestCompute(t *testing.T) {
+	r := testReport()
+
+	if len(r.Incidents) != 3 {
+		t.Fatalf("Compute returned %d incidents, want 3", len(r.Incidents))
+	}
+	first := r.Incidents[0]
+	if first.TimeToAcknowledge != 10*time.Minute || first.TimeToIdentify != time.Hour || first.TimeToResolve != 2*time.Hour || !first.Resolved {
+		t.Errorf("Compute returned %+v for the first incident", first)
+	}
+	if second := r.Incidents[1]; second.TimeToIdentify != time.Hour || second.TimeToResolve != 4*time.Hour {
+		t.Errorf("Compute returned %+v for the incident resolved without fixed update", second)
+	}
+	if third := r.Incidents[2]; third.Resolved || third.Duration != 3*time.Hour || third.Component != NoComponent {
+		t.Errorf("Compute returned %+v for the unresolved incident", third)
+	}
+
+	expected := Group{
+		Key:                   "total",
+		Count:                 3,
+		Acknowledged:          2,
+		Identified:            2,
+		Resolved:              2,
+		TotalDuration:         9 * time.Hour,
+		MeanTimeToAcknowledge: 35 * time.Minute,
+		MeanTimeToIdentify:    time.Hour,
+		MeanTimeToResolve:     3 * time.Hour,
+	}
+	if !reflect.DeepEqual(r.Total, expected) {
+		t.Errorf("Compute returned total %+v, want %+v", r.Total, expected)
+	}
+
+	var keys []string
+	for _, g := range r.ByComponent {
+		keys = append(keys, fmt.Sprintf("%s=%d", g.Key, g.Count))
+	}
+	for _, g := range r.ByMonth {
+		keys = append(keys, fmt.Sprintf("%s=%d", g.Key, g.Count))
+	}
+	if want := []string{"API=2", "none=1", "2017-09=1", "2017-10=2"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Compute returned groups %v, want %v", keys, want)
+	}
+}
+
+func TestCompute_Reopened(t *testing.T) {
+	incidents := []cachet.Incident{{ID: 1, Status: cachet.IncidentStatusFixed, OccurredAt: "2017-10-10 10:00:00"}}
+	updates := map[int][]cachet.IncidentUpdate{1: {
+		{Status: cachet.IncidentStatusFixed, CreatedAt: "2017-10-10 11:00:00"},
+		{Status: cachet.IncidentStatusWatching, CreatedAt: "2017-10-10 12:00:00"},
+		{Status: cachet.IncidentStatusFixed, CreatedAt: "2017-10-10 13:00:00"},
+	}}
+
+	r := Compute(incidents, updates, nil, nil)
+	if got := r.Incidents[0].TimeToResolve; got != 3*time.Hour {
+		t.Errorf("Compute returned time to resolve %v, want %v", got, 3*time.Hour)
+	}
+}
+
+func TestCompute_Instant(t *testing.T) {
+	incidents := []cachet.Incident{
+		{ID: 1, Status: cachet.IncidentStatusIdentified, OccurredAt: "2017-10-10 10:00:00"},
+		{ID: 2, Status: cachet.IncidentStatusInvestigating, OccurredAt: "2017-10-10 10:00:00"},
+	}
+	updates := map[int][]cachet.IncidentUpdate{1: {{Status: cachet.IncidentStatusIdentified, CreatedAt: "2017-10-10 10:00:00"}}}
+
+	r := Compute(incidents, updates, nil, nil)
+	if first := r.Incidents[0]; !first.Acknowledged || !first.Identified || first.TimeToAcknowledge != 0 || first.TimeToIdentify != 0 {
+		t.Errorf("Compute returned %+v for the incident identified instantly", first)
+	}
+	if second := r.Incidents[1]; second.Acknowledged || second.Identified {
+		t.Errorf("Compute returned %+v for the incident without updates", second)
+	}
+	if r.Total.Acknowledged != 1 || r.Total.Identified != 1 {
+		t.Errorf("Compute returned %d acknowledged and %d identified incidents, want 1 and 1", r.Total.Acknowledged, r.Total.Identified)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":2,"name":"API"}]}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"id":1,"name":"API down","component_id":2,"status":4,"occurred_at":"2017-10-10 10:00:00"},
+			{"id":2,"name":"Old","status":4,"occurred_at":"2017-08-10 10:00:00"}
+		]}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents/1/updates", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":1,"total_pages":2}},"data":[{"id":4,"incident_id":1,"status":4,"created_at":"2017-10-10 10:30:00"}]}`)
+		case "2":
+			fmt.Fprint(w, `{"meta":{"pagination":{"current_page":2,"total_pages":2}},"data":[{"id":3,"incident_id":1,"status":1,"created_at":"2017-10-10 10:05:00"}]}`)
+		}
+	})
+	testMux.HandleFunc("/api/v1/incidents/2/updates", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Updates of an incident before From were requested")
+	})
+
+	r, err := Fetch(testClient, &Options{From: time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if len(r.Incidents) != 1 || r.Incidents[0].Component != "API" || r.Total.MeanTimeToResolve != 30*time.Minute || r.Total.MeanTimeToAcknowledge != 5*time.Minute {
+		t.Errorf("Fetch returned %+v", r)
+	}
+}
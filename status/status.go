@@ -0,0 +1,205 @@
+/*
+Package status computes the overall status of an instance like the Cachet status page does.
+
+	summary, err := status.Fetch(client, nil)
+	fmt.Println(summary.Message) // e.g. "Some systems are experiencing issues"
+
+Only enabled components are taken into account.
+The JSON encoding of a Summary is stable: groups and components are always in the same order.
+*/
+package status
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+)
+
+// Levels of the overall status, as used by the status page for the color of the banner.
+const (
+	LevelSuccess = "success"
+	LevelInfo    = "info"
+	LevelDanger  = "danger"
+)
+
+// Messages of the overall status, as shown in the banner of the status page.
+// Like Cachet, instances with up to one enabled component use the singular forms.
+const (
+	MessageOperational         = "All systems are operational"
+	MessageOperationalSingular = "System operational"
+	MessageIssues              = "Some systems are experiencing issues"
+	MessageIssuesSingular      = "The system is experiencing issues"
+	MessageMajorIssues         = "Some systems are experiencing major issues"
+	MessageMajorIssuesSingular = "The system is experiencing major issues"
+)
+
+// DefaultMajorOutageRate is the share of components in a major outage at which the whole system has major issues.
+const DefaultMajorOutageRate = 0.5
+
+// humanStatuses contains the human readable component statuses.
+var humanStatuses = map[int]string{
+	cachet.ComponentStatusUnknown:           "Unknown",
+	cachet.ComponentStatusOperational:       "Operational",
+	cachet.ComponentStatusPerformanceIssues: "Performance Issues",
+	cachet.ComponentStatusPartialOutage:     "Partial Outage",
+	cachet.ComponentStatusMajorOutage:       "Major Outage",
+}
+
+// HumanStatus returns the human readable name of a component status.
+func HumanStatus(status int) string {
+	if name, ok := humanStatuses[status]; ok {
+		return name
+	}
+	return humanStatuses[cachet.ComponentStatusUnknown]
+}
+
+// humanIncidentStatuses contains the human readable incident statuses.
+var humanIncidentStatuses = map[int]string{
+	cachet.IncidentStatusScheduled:     "Scheduled",
+	cachet.IncidentStatusInvestigating: "Investigating",
+	cachet.IncidentStatusIdentified:    "Identified",
+	cachet.IncidentStatusWatching:      "Watching",
+	cachet.IncidentStatusFixed:         "Fixed",
+}
+
+// HumanIncidentStatus returns the human readable name of an incident status.
+// Unknown statuses are named by their number, e.g. "Status 7".
+func HumanIncidentStatus(status int) string {
+	if name, ok := humanIncidentStatuses[status]; ok {
+		return name
+	}
+	return fmt.Sprintf("Status %d", status)
+}
+
+// Options configures the computation.
+type Options struct {
+	// MajorOutageRate is the share of enabled components in a major outage (0 to 1)
+	// at which the system has major issues. It defaults to DefaultMajorOutageRate.
+	MajorOutageRate float64
+}
+
+// Summary is the overall status of an instance.
+type Summary struct {
+	// Status is the worst status of all enabled components.
+	Status      int    `json:"status"`
+	HumanStatus string `json:"human_status"`
+	Level       string `json:"level"`
+	Message     string `json:"message"`
+
+	Groups []GroupSummary `json:"groups"`
+	// Components contains the enabled components without group.
+	Components []ComponentSummary `json:"components"`
+}
+
+// GroupSummary is the status of a component group.
+type GroupSummary struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Status is the worst status of the enabled components of the group.
+	// It is unknown (0) for groups without enabled components.
+	Status      int    `json:"status"`
+	HumanStatus string `json:"human_status"`
+	// Components contains the enabled components of the group, the worst status first.
+	Components []ComponentSummary `json:"components"`
+}
+
+// ComponentSummary is the status of a component.
+type ComponentSummary struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Status      int    `json:"status"`
+	HumanStatus string `json:"human_status"`
+}
+
+// Fetch fetches all component groups and components and computes their status.
+func Fetch(client *cachet.Client, o *Options) (*Summary, error) {
+	groups, err := fetch.ComponentGroups(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	components, err := fetch.Components(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	return Compute(groups, components, o), nil
+}
+
+// Compute computes the status of the groups and components.
+func Compute(groups []cachet.ComponentGroup, components []cachet.Component, o *Options) *Summary {
+	rate := DefaultMajorOutageRate
+	if o != nil && o.MajorOutageRate > 0 {
+		rate = o.MajorOutageRate
+	}
+
+	groups = append([]cachet.ComponentGroup(nil), groups...)
+	sort.SliceStable(groups, func(i, j int) bool { return api.Less(groups[i].Order, groups[i].ID, groups[j].Order, groups[j].ID) })
+	components = append([]cachet.Component(nil), components...)
+	sort.SliceStable(components, func(i, j int) bool {
+		return api.Less(components[i].Order, components[i].ID, components[j].Order, components[j].ID)
+	})
+
+	s := &Summary{Groups: []GroupSummary{}, Components: []ComponentSummary{}}
+	index := map[int]int{}
+	for _, g := range groups {
+		index[g.ID] = len(s.Groups)
+		s.Groups = append(s.Groups, GroupSummary{ID: g.ID, Name: g.Name, Components: []ComponentSummary{}})
+	}
+
+	enabled, operational, majorOutages := 0, 0, 0
+	for _, c := range components {
+		if !c.Enabled {
+			continue
+		}
+		enabled++
+		switch c.Status {
+		case cachet.ComponentStatusOperational:
+			operational++
+		case cachet.ComponentStatusMajorOutage:
+			majorOutages++
+		}
+		if c.Status > s.Status {
+			s.Status = c.Status
+		}
+
+		cs := ComponentSummary{ID: c.ID, Name: c.Name, Status: c.Status, HumanStatus: HumanStatus(c.Status)}
+		i, ok := index[c.GroupID]
+		if !ok {
+			s.Components = append(s.Components, cs)
+			continue
+		}
+		g := &s.Groups[i]
+		g.Components = append(g.Components, cs)
+		if c.Status > g.Status {
+			g.Status = c.Status
+		}
+	}
+
+	for i := range s.Groups {
+		g := &s.Groups[i]
+		g.HumanStatus = HumanStatus(g.Status)
+		// Like EnabledComponentsLowest of the API: the worst status first.
+		sort.SliceStable(g.Components, func(i, j int) bool { return g.Components[i].Status > g.Components[j].Status })
+	}
+
+	s.HumanStatus = HumanStatus(s.Status)
+	switch {
+	case enabled == operational:
+		s.Level, s.Message = LevelSuccess, message(enabled, MessageOperationalSingular, MessageOperational)
+	case float64(majorOutages)/float64(enabled) >= rate:
+		s.Level, s.Message = LevelDanger, message(enabled, MessageMajorIssuesSingular, MessageMajorIssues)
+	default:
+		s.Level, s.Message = LevelInfo, message(enabled, MessageIssuesSingular, MessageIssues)
+	}
+	return s
+}
+
+// message chooses the singular or plural form by the number of enabled components, like trans_choice in Cachet.
+func message(enabled int, singular, plural string) string {
+	if enabled <= 1 {
+		return singular
+	}
+	return plural
+}
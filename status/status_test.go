@@ -0,0 +1,135 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+func TestCompute(t *testing.T) {
+	groups := []cachet.ComponentGroup{
+		{ID: 2, Name: "APIs", Order: 1},
+		{ID: 1, Name: "Websites", Order: 1},
+		{ID: 3, Name: "Empty", Order: 0},
+	}
+	components := []cachet.Component{
+		{ID: 1, Name: "Blog", Status: 1, Enabled: true, GroupID: 1},
+		{ID: 2, Name: "Shop", Status: 3, Enabled: true, GroupID: 1},
+		{ID: 3, Name: "REST", Status: 1, Enabled: true, GroupID: 2},
+		{ID: 4, Name: "Old", Status: 4, Enabled: false, GroupID: 2},
+		{ID: 5, Name: "DNS", Status: 2, Enabled: true},
+		{ID: 6, Name: "Disabled", Status: 4, GroupID: 3},
+	}
+
+	got := Compute(groups, components, nil)
+
+	expected := &Summary{
+		Status:      3,
+		HumanStatus: "Partial Outage",
+		Level:       LevelInfo,
+		Message:     MessageIssues,
+		Groups: []GroupSummary{
+			{ID: 3, Name: "Empty", Status: 0, HumanStatus: "Unknown", Components: []ComponentSummary{}},
+			{ID: 1, Name: "Websites", Status: 3, HumanStatus: "Partial Outage", Components: []ComponentSummary{
+				{ID: 2, Name: "Shop", Status: 3, HumanStatus: "Partial Outage"},
+				{ID: 1, Name: "Blog", Status: 1, HumanStatus: "Operational"},
+			}},
+			{ID: 2, Name: "APIs", Status: 1, HumanStatus: "Operational", Components: []ComponentSummary{
+				{ID: 3, Name: "REST", Status: 1, HumanStatus: "Operational"},
+			}},
+		},
+		Components: []ComponentSummary{{ID: 5, Name: "DNS", Status: 2, HumanStatus: "Performance Issues"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Compute returned %+v, want %+v", got, expected)
+	}
+}
+
+func TestCompute_Message(t *testing.T) {
+	mockData := []struct {
+		statuses []int
+		rate     float64
+		level    string
+		message  string
+	}{
+		{nil, 0, LevelSuccess, MessageOperationalSingular},
+		{[]int{1}, 0, LevelSuccess, MessageOperationalSingular},
+		{[]int{1, 1}, 0, LevelSuccess, MessageOperational},
+		{[]int{1, 2}, 0, LevelInfo, MessageIssues},
+		{[]int{1, 1, 4}, 0, LevelInfo, MessageIssues},
+		{[]int{1, 4}, 0, LevelDanger, MessageMajorIssues},
+		{[]int{1, 1, 4}, 0.3, LevelDanger, MessageMajorIssues},
+		{[]int{0}, 0, LevelInfo, MessageIssuesSingular},
+		{[]int{3}, 0, LevelInfo, MessageIssuesSingular},
+		{[]int{4}, 0, LevelDanger, MessageMajorIssuesSingular},
+	}
+
+	for _, data := range mockData {
+		var components []cachet.Component
+		for i, s := range data.statuses {
+			components = append(components, cachet.Component{ID: i + 1, Status: s, Enabled: true})
+		}
+		got := Compute(nil, components, &Options{MajorOutageRate: data.rate})
+		if got.Level != data.level || got.Message != data.message {
+			t.Errorf("Compute for %v returned %q %q, want %q %q", data.statuses, got.Level, got.Message, data.level, data.message)
+		}
+	}
+}
+
+func TestSummary_JSON(t *testing.T) {
+	s := Compute([]cachet.ComponentGroup{{ID: 1, Name: "Websites"}}, []cachet.Component{{ID: 1, Name: "Blog", Status: 1, Enabled: true, GroupID: 1}}, nil)
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+
+	expected := `{"status":1,"human_status":"Operational","level":"success","message":"System operational",` +
+		`"groups":[{"id":1,"name":"Websites","status":1,"human_status":"Operational","components":[{"id":1,"name":"Blog","status":1,"human_status":"Operational"}]}],` +
+		`"components":[]}`
+	if string(b) != expected {
+		t.Errorf("json.Marshal returned %s, want %s", b, expected)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client, _ := cachet.NewClient(server.URL, nil)
+
+	mux.HandleFunc("/api/v1/components/groups", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":1,"name":"Websites"}]}`)
+	})
+	mux.HandleFunc("/api/v1/components", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":1,"name":"Blog","status":4,"enabled":true,"group_id":1}]}`)
+	})
+
+	got, err := Fetch(client, nil)
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if got.Message != MessageMajorIssuesSingular || len(got.Groups) != 1 || got.Groups[0].Status != 4 {
+		t.Errorf("Fetch returned %+v, want a major outage of group 1", got)
+	}
+}
+
+func TestHumanIncidentStatus(t *testing.T) {
+	mockData := map[int]string{
+		cachet.IncidentStatusScheduled:  "Scheduled",
+		cachet.IncidentStatusIdentified: "Identified",
+		cachet.IncidentStatusFixed:      "Fixed",
+		7:                               "Status 7",
+	}
+
+	for s, expected := range mockData {
+		if got := HumanIncidentStatus(s); got != expected {
+			t.Errorf("HumanIncidentStatus(%d) returned %q, want %q", s, got, expected)
+		}
+	}
+}
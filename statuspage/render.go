@@ -0,0 +1,131 @@
+package statuspage
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/status"
+)
+
+// statusColors contains the colors of the component statuses.
+var statusColors = map[int]string{
+	cachet.ComponentStatusUnknown:           "#8e8e8e",
+	cachet.ComponentStatusOperational:       "#7ed321",
+	cachet.ComponentStatusPerformanceIssues: "#3498db",
+	cachet.ComponentStatusPartialOutage:     "#f7ca18",
+	cachet.ComponentStatusMajorOutage:       "#ff6f6f",
+}
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"statusName":  status.HumanStatus,
+	"statusColor": func(status int) template.CSS { return template.CSS(statusColors[status]) },
+	"time":        func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04 MST") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Helvetica Neue", Arial, sans-serif; color: #333; background: #f0f3f4; margin: 0; }
+main { max-width: 760px; margin: 0 auto; padding: 20px; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+section, details { background: #fff; border: 1px solid #e8e8e8; border-radius: 4px; margin-bottom: 10px; }
+summary, .row { display: flex; justify-content: space-between; padding: 10px 15px; }
+summary { cursor: pointer; font-weight: bold; }
+.row + .row { border-top: 1px solid #e8e8e8; }
+.banner { color: #fff; padding: 15px; border-radius: 4px; font-weight: bold; }
+.status { font-size: 0.9em; }
+.item { padding: 10px 15px; }
+.meta { color: #888; font-size: 0.85em; }
+footer { color: #888; font-size: 0.85em; margin-top: 2em; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<div class="banner" style="background: {{statusColor .Status}}">{{.Message}}</div>
+{{with .Incidents}}<h2>Incidents</h2>
+{{range .}}<section class="item">
+<strong>{{.Name}}</strong> <span class="status">{{.HumanStatus}}</span>
+<p>{{.Message}}</p>
+<div class="meta">{{.OccurredAt}}</div>
+</section>
+{{end}}{{end}}
+{{with .Schedules}}<h2>Maintenance</h2>
+{{range .}}<section class="item">
+<strong>{{.Name}}</strong> <span class="status">{{.HumanStatus}}</span>
+<p>{{.Message}}</p>
+<div class="meta">{{.ScheduledAt}}{{with .CompletedAt}} to {{.}}{{end}}</div>
+</section>
+{{end}}{{end}}
+<h2>Components</h2>
+{{range .Groups}}<details{{if .Open}} open{{end}}>
+<summary><span>{{.Name}}</span><span class="status" style="color: {{statusColor .Status}}">{{statusName .Status}}</span></summary>
+{{range .Components}}<div class="row"><span>{{.Name}}</span><span class="status" style="color: {{statusColor .Status}}">{{statusName .Status}}</span></div>
+{{end}}</details>
+{{end}}{{with .Components}}<section>
+{{range .}}<div class="row"><span>{{.Name}}</span><span class="status" style="color: {{statusColor .Status}}">{{statusName .Status}}</span></div>
+{{end}}</section>
+{{end}}{{with .Metrics}}<h2>Metrics</h2>
+<section>
+{{range .}}<div class="row"><span>{{.Name}}</span><span>{{with .Latest}}{{.Value}}{{else}}-{{end}} {{.Suffix}}</span></div>
+{{end}}</section>
+{{end}}
+<footer>Snapshot taken at {{time .TakenAt}}.</footer>
+</main>
+</body>
+</html>
+`))
+
+// Render writes the snapshot as self-contained HTML page to w.
+func Render(w io.Writer, s *Snapshot) error {
+	return pageTemplate.Execute(w, s)
+}
+
+// WriteSite writes the snapshot into the directory dir.
+// It contains the page as index.html and the snapshot as status.json.
+// Files are replaced atomically, so a published site is never half written.
+func WriteSite(dir string, s *Snapshot) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(dir, "index.html"), func(w io.Writer) error { return Render(w, s) }); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, "status.json"), func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	})
+}
+
+// writeFile writes a file through a temporary file in the same directory.
+func writeFile(path string, write func(io.Writer) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,91 @@
+package statuspage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var testSnapshot = &Snapshot{
+	Title:   "Example <status>",
+	TakenAt: time.Date(2017, 10, 10, 12, 0, 0, 0, time.UTC),
+	Status:  cachet.ComponentStatusPartialOutage,
+	Message: "Some systems are experiencing issues",
+	Groups: []Group{
+		{
+			ComponentGroup: cachet.ComponentGroup{ID: 1, Name: "Websites"},
+			Components:     []cachet.Component{{ID: 2, Name: "Shop", Status: 3}},
+			Status:         cachet.ComponentStatusPartialOutage,
+			Open:           true,
+		},
+		{
+			ComponentGroup: cachet.ComponentGroup{ID: 3, Name: "APIs"},
+			Components:     []cachet.Component{{ID: 4, Name: "REST", Status: 1}},
+			Status:         cachet.ComponentStatusOperational,
+		},
+	},
+	Incidents: []cachet.Incident{{ID: 1, Name: "Shop slow", HumanStatus: "Identified", Message: "Database"}},
+	Metrics:   []Metric{{Metric: cachet.Metric{ID: 1, Name: "Response time", Suffix: "ms"}}},
+}
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, testSnapshot); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	got := buf.String()
+
+	expected := []string{
+		"<title>Example &lt;status&gt;</title>",
+		"Some systems are experiencing issues",
+		"<strong>Shop slow</strong> <span class=\"status\">Identified</span>",
+		"<details open>\n<summary><span>Websites</span>",
+		"<details>\n<summary><span>APIs</span>",
+		"<span>Shop</span><span class=\"status\" style=\"color: #f7ca18\">Partial Outage</span>",
+		"<span>Response time</span><span>- ms</span>",
+		"Snapshot taken at 2017-10-10 12:00 UTC.",
+	}
+	for _, e := range expected {
+		if !strings.Contains(got, e) {
+			t.Errorf("Render returned\n%s\nwithout %q", got, e)
+		}
+	}
+	if strings.Contains(got, "<h2>Maintenance</h2>") {
+		t.Error("Render returned a maintenance section without schedules")
+	}
+	if strings.Contains(got, "http") {
+		t.Error("Render returned a page with external resources")
+	}
+}
+
+func TestWriteSite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statuspage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := WriteSite(dir, testSnapshot); err != nil {
+		t.Fatalf("WriteSite returned error: %v", err)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("WriteSite wrote %d files, want index.html and status.json", len(files))
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "status.json"))
+	if err != nil {
+		t.Fatalf("WriteSite did not write status.json: %v", err)
+	}
+	got := &Snapshot{}
+	if err := json.Unmarshal(b, got); err != nil || got.Title != testSnapshot.Title {
+		t.Errorf("status.json contains %s, want the snapshot", b)
+	}
+}
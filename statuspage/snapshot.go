@@ -0,0 +1,213 @@
+/*
+Package statuspage renders a static snapshot of a status page.
+
+The snapshot is a self-contained HTML page without external resources,
+e.g. to publish it on object storage as fallback while Cachet is down:
+
+	snapshot, err := statuspage.Take(client, &statuspage.Options{Title: "Example status"})
+	err = statuspage.WriteSite("public", snapshot)
+
+Only what the public status page shows is included:
+visible component groups, enabled components, public incidents that are not fixed,
+upcoming and in progress schedules and public metrics.
+*/
+package statuspage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+	"github.com/andygrunwald/cachet/internal/api"
+	"github.com/andygrunwald/cachet/internal/fetch"
+	"github.com/andygrunwald/cachet/status"
+)
+
+// Values of ComponentGroup.Collapsed.
+const (
+	// collapsedNever shows the components of a group.
+	collapsedNever = 0
+	// collapsedAlways hides the components of a group.
+	collapsedAlways = 1
+	// collapsedUnlessIssues hides the components of a group while all of them are operational.
+	collapsedUnlessIssues = 2
+)
+
+// Options configures a snapshot.
+type Options struct {
+	// Title of the page. It defaults to "Status".
+	Title string
+	// Now is the time of the snapshot. It defaults to the current time.
+	Now time.Time
+}
+
+// Snapshot is the content of a status page at a point in time.
+type Snapshot struct {
+	Title   string    `json:"title"`
+	TakenAt time.Time `json:"taken_at"`
+
+	// Status is the worst status of all shown components.
+	Status int `json:"status"`
+	// Message is the banner text of the overall status.
+	Message string `json:"message"`
+
+	Groups     []Group            `json:"groups"`
+	Components []cachet.Component `json:"components"`
+	Incidents  []cachet.Incident  `json:"incidents"`
+	Schedules  []cachet.Schedule  `json:"schedules"`
+	Metrics    []Metric           `json:"metrics"`
+}
+
+// Group is a visible component group with its enabled components.
+type Group struct {
+	cachet.ComponentGroup
+	Components []cachet.Component `json:"components"`
+	// Status is the worst status of the components.
+	Status int `json:"status"`
+	// Open reports whether the components are shown, see ComponentGroup.Collapsed.
+	Open bool `json:"open"`
+}
+
+// Metric is a public metric along with its latest point.
+type Metric struct {
+	cachet.Metric
+	Latest *cachet.Point `json:"latest,omitempty"`
+}
+
+// Take fetches everything shown on the public status page.
+func Take(client *cachet.Client, o *Options) (*Snapshot, error) {
+	if o == nil {
+		o = &Options{}
+	}
+	s := &Snapshot{Title: o.Title, TakenAt: o.Now}
+	if len(s.Title) == 0 {
+		s.Title = "Status"
+	}
+	if s.TakenAt.IsZero() {
+		s.TakenAt = time.Now()
+	}
+
+	groups, err := fetch.ComponentGroups(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	components, err := fetch.Components(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := fetch.Incidents(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := fetch.Schedules(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := fetch.Metrics(client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.addComponents(groups, components)
+	// Like Status, the message only takes the shown components into account.
+	shown := append([]cachet.Component(nil), s.Components...)
+	for _, g := range s.Groups {
+		shown = append(shown, g.Components...)
+	}
+	s.Message = status.Compute(nil, shown, nil).Message
+
+	for _, i := range incidents {
+		if i.Visible == cachet.IncidentVisibilityPublic && i.Status != cachet.IncidentStatusFixed && i.Status != cachet.IncidentStatusScheduled {
+			s.Incidents = append(s.Incidents, i)
+		}
+	}
+	sort.SliceStable(s.Incidents, func(i, j int) bool { return s.Incidents[i].ID > s.Incidents[j].ID })
+
+	for _, schedule := range schedules {
+		if schedule.Status != cachet.ScheduleComplete {
+			s.Schedules = append(s.Schedules, schedule)
+		}
+	}
+	sort.SliceStable(s.Schedules, func(i, j int) bool { return s.Schedules[i].ScheduledAt < s.Schedules[j].ScheduledAt })
+
+	sort.SliceStable(metrics, func(i, j int) bool { return api.Less(metrics[i].Order, metrics[i].ID, metrics[j].Order, metrics[j].ID) })
+	for _, m := range metrics {
+		if m.Visible != cachet.MetricsVisibilityPublic {
+			continue
+		}
+		metric := Metric{Metric: m}
+		points, _, err := client.Metrics.GetPoints(m.ID)
+		if err != nil {
+			return nil, err
+		}
+		if points != nil {
+			for n := range *points {
+				p := (*points)[n]
+				if metric.Latest == nil || p.CreatedAt > metric.Latest.CreatedAt || (p.CreatedAt == metric.Latest.CreatedAt && p.ID > metric.Latest.ID) {
+					metric.Latest = &p
+				}
+			}
+		}
+		s.Metrics = append(s.Metrics, metric)
+	}
+
+	return s, nil
+}
+
+// addComponents sorts the enabled components into the visible groups.
+// Components of hidden groups are not shown at all.
+func (s *Snapshot) addComponents(groups []cachet.ComponentGroup, components []cachet.Component) {
+	sort.SliceStable(groups, func(i, j int) bool { return api.Less(groups[i].Order, groups[i].ID, groups[j].Order, groups[j].ID) })
+	sort.SliceStable(components, func(i, j int) bool {
+		return api.Less(components[i].Order, components[i].ID, components[j].Order, components[j].ID)
+	})
+
+	index := map[int]int{}
+	hidden := map[int]bool{}
+	for _, g := range groups {
+		if g.Visible != cachet.ComponentGroupVisibilityPublic {
+			hidden[g.ID] = true
+			continue
+		}
+		index[g.ID] = len(s.Groups)
+		s.Groups = append(s.Groups, Group{ComponentGroup: g})
+	}
+
+	for _, c := range components {
+		if !c.Enabled || hidden[c.GroupID] {
+			continue
+		}
+		if c.Status > s.Status {
+			s.Status = c.Status
+		}
+
+		i, ok := index[c.GroupID]
+		if !ok {
+			s.Components = append(s.Components, c)
+			continue
+		}
+		g := &s.Groups[i]
+		g.Components = append(g.Components, c)
+		if c.Status > g.Status {
+			g.Status = c.Status
+		}
+	}
+
+	// Groups without enabled components are not shown.
+	var visible []Group
+	for _, g := range s.Groups {
+		if len(g.Components) == 0 {
+			continue
+		}
+		switch g.Collapsed {
+		case collapsedAlways:
+			g.Open = false
+		case collapsedUnlessIssues:
+			g.Open = g.Status > cachet.ComponentStatusOperational
+		default:
+			g.Open = true
+		}
+		visible = append(visible, g)
+	}
+	s.Groups = visible
+}
@@ -0,0 +1,173 @@
+package statuspage
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+// handleInstance serves an instance with visible and hidden entities of every kind.
+func handleInstance() {
+	responses := map[string]string{
+		"/api/v1/components/groups": `{"data":[
+			{"id":1,"name":"Websites","order":2,"visible":1,"collapsed":2},
+			{"id":2,"name":"Internal","order":1,"visible":0},
+			{"id":3,"name":"APIs","order":1,"visible":1,"collapsed":1},
+			{"id":4,"name":"Empty","visible":1}
+		]}`,
+		"/api/v1/components": `{"data":[
+			{"id":1,"name":"Blog","status":1,"enabled":true,"group_id":1},
+			{"id":2,"name":"Shop","status":3,"enabled":true,"group_id":1},
+			{"id":3,"name":"Wiki","status":4,"enabled":true,"group_id":2},
+			{"id":4,"name":"REST","status":1,"enabled":true,"group_id":3},
+			{"id":5,"name":"Old","status":4,"enabled":false,"group_id":3},
+			{"id":6,"name":"DNS","status":2,"enabled":true},
+			{"id":7,"name":"Disabled","status":1,"enabled":false,"group_id":4}
+		]}`,
+		"/api/v1/incidents": `{"data":[
+			{"id":1,"name":"Shop slow","status":2,"visible":1},
+			{"id":2,"name":"Fixed","status":4,"visible":1},
+			{"id":3,"name":"Hidden","status":1,"visible":0}
+		]}`,
+		"/api/v1/schedules": `{"data":[
+			{"id":2,"name":"Later","status":0,"scheduled_at":"2017-10-12 10:00:00"},
+			{"id":1,"name":"Sooner","status":1,"scheduled_at":"2017-10-10 10:00:00"},
+			{"id":3,"name":"Done","status":2,"scheduled_at":"2017-10-01 10:00:00"}
+		]}`,
+		"/api/v1/metrics": `{"data":[
+			{"id":1,"name":"Response time","suffix":"ms","visible":1},
+			{"id":2,"name":"Hidden","visible":2}
+		]}`,
+		"/api/v1/metrics/1/points": `{"data":[
+			{"id":1,"value":100,"created_at":"2017-10-10 10:00:00"},
+			{"id":2,"value":120,"created_at":"2017-10-10 10:01:00"}
+		]}`,
+	}
+	for path, response := range responses {
+		response := response
+		testMux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, response)
+		})
+	}
+}
+
+func TestTake(t *testing.T) {
+	setup()
+	defer teardown()
+	handleInstance()
+
+	now := time.Date(2017, 10, 10, 12, 0, 0, 0, time.UTC)
+	got, err := Take(testClient, &Options{Now: now})
+	if err != nil {
+		t.Fatalf("Take returned error: %v", err)
+	}
+
+	expected := &Snapshot{
+		Title:   "Status",
+		TakenAt: now,
+		Status:  cachet.ComponentStatusPartialOutage,
+		Message: "Some systems are experiencing issues",
+		Groups: []Group{
+			{
+				ComponentGroup: cachet.ComponentGroup{ID: 3, Name: "APIs", Order: 1, Visible: 1, Collapsed: 1},
+				Components:     []cachet.Component{{ID: 4, Name: "REST", Status: 1, Enabled: true, GroupID: 3}},
+				Status:         cachet.ComponentStatusOperational,
+				Open:           false,
+			},
+			{
+				ComponentGroup: cachet.ComponentGroup{ID: 1, Name: "Websites", Order: 2, Visible: 1, Collapsed: 2},
+				Components: []cachet.Component{
+					{ID: 1, Name: "Blog", Status: 1, Enabled: true, GroupID: 1},
+					{ID: 2, Name: "Shop", Status: 3, Enabled: true, GroupID: 1},
+				},
+				Status: cachet.ComponentStatusPartialOutage,
+				Open:   true,
+			},
+		},
+		Components: []cachet.Component{{ID: 6, Name: "DNS", Status: 2, Enabled: true}},
+		Incidents:  []cachet.Incident{{ID: 1, Name: "Shop slow", Status: 2, Visible: 1}},
+		Schedules: []cachet.Schedule{
+			{ID: 1, Name: "Sooner", Status: 1, ScheduledAt: "2017-10-10 10:00:00"},
+			{ID: 2, Name: "Later", Status: 0, ScheduledAt: "2017-10-12 10:00:00"},
+		},
+		Metrics: []Metric{{
+			Metric: cachet.Metric{ID: 1, Name: "Response time", Suffix: "ms", Visible: 1},
+			Latest: &cachet.Point{ID: 2, Value: 120, CreatedAt: "2017-10-10 10:01:00"},
+		}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Take returned %+v, want %+v", got, expected)
+	}
+}
+
+func TestTake_Error(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	})
+
+	if _, err := Take(testClient, nil); err == nil {
+		t.Error("Take returned no error. Expected one.")
+	}
+}
+
+func TestTake_HiddenComponents(t *testing.T) {
+	setup()
+	defer teardown()
+
+	responses := map[string]string{
+		"/api/v1/components/groups": `{"data":[{"id":1,"name":"Internal","visible":0}]}`,
+		"/api/v1/components": `{"data":[
+			{"id":1,"name":"Wiki","status":4,"enabled":true,"group_id":1},
+			{"id":2,"name":"Jenkins","status":4,"enabled":true,"group_id":1},
+			{"id":3,"name":"Blog","status":1,"enabled":true}
+		]}`,
+		"/api/v1/incidents": `{"data":[]}`,
+		"/api/v1/schedules": `{"data":[]}`,
+		"/api/v1/metrics":   `{"data":[]}`,
+	}
+	for path, response := range responses {
+		response := response
+		testMux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, response)
+		})
+	}
+
+	got, err := Take(testClient, nil)
+	if err != nil {
+		t.Fatalf("Take returned error: %v", err)
+	}
+	if got.Status != cachet.ComponentStatusOperational || got.Message != "System operational" {
+		t.Errorf("Take returned status %d with message %q, want %d with %q", got.Status, got.Message, cachet.ComponentStatusOperational, "System operational")
+	}
+}
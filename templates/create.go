@@ -0,0 +1,70 @@
+package templates
+
+import (
+	"fmt"
+
+	"github.com/andygrunwald/cachet"
+)
+
+// CreateIncident renders the template with the given name and creates an incident from it.
+// Fields of base that are not set by the template, like the component, are kept.
+// The name of base is used if the template has no title.
+// Incidents are public unless base sets a visibility.
+func (r *Registry) CreateIncident(client *cachet.Client, name string, vars map[string]string, base *cachet.Incident) (*cachet.Incident, *cachet.Response, error) {
+	rendered, err := r.Preview(name, vars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i := cachet.Incident{}
+	if base != nil {
+		i = *base
+	}
+	if len(rendered.Name) > 0 {
+		i.Name = rendered.Name
+	}
+	if len(i.Name) == 0 {
+		return nil, nil, fmt.Errorf("Template %q renders no incident name", name)
+	}
+	i.Message = rendered.Message
+	i.Status = rendered.Status
+	if rendered.ComponentStatus > 0 {
+		i.ComponentStatus = rendered.ComponentStatus
+	}
+	if i.Visible == 0 {
+		i.Visible = cachet.IncidentVisibilityPublic
+	}
+
+	return client.Incidents.Create(&i)
+}
+
+// CreateUpdate renders the template with the given name and adds it as update to the incident with the ID incidentID.
+// A component status of the template is applied to the component of the incident.
+// It is dropped for incidents without component.
+func (r *Registry) CreateUpdate(client *cachet.Client, incidentID int, name string, vars map[string]string) (*cachet.IncidentUpdate, *cachet.Response, error) {
+	rendered, err := r.Preview(name, vars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u := &cachet.IncidentUpdate{
+		Status:  rendered.Status,
+		Message: rendered.Message,
+	}
+	if rendered.ComponentStatus > 0 {
+		// Cachet only changes the component of an update with the component ID.
+		i, resp, err := client.Incidents.Get(incidentID)
+		if err != nil {
+			return nil, resp, err
+		}
+		if i == nil {
+			return nil, resp, fmt.Errorf("Empty response from the Cachet API")
+		}
+		if i.ComponentID > 0 {
+			u.ComponentID = i.ComponentID
+			u.ComponentStatus = rendered.ComponentStatus
+		}
+	}
+
+	return client.IncidentUpdates.Create(incidentID, u)
+}
@@ -0,0 +1,143 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/cachet"
+)
+
+var (
+	// testMux is the HTTP request multiplexer used with the test server.
+	testMux *http.ServeMux
+
+	// testClient is the cachet client being tested.
+	testClient *cachet.Client
+
+	// testServer is a test HTTP server used to provide mock API responses.
+	testServer *httptest.Server
+)
+
+// setup sets up a test HTTP server along with a cachet.Client that is configured to talk to that test server.
+func setup() {
+	testMux = http.NewServeMux()
+	testServer = httptest.NewServer(testMux)
+	testClient, _ = cachet.NewClient(testServer.URL, nil)
+}
+
+// teardown closes the test HTTP server.
+func teardown() {
+	testServer.Close()
+}
+
+func TestRegistry_CreateIncident(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Request method = %v, want POST", r.Method)
+		}
+		var got map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&got)
+		expected := map[string]interface{}{
+			"name":             "users is unavailable",
+			"message":          "The database users does not respond. We expect it back in 1h0m0s.",
+			"status":           float64(1),
+			"component_id":     float64(3),
+			"component_status": float64(4),
+			"visible":          float64(1),
+			"notify":           true,
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Request body = %v, want %v", got, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":7,"name":"users is unavailable"}}`)
+	})
+
+	r := testRegistry(t)
+	i, _, err := r.CreateIncident(testClient, "database-outage", map[string]string{"database": "users", "eta": "1h"}, &cachet.Incident{ComponentID: 3, Notify: true})
+	if err != nil {
+		t.Fatalf("CreateIncident returned error: %v", err)
+	}
+	if i.ID != 7 {
+		t.Errorf("CreateIncident returned %+v", i)
+	}
+
+	if _, _, err := r.CreateIncident(testClient, "database-outage", nil, nil); err == nil {
+		t.Error("CreateIncident returned no error for missing variables")
+	}
+}
+
+func TestRegistry_CreateIncident_NoName(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&Template{Name: "fixed", Message: "Resolved", Status: cachet.IncidentStatusFixed})
+
+	if _, _, err := r.CreateIncident(nil, "fixed", nil, nil); err == nil {
+		t.Error("CreateIncident returned no error without an incident name")
+	}
+}
+
+func TestRegistry_CreateUpdate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents/7", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":7,"component_id":3}}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents/7/updates", func(w http.ResponseWriter, r *http.Request) {
+		var got map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&got)
+		expected := map[string]interface{}{"status": float64(4), "message": "Resolved after 2 hours", "component_id": float64(3), "component_status": float64(1)}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Request body = %v, want %v", got, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":9,"incident_id":7}}`)
+	})
+
+	r := NewRegistry()
+	r.Register(&Template{
+		Name:            "fixed",
+		Message:         "Resolved after {{.hours}} hours",
+		Status:          cachet.IncidentStatusFixed,
+		ComponentStatus: cachet.ComponentStatusOperational,
+		Vars:            []Var{{Name: "hours", Type: TypeInt, Required: true}},
+	})
+
+	u, _, err := r.CreateUpdate(testClient, 7, "fixed", map[string]string{"hours": "2"})
+	if err != nil {
+		t.Fatalf("CreateUpdate returned error: %v", err)
+	}
+	if u.ID != 9 {
+		t.Errorf("CreateUpdate returned %+v", u)
+	}
+}
+
+func TestRegistry_CreateUpdate_NoComponent(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/api/v1/incidents/7", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":7}}`)
+	})
+	testMux.HandleFunc("/api/v1/incidents/7/updates", func(w http.ResponseWriter, r *http.Request) {
+		var got map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&got)
+		expected := map[string]interface{}{"status": float64(4), "message": "Resolved"}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Request body = %v, want %v", got, expected)
+		}
+		fmt.Fprint(w, `{"data":{"id":9,"incident_id":7}}`)
+	})
+
+	r := NewRegistry()
+	r.Register(&Template{Name: "fixed", Message: "Resolved", Status: cachet.IncidentStatusFixed, ComponentStatus: cachet.ComponentStatusOperational})
+
+	if _, _, err := r.CreateUpdate(testClient, 7, "fixed", nil); err != nil {
+		t.Fatalf("CreateUpdate returned error: %v", err)
+	}
+}
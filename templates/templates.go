@@ -0,0 +1,220 @@
+/*
+Package templates manages incident templates with typed variables.
+
+A template renders the name and message of incidents and incident updates
+with Go text/template. Templates are registered in code or loaded from YAML or JSON files:
+
+	name: database-outage
+	title: "{{.database}} is unavailable"
+	message: |
+	  The database {{.database}} does not respond. We expect it back in {{.eta}}.
+	status: 1
+	component_status: 4
+	vars:
+	  - name: database
+	    required: true
+	  - name: eta
+	    type: duration
+	    default: 30m
+
+Variables are passed as strings and converted to their type before rendering:
+
+	registry := templates.NewRegistry()
+	err := registry.LoadDir("templates/")
+
+	preview, err := registry.Preview("database-outage", map[string]string{"database": "users"})
+
+	incident, _, err := registry.CreateIncident(client, "database-outage",
+		map[string]string{"database": "users"}, &cachet.Incident{ComponentID: 3})
+*/
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Template is an incident template.
+type Template struct {
+	Name string `yaml:"name" json:"name"`
+	// Title renders the name of incidents. It is not used for incident updates.
+	Title string `yaml:"title" json:"title"`
+	// Message renders the message of incidents and incident updates.
+	Message string `yaml:"message" json:"message"`
+	// Status is the cachet.IncidentStatus* of incidents and updates created from the template.
+	Status int `yaml:"status" json:"status"`
+	// ComponentStatus is the cachet.ComponentStatus* set by the template. 0 leaves the component untouched.
+	ComponentStatus int   `yaml:"component_status" json:"component_status"`
+	Vars            []Var `yaml:"vars" json:"vars"`
+
+	title   *template.Template
+	message *template.Template
+}
+
+// compile parses the title and message of the template and checks its variables.
+func (t *Template) compile() error {
+	if len(t.Name) == 0 {
+		return fmt.Errorf("Template without a name")
+	}
+	if len(t.Message) == 0 {
+		return fmt.Errorf("Template %q has no message", t.Name)
+	}
+
+	vars := map[string]bool{}
+	for _, v := range t.Vars {
+		if len(v.Name) == 0 {
+			return fmt.Errorf("Template %q has a variable without a name", t.Name)
+		}
+		if vars[v.Name] {
+			return fmt.Errorf("Template %q defines the variable %q twice", t.Name, v.Name)
+		}
+		vars[v.Name] = true
+
+		if _, ok := converters[v.kind()]; !ok {
+			return fmt.Errorf("Template %q: variable %q has the unknown type %q", t.Name, v.Name, v.Type)
+		}
+		if len(v.Default) > 0 {
+			if _, err := v.convert(v.Default); err != nil {
+				return fmt.Errorf("Template %q: invalid default of variable %q: %v", t.Name, v.Name, err)
+			}
+		}
+	}
+
+	var err error
+	if t.title, err = template.New(t.Name + ".title").Option("missingkey=error").Parse(t.Title); err != nil {
+		return fmt.Errorf("Template %q: %v", t.Name, err)
+	}
+	if t.message, err = template.New(t.Name + ".message").Option("missingkey=error").Parse(t.Message); err != nil {
+		return fmt.Errorf("Template %q: %v", t.Name, err)
+	}
+	return nil
+}
+
+// Registry is a set of templates identified by their name.
+// It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	templates map[string]*Template
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{templates: map[string]*Template{}}
+}
+
+// Register validates and adds the template t.
+// Registering a name twice is an error.
+func (r *Registry) Register(t *Template) error {
+	if err := t.compile(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.templates[t.Name]; ok {
+		return fmt.Errorf("Template %q is registered twice", t.Name)
+	}
+	r.templates[t.Name] = t
+	return nil
+}
+
+// Load reads a YAML or JSON template from r and registers it.
+// Unknown fields are reported as error to catch typos early.
+func (r *Registry) Load(rd io.Reader) error {
+	b, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+
+	t := &Template{}
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.DisallowUnknownFields()
+		err = d.Decode(t)
+	} else {
+		err = yaml.UnmarshalStrict(b, t)
+	}
+	if err != nil {
+		return err
+	}
+	return r.Register(t)
+}
+
+// LoadFile reads a YAML or JSON template from the file path and registers it.
+func (r *Registry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.Load(f); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// LoadDir registers all templates of the directory dir with the extension .yaml, .yml or .json.
+func (r *Registry) LoadDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		switch filepath.Ext(f.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if f.IsDir() {
+			continue
+		}
+		if err := r.LoadFile(filepath.Join(dir, f.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the template with the given name.
+func (r *Registry) Get(name string) (*Template, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.templates[name]
+	return t, ok
+}
+
+// Names returns the sorted names of all templates.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// template returns the template with the given name or an error.
+func (r *Registry) template(name string) (*Template, error) {
+	t, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("Unknown template %q", name)
+	}
+	return t, nil
+}
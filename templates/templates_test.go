@@ -0,0 +1,101 @@
+package templates
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testTemplate = `name: database-outage
+title: "{{.database}} is unavailable"
+message: |
+  The database {{.database}} does not respond. We expect it back in {{.eta}}.
+status: 1
+component_status: 4
+vars:
+  - name: database
+    required: true
+  - name: eta
+    type: duration
+    default: 30m
+`
+
+func TestRegistry_Load(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Load(strings.NewReader(testTemplate)); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if err := r.Load(strings.NewReader(`{"name":"fixed","message":"Resolved","status":4}`)); err != nil {
+		t.Fatalf("Load returned error for JSON: %v", err)
+	}
+
+	if got, want := r.Names(), []string{"database-outage", "fixed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names returned %v, want %v", got, want)
+	}
+	tmpl, ok := r.Get("database-outage")
+	if !ok || tmpl.ComponentStatus != 4 || len(tmpl.Vars) != 2 {
+		t.Errorf("Get returned %+v, %v", tmpl, ok)
+	}
+	if _, ok := r.Get("unknown"); ok {
+		t.Error("Get returned an unknown template")
+	}
+}
+
+func TestRegistry_Register_Invalid(t *testing.T) {
+	mockData := []struct {
+		template *Template
+		expected string
+	}{
+		{&Template{Message: "m"}, "Template without a name"},
+		{&Template{Name: "a"}, `Template "a" has no message`},
+		{&Template{Name: "a", Message: "{{.x"}, `Template "a": template: a.message:1: unclosed action`},
+		{&Template{Name: "a", Message: "m", Vars: []Var{{Name: "x"}, {Name: "x"}}}, `Template "a" defines the variable "x" twice`},
+		{&Template{Name: "a", Message: "m", Vars: []Var{{Name: "x", Type: "date"}}}, `Template "a": variable "x" has the unknown type "date"`},
+		{&Template{Name: "a", Message: "m", Vars: []Var{{Name: "x", Type: "int", Default: "many"}}}, `Template "a": invalid default of variable "x": "many" is no valid int`},
+	}
+
+	for _, data := range mockData {
+		err := NewRegistry().Register(data.template)
+		if err == nil || err.Error() != data.expected {
+			t.Errorf("Register returned %v, want %q", err, data.expected)
+		}
+	}
+
+	r := NewRegistry()
+	r.Register(&Template{Name: "a", Message: "m"})
+	if err := r.Register(&Template{Name: "a", Message: "m"}); err == nil {
+		t.Error("Register returned no error for a duplicate name")
+	}
+	if err := r.Load(strings.NewReader("name: a\nmesage: typo\n")); err == nil {
+		t.Error("Load returned no error for an unknown field")
+	}
+}
+
+func TestRegistry_LoadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "outage.yaml"), []byte(testTemplate), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "fixed.json"), []byte(`{"name":"fixed","message":"Resolved","status":4}`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("# Templates"), 0644)
+
+	r := NewRegistry()
+	if err := r.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir returned error: %v", err)
+	}
+	if got, want := r.Names(), []string{"database-outage", "fixed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names returned %v, want %v", got, want)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "broken.yml"), []byte("name: broken\n"), 0644)
+	err = NewRegistry().LoadDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "broken.yml") {
+		t.Errorf("LoadDir returned %v, want an error naming the file", err)
+	}
+}
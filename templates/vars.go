@@ -0,0 +1,189 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of variables.
+const (
+	TypeString   = "string"
+	TypeInt      = "int"
+	TypeFloat    = "float"
+	TypeBool     = "bool"
+	TypeDuration = "duration"
+)
+
+// converters convert the string value of a variable to its type.
+var converters = map[string]func(string) (interface{}, error){
+	TypeString: func(s string) (interface{}, error) { return s, nil },
+	TypeInt: func(s string) (interface{}, error) {
+		return strconv.Atoi(s)
+	},
+	TypeFloat: func(s string) (interface{}, error) {
+		return strconv.ParseFloat(s, 64)
+	},
+	TypeBool: func(s string) (interface{}, error) {
+		return strconv.ParseBool(s)
+	},
+	TypeDuration: func(s string) (interface{}, error) {
+		return time.ParseDuration(s)
+	},
+}
+
+// Var is a variable of a template.
+type Var struct {
+	Name string `yaml:"name" json:"name"`
+	// Type is one of the Type* constants. It defaults to TypeString.
+	Type        string `yaml:"type" json:"type"`
+	Description string `yaml:"description" json:"description"`
+	// Required variables have to be passed, optional variables without a value use Default.
+	Required bool   `yaml:"required" json:"required"`
+	Default  string `yaml:"default" json:"default"`
+}
+
+func (v Var) kind() string {
+	if len(v.Type) == 0 {
+		return TypeString
+	}
+	return v.Type
+}
+
+// convert converts the string s to the type of the variable.
+func (v Var) convert(s string) (interface{}, error) {
+	value, err := converters[v.kind()](s)
+	if err != nil {
+		return nil, fmt.Errorf("%q is no valid %s", s, v.kind())
+	}
+	return value, nil
+}
+
+// ValidationError lists all problems with the variables passed to a template.
+type ValidationError struct {
+	Template string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("Invalid variables for template %q: %s", e.Template, strings.Join(e.Problems, "; "))
+}
+
+// Validate checks that vars contains all required variables of the template,
+// no unknown variables and only values of the right type.
+// Problems are reported as *ValidationError.
+func (t *Template) Validate(vars map[string]string) error {
+	_, err := t.values(vars)
+	return err
+}
+
+// values converts vars to the data passed to the templates.
+func (t *Template) values(vars map[string]string) (map[string]interface{}, error) {
+	var problems []string
+	values := map[string]interface{}{}
+
+	known := map[string]bool{}
+	for _, v := range t.Vars {
+		known[v.Name] = true
+
+		s, ok := vars[v.Name]
+		switch {
+		case ok:
+		case v.Required:
+			problems = append(problems, fmt.Sprintf("%s is required", v.Name))
+			continue
+		case len(v.Default) == 0:
+			// Optional variables without default are the zero value of their type.
+			values[v.Name] = zero(v.kind())
+			continue
+		default:
+			s = v.Default
+		}
+
+		value, err := v.convert(s)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", v.Name, err))
+			continue
+		}
+		values[v.Name] = value
+	}
+
+	var unknown []string
+	for name := range vars {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, fmt.Sprintf("%s is unknown", name))
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Template: t.Name, Problems: problems}
+	}
+	return values, nil
+}
+
+// zero returns the zero value of a type.
+func zero(kind string) interface{} {
+	switch kind {
+	case TypeInt:
+		return 0
+	case TypeFloat:
+		return 0.0
+	case TypeBool:
+		return false
+	case TypeDuration:
+		return time.Duration(0)
+	}
+	return ""
+}
+
+// Rendered is a template rendered with variables.
+type Rendered struct {
+	Template        string
+	Name            string
+	Message         string
+	Status          int
+	ComponentStatus int
+	// Vars are the converted variables as passed to the template.
+	Vars map[string]interface{}
+}
+
+// Render validates vars and renders the template.
+func (t *Template) Render(vars map[string]string) (*Rendered, error) {
+	values, err := t.values(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var title, message bytes.Buffer
+	if err := t.title.Execute(&title, values); err != nil {
+		return nil, fmt.Errorf("Failed to render the title of template %q: %v", t.Name, err)
+	}
+	if err := t.message.Execute(&message, values); err != nil {
+		return nil, fmt.Errorf("Failed to render the message of template %q: %v", t.Name, err)
+	}
+
+	return &Rendered{
+		Template:        t.Name,
+		Name:            strings.TrimSpace(title.String()),
+		Message:         strings.TrimSpace(message.String()),
+		Status:          t.Status,
+		ComponentStatus: t.ComponentStatus,
+		Vars:            values,
+	}, nil
+}
+
+// Preview renders the template with the given name without creating anything.
+func (r *Registry) Preview(name string, vars map[string]string) (*Rendered, error) {
+	t, err := r.template(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.Render(vars)
+}
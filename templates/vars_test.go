@@ -0,0 +1,85 @@
+package templates
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRegistry(t *testing.T) *Registry {
+	r := NewRegistry()
+	if err := r.Load(strings.NewReader(testTemplate)); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	return r
+}
+
+func TestRegistry_Preview(t *testing.T) {
+	r := testRegistry(t)
+
+	got, err := r.Preview("database-outage", map[string]string{"database": "users"})
+	if err != nil {
+		t.Fatalf("Preview returned error: %v", err)
+	}
+	expected := &Rendered{
+		Template:        "database-outage",
+		Name:            "users is unavailable",
+		Message:         "The database users does not respond. We expect it back in 30m0s.",
+		Status:          1,
+		ComponentStatus: 4,
+		Vars:            map[string]interface{}{"database": "users", "eta": 30 * time.Minute},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Preview returned %+v, want %+v", got, expected)
+	}
+
+	if _, err := r.Preview("unknown", nil); err == nil {
+		t.Error("Preview returned no error for an unknown template")
+	}
+}
+
+func TestTemplate_Validate(t *testing.T) {
+	tmpl, _ := testRegistry(t).Get("database-outage")
+
+	err := tmpl.Validate(map[string]string{"eta": "soon", "region": "eu"})
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("Validate returned %v, want a *ValidationError", err)
+	}
+	expected := []string{"database is required", `eta: "soon" is no valid duration`, "region is unknown"}
+	if !reflect.DeepEqual(verr.Problems, expected) {
+		t.Errorf("Validate returned problems %v, want %v", verr.Problems, expected)
+	}
+	if !strings.HasPrefix(err.Error(), `Invalid variables for template "database-outage": database is required; `) {
+		t.Errorf("Validate returned the message %q", err.Error())
+	}
+
+	if err := tmpl.Validate(map[string]string{"database": "users", "eta": "1h"}); err != nil {
+		t.Errorf("Validate returned error for valid variables: %v", err)
+	}
+}
+
+func TestTemplate_Render_Types(t *testing.T) {
+	tmpl := &Template{
+		Name:    "types",
+		Message: "{{.count}} {{.ratio}} {{if .down}}down{{end}} {{printf \"%q\" .note}}",
+		Vars: []Var{
+			{Name: "count", Type: TypeInt},
+			{Name: "ratio", Type: TypeFloat, Default: "0.5"},
+			{Name: "down", Type: TypeBool},
+			{Name: "note"},
+		},
+	}
+	if err := NewRegistry().Register(tmpl); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+
+	got, err := tmpl.Render(map[string]string{"count": "3", "down": "true"})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if want := `3 0.5 down ""`; got.Message != want {
+		t.Errorf("Render returned %q, want %q", got.Message, want)
+	}
+}